@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"rgs/clock"
+	"rgs/handlers"
+	"rgs/middleware"
+	"rgs/migrations"
+	"rgs/observability"
+	"rgs/services"
+	"rgs/sqlc"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Worker is a background loop that runs until its context is cancelled.
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+type App struct {
+	Router  *chi.Mux
+	Ops     *chi.Mux
+	Health  *services.HealthService
+	Bus     *services.EventBus
+	Leader  *services.LeaderElector
+	Workers []Worker
+}
+
+// Build wires services, workers and routers. The caller runs the workers
+// and serves the routers.
+//
+// db is the privileged pool workers use. apiDB is nil unless row-level
+// security is on, in which case API requests query through it instead,
+// pinned to their operator.
+func Build(db, apiDB *sql.DB, cfg Config) *App {
+	queries := sqlc.New(db)
+
+	var api services.DB = db
+	var tenants middleware.TenantPinner
+	if apiDB != nil {
+		tenantDB := services.NewTenantDB(apiDB)
+		api, tenants = tenantDB, tenantDB
+	}
+	apiQueries := sqlc.New(api)
+
+	walletClient := services.NewWalletClient(
+		cfg.Wallet.URL,
+		cfg.Wallet.Secret,
+		cfg.Wallet.Timeout,
+	)
+
+	eventBus := services.NewEventBus(cfg.Events.BufferSize)
+	ledgerSvc := services.NewLedgerService(apiQueries)
+	clk := clock.Real{}
+	heartbeats := services.NewHeartbeats(clk)
+
+	eventDispatcher := services.NewEventDispatcher(
+		queries, services.SQLTx[services.EventRepo](db, queries), clk,
+		cfg.Workers.ID,
+		cfg.Workers.EventDispatcherInterval,
+		heartbeats.Register("event_dispatcher", cfg.Workers.EventDispatcherInterval+time.Minute),
+		eventBus,
+	)
+
+	outboxWorker := services.NewOutboxWorker(
+		queries, services.SQLTx[services.OutboxRepo](db, queries), clk,
+		walletClient, eventBus, eventDispatcher, ledgerSvc,
+		services.DefaultOutboxRetryPolicy,
+		cfg.Workers.ID,
+		cfg.Workers.OutboxInterval,
+		heartbeats.Register("outbox_worker", cfg.Workers.OutboxInterval+2*time.Minute),
+	)
+
+	webhookWorker := services.NewWebhookWorker(
+		queries, clk, eventBus, cfg.Workers.ID,
+		cfg.Webhooks.RetryPolicy(),
+		cfg.Webhooks.WebhookConcurrency(),
+		cfg.Workers.WebhookInterval,
+		heartbeats.Register("webhook_worker", cfg.Workers.WebhookInterval+10*time.Minute),
+	)
+
+	reconciliationSvc := services.NewReconciliationService(queries, walletClient, services.DefaultOutboxRetryPolicy)
+	var leader *services.LeaderElector
+	if cfg.Workers.LeaderElection {
+		leader = services.NewLeaderElector(db, "rgs.singleton-jobs", cfg.Workers.ID)
+	}
+	reconciliationWorker := services.NewReconciliationWorker(
+		reconciliationSvc, leader, clk,
+		cfg.Workers.ReconciliationInterval,
+		heartbeats.Register("reconciliation_worker", cfg.Workers.ReconciliationInterval+time.Hour),
+	)
+
+	healthSvc := services.NewHealthService(db, walletClient, heartbeats, migrations.Latest())
+
+	complianceSvc := services.NewComplianceService(apiQueries)
+
+	// Services (business logic)
+	sessionsSvc := services.NewSessionsService(
+		apiQueries, services.SQLTx[services.SessionRepo](api, apiQueries), clk,
+		eventDispatcher, complianceSvc,
+	)
+	betAgg := services.NewBetAggregate(
+		apiQueries, services.SQLTx[services.BetRepo](api, apiQueries),
+		walletClient, eventDispatcher, complianceSvc, ledgerSvc,
+	)
+	webhookSvc := services.NewWebhookService(apiQueries, clk, complianceSvc, cfg.Webhooks.RetryPolicy())
+	endpointSvc := services.NewWebhookEndpointService(
+		apiQueries, services.SQLTx[services.WebhookEndpointRepo](api, apiQueries), clk, complianceSvc,
+	)
+	outboxSvc := services.NewOutboxService(apiQueries, clk)
+
+	// Handlers
+	sessionsHandler := handlers.NewSessionsHandler(sessionsSvc)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+	endpointHandler := handlers.NewWebhookEndpointHandler(endpointSvc)
+	outboxHandler := handlers.NewOutboxHandler(outboxSvc)
+	betsHandler := handlers.NewBetsHandler(betAgg)
+	roundsHandler := handlers.NewRoundsHandler(apiQueries)
+	sseHandler := handlers.NewSSEHandler(eventBus, clk)
+	auditHandler := handlers.NewAuditHandler(complianceSvc)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerSvc, clk)
+	reconciliationHandler := handlers.NewReconciliationHandler(services.NewReconciliationService(apiQueries, walletClient, services.DefaultOutboxRetryPolicy))
+	healthHandler := handlers.NewHealthHandler(healthSvc)
+
+	// Router
+	r := chi.NewRouter()
+	opMiddleware := middleware.NewOperatorMiddleware(apiQueries, tenants)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+	webhookToolsLimiter := middleware.NewRateLimiterPerMinute(cfg.RateLimit.WebhookToolsPerMinute)
+	r.Use(opMiddleware.Handle)
+	r.Use(rateLimiter.Limit)
+	r.Use(observability.MetricsMiddleware)
+	r.Use(middleware.OTelMiddleware)
+
+	// Sessions
+	r.Post("/sessions/launch", sessionsHandler.LaunchSession)
+	r.Post("/sessions/revoke", sessionsHandler.RevokeSession)
+	r.Get("/sessions/verify", sessionsHandler.VerifySession)
+
+	// Bets
+	r.Post("/bets", betsHandler.PlaceBet)
+
+	// Rounds
+	r.Get("/rounds/{id}", roundsHandler.GetRound)
+
+	// Webhooks
+	r.Get("/webhooks", webhookHandler.ListWebhooks)
+	r.Post("/webhooks/retry/{id}", webhookHandler.RetryWebhook)
+	r.With(webhookToolsLimiter.Limit).Post("/webhooks/test", webhookHandler.TestWebhook)
+	r.With(webhookToolsLimiter.Limit).Post("/webhooks/replay", webhookHandler.ReplayWebhooks)
+	r.Get("/webhooks/{id}/attempts", webhookHandler.ListAttempts)
+	r.Get("/webhooks/retry-policy", webhookHandler.GetRetryPolicy)
+	r.Put("/webhooks/retry-policy", webhookHandler.SetRetryPolicy)
+	r.Delete("/webhooks/retry-policy", webhookHandler.ResetRetryPolicy)
+	r.Get("/webhooks/endpoints", endpointHandler.List)
+	r.Post("/webhooks/endpoints", endpointHandler.Create)
+	r.Get("/webhooks/endpoints/{id}", endpointHandler.Get)
+	r.Put("/webhooks/endpoints/{id}", endpointHandler.Update)
+	r.Delete("/webhooks/endpoints/{id}", endpointHandler.Delete)
+	r.Post("/webhooks/endpoints/{id}/rotate-secret", endpointHandler.RotateSecret)
+
+	// Outbox
+	r.Get("/outbox", outboxHandler.ListOutbox)
+	r.Get("/outbox/{id}", outboxHandler.GetOutbox)
+	r.Post("/outbox/{id}/redrive", outboxHandler.Redrive)
+	r.Post("/outbox/redrive", outboxHandler.RedriveAll)
+
+	// Stream
+	r.Get("/stream", sseHandler.Stream)
+
+	// Audit
+	r.Get("/audit", auditHandler.List)
+
+	// Ledger
+	r.Get("/ledger/balances", ledgerHandler.OperatorBalances)
+	r.Get("/ledger/players/{id}/balance", ledgerHandler.PlayerBalance)
+
+	// Reconciliation
+	r.Get("/reconciliation/discrepancies", reconciliationHandler.ListDiscrepancies)
+
+	// Ops listener: probes and metrics, no operator key
+	ops := chi.NewRouter()
+	ops.Get("/healthz", healthHandler.Healthz)
+	ops.Get("/readyz", healthHandler.Readyz)
+	ops.Handle("/metrics", promhttp.Handler())
+
+	return &App{
+		Router: r,
+		Ops:    ops,
+		Health: healthSvc,
+		Bus:    eventBus,
+		Leader: leader,
+		Workers: []Worker{
+			eventDispatcher,
+			outboxWorker,
+			webhookWorker,
+			reconciliationWorker,
+		},
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"rgs/app"
+	"rgs/migrations"
+	"rgs/services"
+	"rgs/sqlc"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const usage = `usage: rgs <command> [args]
+
+commands:
+  serve                                  run the API, ops listener and workers (default)
+  config print                           print the effective config with secrets redacted
+  migrate up                             apply pending migrations
+  migrate down [-steps N]                roll back the newest N migrations (default 1)
+  migrate status                         show the applied and pending migrations
+  operator create -name N -webhook-url U create an operator with a new API key
+  operator rotate-key -id ID             replace an operator's API key
+  operator set-limits -id ID [flags]     set max bet, jurisdictions and daily limits
+  player block -operator ID -player ID   block a player (-unblock to lift)
+  round verify -operator ID -id ID       recompute a round from its seeds
+`
+
+// run dispatches os.Args to a subcommand; no arguments means serve.
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return serve(ctx)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(ctx)
+	case "config":
+		return runConfig(args[1:])
+	case "migrate":
+		return runMigrate(ctx, args[1:])
+	case "operator":
+		return runOperator(ctx, args[1:])
+	case "player":
+		return runPlayer(ctx, args[1:])
+	case "round":
+		return runRound(ctx, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func subcommand(group string, args []string) (string, []string, error) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return "", nil, fmt.Errorf("%s: missing subcommand", group)
+	}
+	return args[0], args[1:], nil
+}
+
+func unknownSubcommand(group, name string) error {
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("%s: unknown subcommand %q", group, name)
+}
+
+// openDB connects for admin commands, which need only the database config.
+func openDB() (*sql.DB, error) {
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Database.URL == "" {
+		return nil, errors.New("database.url (DATABASE_URL) is required")
+	}
+	return ConnectDB(cfg), nil
+}
+
+// runConfig prints the effective config, then fails if it would not pass
+// serve's validation.
+func runConfig(args []string) error {
+	name, _, err := subcommand("config", args)
+	if err != nil {
+		return err
+	}
+	if name != "print" {
+		return unknownSubcommand("config", name)
+	}
+
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	name, args, err := subcommand("migrate", args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("migrate "+name, flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	runner := migrations.NewRunner(db)
+
+	switch name {
+	case "up":
+		applied, err := runner.Up(ctx)
+		if applied == nil {
+			applied = []migrations.Migration{}
+		}
+		if perr := printJSON(map[string]any{"applied": applied}); perr != nil {
+			return perr
+		}
+		return err
+	case "down":
+		if *steps < 1 {
+			return errors.New("migrate down: -steps must be at least 1")
+		}
+		reverted, err := runner.Down(ctx, *steps)
+		if reverted == nil {
+			reverted = []migrations.Migration{}
+		}
+		if perr := printJSON(map[string]any{"reverted": reverted}); perr != nil {
+			return perr
+		}
+		return err
+	case "status":
+		st, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(st)
+	}
+
+	return unknownSubcommand("migrate", name)
+}
+
+func runOperator(ctx context.Context, args []string) error {
+	name, args, err := subcommand("operator", args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("operator "+name, flag.ContinueOnError)
+	id := fs.Int("id", 0, "operator id")
+	opName := fs.String("name", "", "operator name")
+	webhookURL := fs.String("webhook-url", "", "webhook endpoint")
+	maxBet := fs.Float64("max-bet", 0, "maximum bet amount")
+	jurisdictions := fs.String("jurisdictions", "", "comma-separated allowed jurisdictions, empty allows all")
+	dailyLoss := fs.Float64("daily-loss-limit", 0, "daily loss limit")
+	dailyWin := fs.Float64("daily-win-limit", 0, "daily win limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	queries := sqlc.New(db)
+	svc := services.NewOperatorService(queries, services.SQLTx[services.OperatorRepo](db, queries), services.NewComplianceService(queries))
+
+	switch name {
+	case "create":
+		if *opName == "" || *webhookURL == "" {
+			return errors.New("operator create: -name and -webhook-url are required")
+		}
+		op, err := svc.Create(ctx, *opName, *webhookURL)
+		if err != nil {
+			return err
+		}
+		return printJSON(op)
+	case "rotate-key":
+		if *id <= 0 {
+			return errors.New("operator rotate-key: -id is required")
+		}
+		op, err := svc.RotateKey(ctx, int32(*id))
+		if err != nil {
+			return err
+		}
+		return printJSON(op)
+	case "set-limits":
+		if *id <= 0 {
+			return errors.New("operator set-limits: -id is required")
+		}
+		limits, err := svc.SetLimits(ctx, sqlc.UpsertOperatorLimitsParams{
+			OperatorID:           int32(*id),
+			MaxBet:               *maxBet,
+			AllowedJurisdictions: splitList(*jurisdictions),
+			DailyLossLimit:       *dailyLoss,
+			DailyWinLimit:        *dailyWin,
+		})
+		if err != nil {
+			return err
+		}
+		return printJSON(limits)
+	}
+
+	return unknownSubcommand("operator", name)
+}
+
+func runPlayer(ctx context.Context, args []string) error {
+	name, args, err := subcommand("player", args)
+	if err != nil {
+		return err
+	}
+	if name != "block" {
+		return unknownSubcommand("player", name)
+	}
+
+	fs := flag.NewFlagSet("player block", flag.ContinueOnError)
+	operatorID := fs.Int("operator", 0, "operator id")
+	playerID := fs.Int("player", 0, "player id")
+	unblock := fs.Bool("unblock", false, "lift the block instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *operatorID <= 0 || *playerID <= 0 {
+		return errors.New("player block: -operator and -player are required")
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	queries := sqlc.New(db)
+	svc := services.NewOperatorService(queries, services.SQLTx[services.OperatorRepo](db, queries), services.NewComplianceService(queries))
+
+	player, err := svc.SetPlayerBlocked(ctx, int32(*operatorID), int32(*playerID), !*unblock)
+	if err != nil {
+		return err
+	}
+	return printJSON(player)
+}
+
+func runRound(ctx context.Context, args []string) error {
+	name, args, err := subcommand("round", args)
+	if err != nil {
+		return err
+	}
+	if name != "verify" {
+		return unknownSubcommand("round", name)
+	}
+
+	fs := flag.NewFlagSet("round verify", flag.ContinueOnError)
+	operatorID := fs.Int("operator", 0, "operator id")
+	id := fs.Int("id", 0, "round id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *operatorID <= 0 || *id <= 0 {
+		return errors.New("round verify: -operator and -id are required")
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := services.VerifyRound(ctx, sqlc.New(db), int32(*operatorID), int32(*id))
+	if err != nil {
+		return err
+	}
+	if err := printJSON(result); err != nil {
+		return err
+	}
+	if !result.Valid {
+		return fmt.Errorf("round %d: stored outcome does not match its seeds", result.RoundID)
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+// Package clock abstracts the current time so time-based logic can be
+// driven deterministically in tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (Real) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a manually advanced clock. Timers and tickers fire when Advance
+// or Set moves the clock past their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	at     time.Time
+	period time.Duration // zero for one-shot timers
+	ch     chan time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{at: f.now.Add(d), ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.fire()
+	return w.ch
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	return &fakeTicker{clock: f, w: w}
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+	f.fire()
+}
+
+// Waiters reports how many timers and tickers are pending, so tests can
+// wait for a goroutine to block on the clock before advancing it.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// fire delivers every due deadline. Like time.Ticker, a ticker whose
+// reader falls behind drops ticks rather than queueing them.
+func (f *Fake) fire() {
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			kept = append(kept, w)
+			continue
+		}
+
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+
+		if w.period > 0 {
+			for !w.at.After(f.now) {
+				w.at = w.at.Add(w.period)
+			}
+			kept = append(kept, w)
+		}
+	}
+	f.waiters = kept
+}
+
+func (f *Fake) remove(w *waiter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t *fakeTicker) Stop()               { t.clock.remove(t.w) }
@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func received(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeAfterFiresOnAdvance(t *testing.T) {
+	f := NewFake(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	ch := f.After(time.Minute)
+
+	f.Advance(59 * time.Second)
+	if received(ch) {
+		t.Fatal("fired early")
+	}
+
+	f.Advance(time.Second)
+	if !received(ch) {
+		t.Fatal("did not fire at deadline")
+	}
+	if f.Waiters() != 0 {
+		t.Fatalf("waiters = %d after firing", f.Waiters())
+	}
+}
+
+func TestFakeTickerDropsMissedTicks(t *testing.T) {
+	f := NewFake(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	ticker := f.NewTicker(10 * time.Second)
+
+	f.Advance(35 * time.Second)
+	if !received(ticker.C()) {
+		t.Fatal("no tick")
+	}
+	if received(ticker.C()) {
+		t.Fatal("missed ticks were queued")
+	}
+
+	f.Advance(5 * time.Second)
+	if !received(ticker.C()) {
+		t.Fatal("no tick on the next period")
+	}
+
+	ticker.Stop()
+	f.Advance(time.Minute)
+	if received(ticker.C()) {
+		t.Fatal("stopped ticker ticked")
+	}
+}
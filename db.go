@@ -2,14 +2,28 @@ package main
 
 import (
 	"database/sql"
+	"rgs/app"
 	"rgs/observability"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 )
 
-func ConnectDB(cfg Config) *sql.DB {
-	db, err := sql.Open("pgx", cfg.DbUrl)
+func ConnectDB(cfg app.Config) *sql.DB {
+	return connect(cfg.Database.URL)
+}
+
+// ConnectAPIDB opens the restricted pool API requests use in row-level
+// security mode, or returns nil when database.api_url is unset.
+func ConnectAPIDB(cfg app.Config) *sql.DB {
+	if cfg.Database.APIURL == "" {
+		return nil
+	}
+	return connect(cfg.Database.APIURL)
+}
+
+func connect(url string) *sql.DB {
+	db, err := sql.Open("pgx", url)
 	if err != nil {
 		observability.Logger.Fatal("failed to open db", zap.Error(err))
 	}
@@ -0,0 +1,104 @@
+// Package fakes holds test doubles for the external systems services
+// talk to.
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+type WalletCall struct {
+	Op        string
+	PlayerID  int32
+	Amount    float64
+	RequestID string
+}
+
+// Wallet is an in-memory wallet. Like the real one it dedupes on request
+// id, and it declines debits that exceed the balance. Failures can be
+// injected per operation.
+type Wallet struct {
+	mu        sync.Mutex
+	balances  map[int32]float64
+	applied   map[string]bool
+	calls     []WalletCall
+	debitErr  error
+	creditErr error
+}
+
+func NewWallet() *Wallet {
+	return &Wallet{
+		balances: map[int32]float64{},
+		applied:  map[string]bool{},
+	}
+}
+
+func (w *Wallet) SetBalance(playerID int32, amount float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balances[playerID] = amount
+}
+
+func (w *Wallet) Balance(playerID int32) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.balances[playerID]
+}
+
+// FailDebits makes every debit return err until called again with nil.
+func (w *Wallet) FailDebits(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debitErr = err
+}
+
+// FailCredits makes every credit return err until called again with nil.
+func (w *Wallet) FailCredits(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.creditErr = err
+}
+
+// Calls returns every call made, including failed ones.
+func (w *Wallet) Calls() []WalletCall {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]WalletCall(nil), w.calls...)
+}
+
+func (w *Wallet) Debit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.calls = append(w.calls, WalletCall{Op: "debit", PlayerID: playerID, Amount: amount, RequestID: requestID})
+	if w.debitErr != nil {
+		return false, w.debitErr
+	}
+	if w.applied[requestID] {
+		return true, nil
+	}
+	if w.balances[playerID] < amount {
+		return false, nil
+	}
+
+	w.balances[playerID] -= amount
+	w.applied[requestID] = true
+	return true, nil
+}
+
+func (w *Wallet) Credit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.calls = append(w.calls, WalletCall{Op: "credit", PlayerID: playerID, Amount: amount, RequestID: requestID})
+	if w.creditErr != nil {
+		return false, w.creditErr
+	}
+	if w.applied[requestID] {
+		return true, nil
+	}
+
+	w.balances[playerID] += amount
+	w.applied[requestID] = true
+	return true, nil
+}
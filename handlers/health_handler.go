@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"rgs/observability"
+	"rgs/services"
+
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	svc *services.HealthService
+}
+
+func NewHealthHandler(svc *services.HealthService) *HealthHandler {
+	return &HealthHandler{svc: svc}
+}
+
+// Healthz is the liveness probe: the process is up and serving.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
+
+// Readyz reports every dependency and answers 503 if any of them fails.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	res := h.svc.Ready(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if res.Status != services.CheckOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		observability.Logger.Error("failed to encode readiness", zap.Error(err))
+		return
+	}
+}
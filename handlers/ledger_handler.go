@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"rgs/clock"
+	"rgs/middleware"
+	"rgs/observability"
+	"rgs/services"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type LedgerHandler struct {
+	svc   *services.LedgerService
+	clock clock.Clock
+}
+
+func NewLedgerHandler(svc *services.LedgerService, clk clock.Clock) *LedgerHandler {
+	return &LedgerHandler{svc: svc, clock: clk}
+}
+
+// parseRange reads from/to (RFC3339) and defaults to the 30 days up to now.
+func parseRange(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	from := to.AddDate(0, 0, -30)
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = t
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = t
+	}
+
+	return from, to, nil
+}
+
+func (h *LedgerHandler) PlayerBalance(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	playerID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid player id", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseRange(r, h.clock.Now())
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.svc.PlayerBalance(r.Context(), operator.ID, int32(playerID), from, to)
+	if err != nil {
+		http.Error(w, "failed to load player balance", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load player balance", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(balance)
+	if err != nil {
+		observability.Logger.Error("failed to encode player balance", zap.Error(err))
+		return
+	}
+}
+
+func (h *LedgerHandler) OperatorBalances(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from, to, err := parseRange(r, h.clock.Now())
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+
+	balances, err := h.svc.OperatorBalances(r.Context(), operator.ID, from, to)
+	if err != nil {
+		http.Error(w, "failed to load operator balances", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load operator balances", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(balances)
+	if err != nil {
+		observability.Logger.Error("failed to encode operator balances", zap.Error(err))
+		return
+	}
+}
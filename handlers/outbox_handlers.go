@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"rgs/middleware"
 	"rgs/observability"
 	"rgs/services"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
@@ -44,3 +48,107 @@ func (h *OutboxHandler) ListOutbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func outboxID(r *http.Request) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (h *OutboxHandler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := outboxID(r)
+	if !ok {
+		http.Error(w, "invalid outbox id", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.svc.GetOutbox(r.Context(), operator.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "outbox entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load outbox entry", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load outbox entry", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		observability.Logger.Error("failed to encode outbox entry", zap.Error(err))
+		return
+	}
+}
+
+func (h *OutboxHandler) Redrive(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := outboxID(r)
+	if !ok {
+		http.Error(w, "invalid outbox id", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.svc.Redrive(r.Context(), operator.ID, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "outbox entry not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrOutboxNotDead):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to redrive outbox entry", http.StatusInternalServerError)
+		observability.Logger.Error("failed to redrive outbox entry", zap.Error(err))
+		return
+	}
+
+	observability.Logger.Info("outbox entry redriven",
+		zap.Int32("operator_id", operator.ID),
+		zap.Int32("outbox_id", entry.ID),
+	)
+
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		observability.Logger.Error("failed to encode outbox entry", zap.Error(err))
+		return
+	}
+}
+
+func (h *OutboxHandler) RedriveAll(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entries, err := h.svc.RedriveAll(r.Context(), operator.ID)
+	if err != nil {
+		http.Error(w, "failed to redrive outbox entries", http.StatusInternalServerError)
+		observability.Logger.Error("failed to redrive outbox entries", zap.Error(err))
+		return
+	}
+
+	observability.Logger.Info("dead outbox entries redriven",
+		zap.Int32("operator_id", operator.ID),
+		zap.Int("count", len(entries)),
+	)
+
+	err = json.NewEncoder(w).Encode(entries)
+	if err != nil {
+		observability.Logger.Error("failed to encode outbox entries", zap.Error(err))
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"rgs/middleware"
+	"rgs/observability"
+	"rgs/services"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type ReconciliationHandler struct {
+	svc *services.ReconciliationService
+}
+
+func NewReconciliationHandler(svc *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{svc: svc}
+}
+
+func (h *ReconciliationHandler) ListDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var runID *int32
+	if v := r.URL.Query().Get("run_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid run_id", http.StatusBadRequest)
+			return
+		}
+		tmp := int32(id)
+		runID = &tmp
+	}
+
+	limit := int32(50)
+	offset := int32(0)
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = int32(n)
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			offset = int32(n)
+		}
+	}
+
+	items, err := h.svc.ListDiscrepancies(r.Context(), operator.ID, runID, limit, offset)
+	if err != nil {
+		http.Error(w, "failed to load discrepancies", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load discrepancies", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(items)
+	if err != nil {
+		observability.Logger.Error("failed to encode discrepancies", zap.Error(err))
+		return
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"rgs/middleware"
 	"rgs/observability"
 	"strconv"
 
@@ -21,6 +22,12 @@ func NewRoundsHandler(q *sqlc.Queries) *RoundsHandler {
 }
 
 func (h *RoundsHandler) GetRound(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	if idStr == "" {
 		http.Error(w, "missing round id", http.StatusBadRequest)
@@ -33,7 +40,10 @@ func (h *RoundsHandler) GetRound(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	round, err := h.queries.GetRound(r.Context(), int32(id))
+	round, err := h.queries.GetRound(r.Context(), sqlc.GetRoundParams{
+		ID:         int32(id),
+		OperatorID: operator.ID,
+	})
 	if err != nil {
 		http.Error(w, "round not found", http.StatusNotFound)
 		observability.Logger.Error("round not found", zap.Error(err))
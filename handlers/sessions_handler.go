@@ -1,8 +1,9 @@
 package handlers
 
 import (
-	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"rgs/middleware"
 	"rgs/observability"
@@ -119,7 +120,12 @@ func (h *SessionsHandler) RevokeSession(w http.ResponseWriter, r *http.Request)
 	observability.Logger.Info("revoking session",
 		zap.Int32("operator_id", operator.ID),
 	)
-	if err := h.svc.RevokeSession(context.Background(), id, operator.ID); err != nil {
+	err = h.svc.RevokeSession(r.Context(), id, operator.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		http.Error(w, "failed to revoke", http.StatusInternalServerError)
 		observability.Logger.Error("session revoking failed", zap.Error(err))
 		return
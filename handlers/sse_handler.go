@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"rgs/clock"
 	"rgs/middleware"
 	"rgs/observability"
 	"rgs/services"
@@ -12,12 +13,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// reconnectHint is the SSE retry delay sent to clients on shutdown.
+const reconnectHint = 2 * time.Second
+
+// pingInterval keeps idle connections open through proxies.
+const pingInterval = 15 * time.Second
+
 type SSEHandler struct {
-	bus *services.EventBus
+	bus   *services.EventBus
+	clock clock.Clock
 }
 
-func NewSSEHandler(bus *services.EventBus) *SSEHandler {
-	return &SSEHandler{bus: bus}
+func NewSSEHandler(bus *services.EventBus, clk clock.Clock) *SSEHandler {
+	return &SSEHandler{bus: bus, clock: clk}
 }
 
 func (h *SSEHandler) Stream(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +61,7 @@ func (h *SSEHandler) Stream(w http.ResponseWriter, r *http.Request) {
 	sub := h.bus.Subscribe(operator.ID)
 	defer h.bus.Unsubscribe(operator.ID, sub)
 
-	ticker := time.NewTicker(15 * time.Second)
+	ticker := h.clock.NewTicker(pingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -61,11 +69,18 @@ func (h *SSEHandler) Stream(w http.ResponseWriter, r *http.Request) {
 		case <-r.Context().Done():
 			return
 
+		case <-h.bus.Done():
+			// Server is shutting down: ask the client to reconnect shortly,
+			// which lands it on another replica.
+			_, _ = fmt.Fprintf(w, "retry: %d\n\n", reconnectHint.Milliseconds())
+			flusher.Flush()
+			return
+
 		case evt := <-sub:
 			h.writeEvent(w, evt)
 			flusher.Flush()
 
-		case <-ticker.C:
+		case <-ticker.C():
 			_, err := fmt.Fprintf(w, ": ping\n\n")
 			if err != nil {
 				observability.Logger.Error("failed to write event ping", zap.Error(err))
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"rgs/middleware"
+	"rgs/observability"
+	"rgs/services"
+	"rgs/sqlc"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type WebhookEndpointHandler struct {
+	svc *services.WebhookEndpointService
+}
+
+func NewWebhookEndpointHandler(svc *services.WebhookEndpointService) *WebhookEndpointHandler {
+	return &WebhookEndpointHandler{svc: svc}
+}
+
+type webhookEndpointRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Enabled    *bool    `json:"enabled"`
+	Ordered    bool     `json:"ordered"`
+}
+
+func (r webhookEndpointRequest) params() services.WebhookEndpointParams {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return services.WebhookEndpointParams{
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		Enabled:    enabled,
+		Ordered:    r.Ordered,
+	}
+}
+
+// webhookEndpointResponse leaves the secret out except right after create
+// or rotation.
+type webhookEndpointResponse struct {
+	ID                      int32      `json:"id"`
+	URL                     string     `json:"url"`
+	EventTypes              []string   `json:"event_types"`
+	Enabled                 bool       `json:"enabled"`
+	Ordered                 bool       `json:"ordered"`
+	Secret                  string     `json:"secret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+func newWebhookEndpointResponse(ep sqlc.WebhookEndpoint) webhookEndpointResponse {
+	resp := webhookEndpointResponse{
+		ID:         ep.ID,
+		URL:        ep.Url,
+		EventTypes: ep.EventTypes,
+		Enabled:    ep.Enabled,
+		Ordered:    ep.Ordered,
+		CreatedAt:  ep.CreatedAt,
+		UpdatedAt:  ep.UpdatedAt,
+	}
+	if ep.PreviousSecretExpiresAt.Valid {
+		resp.PreviousSecretExpiresAt = &ep.PreviousSecretExpiresAt.Time
+	}
+	return resp
+}
+
+func endpointID(r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (h *WebhookEndpointHandler) Create(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req webhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	ep, err := h.svc.Create(r.Context(), operator.ID, req.params())
+	switch {
+	case errors.Is(err, services.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "failed to create webhook endpoint", http.StatusInternalServerError)
+		observability.Logger.Error("failed to create webhook endpoint", zap.Error(err))
+		return
+	}
+
+	resp := newWebhookEndpointResponse(ep)
+	resp.Secret = ep.Secret
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook endpoint", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookEndpointHandler) List(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	endpoints, err := h.svc.List(r.Context(), operator.ID)
+	if err != nil {
+		http.Error(w, "failed to load webhook endpoints", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load webhook endpoints", zap.Error(err))
+		return
+	}
+
+	resp := make([]webhookEndpointResponse, 0, len(endpoints))
+	for _, ep := range endpoints {
+		resp = append(resp, newWebhookEndpointResponse(ep))
+	}
+
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook endpoints", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookEndpointHandler) Get(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := endpointID(r)
+	if !ok {
+		http.Error(w, "invalid endpoint id", http.StatusBadRequest)
+		return
+	}
+
+	ep, err := h.svc.Get(r.Context(), operator.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "webhook endpoint not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load webhook endpoint", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load webhook endpoint", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newWebhookEndpointResponse(ep))
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook endpoint", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookEndpointHandler) Update(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := endpointID(r)
+	if !ok {
+		http.Error(w, "invalid endpoint id", http.StatusBadRequest)
+		return
+	}
+
+	var req webhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	ep, err := h.svc.Update(r.Context(), operator.ID, id, req.params())
+	switch {
+	case errors.Is(err, services.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "webhook endpoint not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "failed to update webhook endpoint", http.StatusInternalServerError)
+		observability.Logger.Error("failed to update webhook endpoint", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newWebhookEndpointResponse(ep))
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook endpoint", zap.Error(err))
+		return
+	}
+}
+
+// RotateSecret takes an optional {"overlap_seconds": n}; without it the
+// old secret keeps signing for services.DefaultSecretOverlap.
+func (h *WebhookEndpointHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := endpointID(r)
+	if !ok {
+		http.Error(w, "invalid endpoint id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		OverlapSeconds *int64 `json:"overlap_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	overlap := services.DefaultSecretOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+
+	ep, err := h.svc.RotateSecret(r.Context(), operator.ID, id, overlap)
+	switch {
+	case errors.Is(err, services.ErrInvalidEndpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "webhook endpoint not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "failed to rotate webhook secret", http.StatusInternalServerError)
+		observability.Logger.Error("failed to rotate webhook secret", zap.Error(err))
+		return
+	}
+
+	resp := newWebhookEndpointResponse(ep)
+	resp.Secret = ep.Secret
+
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook endpoint", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookEndpointHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := endpointID(r)
+	if !ok {
+		http.Error(w, "invalid endpoint id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.svc.Delete(r.Context(), operator.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "webhook endpoint not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to delete webhook endpoint", http.StatusInternalServerError)
+		observability.Logger.Error("failed to delete webhook endpoint", zap.Error(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
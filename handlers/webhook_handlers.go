@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"rgs/middleware"
 	"rgs/observability"
 	"rgs/services"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -21,6 +24,12 @@ func NewWebhookHandler(svc *services.WebhookService) *WebhookHandler {
 }
 
 func (h *WebhookHandler) RetryWebhook(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id64, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
@@ -29,9 +38,16 @@ func (h *WebhookHandler) RetryWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.svc.RetryWebhook(r.Context(), int32(id64))
-	if err != nil {
+	err = h.svc.RetryWebhook(r.Context(), operator.ID, int32(id64))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
 		http.Error(w, "event not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrWebhookInFlight):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to schedule retry", http.StatusInternalServerError)
 		observability.Logger.Error("retry webhook failed", zap.Error(err))
 		return
 	}
@@ -66,3 +82,218 @@ func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// TestWebhook sends a ping to {"endpoint_id": n} and answers with the
+// attempt, whether or not the receiver accepted it.
+func (h *WebhookHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		EndpointID int32 `json:"endpoint_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := h.svc.TestEndpoint(r.Context(), operator.ID, req.EndpointID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "webhook endpoint not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to send test webhook", http.StatusInternalServerError)
+		observability.Logger.Error("failed to send test webhook", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(attempt)
+	if err != nil {
+		observability.Logger.Error("failed to encode test webhook attempt", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookHandler) ReplayWebhooks(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Status    string    `json:"status"`
+		EventType string    `json:"event_type"`
+		From      time.Time `json:"from"`
+		To        time.Time `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := h.svc.Replay(r.Context(), operator.ID, services.WebhookReplayFilter{
+		Status:    req.Status,
+		EventType: req.EventType,
+		From:      req.From,
+		To:        req.To,
+	})
+	if errors.Is(err, services.ErrInvalidReplay) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to replay webhooks", http.StatusInternalServerError)
+		observability.Logger.Error("failed to replay webhooks", zap.Error(err))
+		return
+	}
+
+	if ids == nil {
+		ids = []int32{}
+	}
+	err = json.NewEncoder(w).Encode(map[string]any{
+		"replayed": len(ids),
+		"ids":      ids,
+	})
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook replay", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	attempts, err := h.svc.Attempts(r.Context(), operator.ID, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load delivery attempts", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load webhook delivery attempts", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(attempts)
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook delivery attempts", zap.Error(err))
+		return
+	}
+}
+
+// retryPolicyView carries durations in seconds.
+type retryPolicyView struct {
+	MaxAttempts      int32   `json:"max_attempts"`
+	MaxAgeSeconds    int64   `json:"max_age_seconds"`
+	BaseDelaySeconds int64   `json:"base_delay_seconds"`
+	MaxDelaySeconds  int64   `json:"max_delay_seconds"`
+	Jitter           float64 `json:"jitter"`
+}
+
+func newRetryPolicyView(p services.RetryPolicy) retryPolicyView {
+	return retryPolicyView{
+		MaxAttempts:      p.MaxAttempts,
+		MaxAgeSeconds:    int64(p.MaxAge / time.Second),
+		BaseDelaySeconds: int64(p.BaseDelay / time.Second),
+		MaxDelaySeconds:  int64(p.MaxDelay / time.Second),
+		Jitter:           p.Jitter,
+	}
+}
+
+func (v retryPolicyView) policy() services.RetryPolicy {
+	return services.RetryPolicy{
+		MaxAttempts: v.MaxAttempts,
+		MaxAge:      time.Duration(v.MaxAgeSeconds) * time.Second,
+		BaseDelay:   time.Duration(v.BaseDelaySeconds) * time.Second,
+		MaxDelay:    time.Duration(v.MaxDelaySeconds) * time.Second,
+		Jitter:      v.Jitter,
+	}
+}
+
+func (h *WebhookHandler) GetRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	policy, err := h.svc.RetryPolicy(r.Context(), operator.ID)
+	if err != nil {
+		http.Error(w, "failed to load retry policy", http.StatusInternalServerError)
+		observability.Logger.Error("failed to load webhook retry policy", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newRetryPolicyView(policy))
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook retry policy", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookHandler) SetRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req retryPolicyView
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.svc.SetRetryPolicy(r.Context(), operator.ID, req.policy())
+	switch {
+	case errors.Is(err, services.ErrInvalidRetryPolicy):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "failed to save retry policy", http.StatusInternalServerError)
+		observability.Logger.Error("failed to save webhook retry policy", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newRetryPolicyView(policy))
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook retry policy", zap.Error(err))
+		return
+	}
+}
+
+func (h *WebhookHandler) ResetRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	operator, ok := middleware.OperatorFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	policy, err := h.svc.ResetRetryPolicy(r.Context(), operator.ID)
+	if err != nil {
+		http.Error(w, "failed to reset retry policy", http.StatusInternalServerError)
+		observability.Logger.Error("failed to reset webhook retry policy", zap.Error(err))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(newRetryPolicyView(policy))
+	if err != nil {
+		observability.Logger.Error("failed to encode webhook retry policy", zap.Error(err))
+		return
+	}
+}
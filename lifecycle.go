@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rgs/app"
+	"rgs/observability"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Lifecycle runs the HTTP servers and workers until its context is
+// cancelled, then shuts down in order: stop accepting requests and drain
+// (servers in the order given), stop workers, then run the closers (DB
+// pool, tracer, ...) in the order they were added.
+type Lifecycle struct {
+	servers []*http.Server
+	workers []app.Worker
+	closers []func(context.Context) error
+	drain   time.Duration
+}
+
+func NewLifecycle(drain time.Duration, servers ...*http.Server) *Lifecycle {
+	return &Lifecycle{servers: servers, drain: drain}
+}
+
+func (l *Lifecycle) AddWorker(w app.Worker) {
+	l.workers = append(l.workers, w)
+}
+
+func (l *Lifecycle) AddCloser(fn func(context.Context) error) {
+	l.closers = append(l.closers, fn)
+}
+
+func (l *Lifecycle) Run(ctx context.Context) error {
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range l.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(workerCtx)
+		}()
+	}
+
+	serverErr := make(chan error, len(l.servers))
+	for _, srv := range l.servers {
+		go func() {
+			observability.Logger.Info("Server starting", zap.String("addr", srv.Addr))
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		observability.Logger.Info("shutdown signal received")
+	case err := <-serverErr:
+		runErr = err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), l.drain)
+	defer cancel()
+
+	for _, srv := range l.servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			observability.Logger.Error("http server did not drain in time",
+				zap.String("addr", srv.Addr),
+				zap.Error(err),
+			)
+		}
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(workersDone)
+	}()
+
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		observability.Logger.Error("workers did not stop in time")
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), l.drain)
+	defer cancelClose()
+
+	for _, fn := range l.closers {
+		if err := fn(closeCtx); err != nil {
+			observability.Logger.Error("shutdown step failed", zap.Error(err))
+		}
+	}
+
+	observability.Logger.Info("shutdown complete")
+	return runErr
+}
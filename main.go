@@ -2,36 +2,86 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"rgs/app"
 	"rgs/observability"
-
-	"go.uber.org/zap"
+	"syscall"
 )
 
 func main() {
 	if err := observability.InitLogger(); err != nil {
 		log.Fatalf("failed to init logger: %v", err)
 	}
-	defer func(Logger *zap.Logger) {
-		err := Logger.Sync()
-		if err != nil {
-			log.Fatalf("failed to sync logger: %v", err)
-		}
-	}(observability.Logger)
 
-	shutdownTracing := observability.InitTracing()
-	defer shutdownTracing(context.Background())
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:])
+	stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+
+	// Synced by hand: os.Exit below would skip a deferred call.
+	if syncErr := observability.Logger.Sync(); syncErr != nil {
+		log.Fatalf("failed to sync logger: %v", syncErr)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func serve(ctx context.Context) error {
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	shutdownTracing := observability.InitTracing(cfg.Tracing.OTLPEndpoint)
 
 	observability.InitMetrics()
 
-	cfg := LoadConfig()
 	db := ConnectDB(cfg)
+	apiDB := ConnectAPIDB(cfg)
+
+	a := app.Build(db, apiDB, cfg)
+
+	server := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: a.Router,
+	}
+	// SSE streams never finish on their own; closing the bus ends them
+	// with a reconnect hint so Shutdown can drain.
+	server.RegisterOnShutdown(a.Bus.Close)
+	server.RegisterOnShutdown(a.Health.MarkShuttingDown)
 
-	r := BuildApp(db, cfg)
+	opsServer := &http.Server{
+		Addr:    cfg.Server.OpsAddr,
+		Handler: a.Ops,
+	}
 
-	observability.Logger.Info("Server starting", zap.String("port", "8080"))
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		observability.Logger.Fatal("Error starting the server", zap.Error(err))
+	// DrainTimeout bounds each shutdown phase: in-flight requests, then
+	// workers, then closing dependencies.
+	lc := NewLifecycle(cfg.Server.DrainTimeout, server, opsServer)
+	for _, w := range a.Workers {
+		lc.AddWorker(w)
+	}
+	if a.Leader != nil {
+		lc.AddCloser(func(ctx context.Context) error {
+			a.Leader.Release(ctx)
+			return nil
+		})
 	}
+	lc.AddCloser(func(context.Context) error { return db.Close() })
+	if apiDB != nil {
+		lc.AddCloser(func(context.Context) error { return apiDB.Close() })
+	}
+	lc.AddCloser(shutdownTracing)
+
+	return lc.Run(ctx)
 }
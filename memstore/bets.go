@@ -0,0 +1,107 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+)
+
+func (s *Store) CreateRound(ctx context.Context, arg sqlc.CreateRoundParams) (sqlc.Round, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := sqlc.Round{
+		ID:         s.nextID(),
+		OperatorID: arg.OperatorID,
+		PlayerID:   arg.PlayerID,
+		ServerSeed: arg.ServerSeed,
+		ClientSeed: arg.ClientSeed,
+		Outcome:    arg.Outcome,
+		CreatedAt:  s.clock.Now(),
+	}
+	s.data.rounds[r.ID] = r
+	return r, nil
+}
+
+func (s *Store) GetRound(ctx context.Context, arg sqlc.GetRoundParams) (sqlc.Round, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.data.rounds[arg.ID]
+	if !ok || r.OperatorID != arg.OperatorID {
+		return sqlc.Round{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (s *Store) CreateBet(ctx context.Context, arg sqlc.CreateBetParams) (sqlc.Bet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, b := range s.data.bets {
+		if b.OperatorID == arg.OperatorID && b.IdempotencyKey == arg.IdempotencyKey {
+			return sqlc.Bet{}, ErrUniqueViolation
+		}
+	}
+
+	b := sqlc.Bet{
+		ID:             s.nextID(),
+		OperatorID:     arg.OperatorID,
+		PlayerID:       arg.PlayerID,
+		RoundID:        arg.RoundID,
+		Amount:         arg.Amount,
+		Outcome:        arg.Outcome,
+		WinAmount:      arg.WinAmount,
+		Status:         arg.Status,
+		IdempotencyKey: arg.IdempotencyKey,
+		CreatedAt:      s.clock.Now(),
+	}
+	s.data.bets[b.ID] = b
+	return b, nil
+}
+
+func (s *Store) GetBetByIdempotency(ctx context.Context, arg sqlc.GetBetByIdempotencyParams) (sqlc.Bet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, b := range s.data.bets {
+		if b.OperatorID == arg.OperatorID && b.IdempotencyKey == arg.IdempotencyKey {
+			return b, nil
+		}
+	}
+	return sqlc.Bet{}, sql.ErrNoRows
+}
+
+func (s *Store) UpdateBetStatus(ctx context.Context, arg sqlc.UpdateBetStatusParams) (sqlc.Bet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.data.bets[arg.ID]
+	if !ok {
+		return sqlc.Bet{}, sql.ErrNoRows
+	}
+	b.Status = arg.Status
+	b.WinAmount = arg.WinAmount
+	s.data.bets[b.ID] = b
+	return b, nil
+}
+
+func (s *Store) MarkBetAsWon(ctx context.Context, id int32) (sqlc.Bet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.data.bets[id]
+	if !ok {
+		return sqlc.Bet{}, sql.ErrNoRows
+	}
+	b.Status = "won"
+	s.data.bets[id] = b
+	return b, nil
+}
+
+// Bets returns every bet ordered by id.
+func (s *Store) Bets() []sqlc.Bet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedValues(s.data.bets)
+}
@@ -0,0 +1,77 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+	"slices"
+)
+
+func (s *Store) GetOperatorLimits(ctx context.Context, operatorID int32) (sqlc.OperatorLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.data.limits[operatorID]
+	if !ok {
+		return sqlc.OperatorLimit{}, sql.ErrNoRows
+	}
+	return l, nil
+}
+
+func (s *Store) UpsertOperatorLimits(ctx context.Context, arg sqlc.UpsertOperatorLimitsParams) (sqlc.OperatorLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := sqlc.OperatorLimit(arg)
+	s.data.limits[l.OperatorID] = l
+	return l, nil
+}
+
+func (s *Store) InsertAuditLog(ctx context.Context, arg sqlc.InsertAuditLogParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.auditLogs = append(s.data.auditLogs, sqlc.AuditLog{
+		ID:         s.nextID(),
+		OperatorID: arg.OperatorID,
+		PlayerID:   arg.PlayerID,
+		Action:     arg.Action,
+		Details:    arg.Details,
+		CreatedAt:  s.clock.Now(),
+	})
+	return nil
+}
+
+func (s *Store) ListAuditLogsByOperator(ctx context.Context, arg sqlc.ListAuditLogsByOperatorParams) ([]sqlc.AuditLog, error) {
+	return s.listAuditLogs(arg.Limit, arg.Offset, func(l sqlc.AuditLog) bool {
+		return l.OperatorID == arg.OperatorID
+	}), nil
+}
+
+func (s *Store) ListAuditLogsByOperatorPlayer(ctx context.Context, arg sqlc.ListAuditLogsByOperatorPlayerParams) ([]sqlc.AuditLog, error) {
+	return s.listAuditLogs(arg.Limit, arg.Offset, func(l sqlc.AuditLog) bool {
+		return l.OperatorID == arg.OperatorID && l.PlayerID == arg.PlayerID
+	}), nil
+}
+
+// listAuditLogs orders newest first like the queries' created_at DESC.
+func (s *Store) listAuditLogs(limit, offset int32, match func(sqlc.AuditLog) bool) []sqlc.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []sqlc.AuditLog
+	for _, l := range slices.Backward(s.data.auditLogs) {
+		if match(l) {
+			matched = append(matched, l)
+		}
+	}
+
+	if int(offset) >= len(matched) {
+		return nil
+	}
+	matched = matched[offset:]
+	if int(limit) < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched
+}
@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+)
+
+func (s *Store) InsertEvent(ctx context.Context, arg sqlc.InsertEventParams) (sqlc.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.data.events {
+		if e.EventID == arg.EventID {
+			return sqlc.Event{}, ErrUniqueViolation
+		}
+	}
+
+	e := sqlc.Event{
+		ID:         s.nextID(),
+		EventID:    arg.EventID,
+		OperatorID: arg.OperatorID,
+		EventType:  arg.EventType,
+		Payload:    arg.Payload,
+		CreatedAt:  s.clock.Now(),
+		Version:    arg.Version,
+	}
+	s.data.events[e.ID] = e
+	return e, nil
+}
+
+func (s *Store) ClaimUndispatchedEvents(ctx context.Context, arg sqlc.ClaimUndispatchedEventsParams) ([]sqlc.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := arg.Now
+	var out []sqlc.Event
+	for _, e := range sortedValues(s.data.events) {
+		if len(out) == 100 {
+			break
+		}
+		if e.DispatchedAt.Valid || (e.LockedUntil.Valid && !e.LockedUntil.Time.Before(now)) {
+			continue
+		}
+		e.LockedBy = sql.NullString{String: arg.WorkerID, Valid: true}
+		e.LockedUntil = sql.NullTime{Time: arg.LockedUntil, Valid: true}
+		s.data.events[e.ID] = e
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (s *Store) MarkEventDispatched(ctx context.Context, arg sqlc.MarkEventDispatchedParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.events[arg.ID]
+	if !ok || e.DispatchedAt.Valid || e.LockedBy.String != arg.WorkerID {
+		return 0, nil
+	}
+	e.DispatchedAt = sql.NullTime{Time: s.clock.Now(), Valid: true}
+	e.LockedUntil = sql.NullTime{}
+	s.data.events[arg.ID] = e
+	return 1, nil
+}
+
+// Events returns every recorded event ordered by id.
+func (s *Store) Events() []sqlc.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedValues(s.data.events)
+}
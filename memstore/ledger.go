@@ -0,0 +1,35 @@
+package memstore
+
+import (
+	"context"
+	"rgs/sqlc"
+	"slices"
+)
+
+func (s *Store) InsertLedgerEntry(ctx context.Context, arg sqlc.InsertLedgerEntryParams) (sqlc.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := sqlc.LedgerEntry{
+		ID:            s.nextID(),
+		TransactionID: arg.TransactionID,
+		OperatorID:    arg.OperatorID,
+		PlayerID:      arg.PlayerID,
+		BetID:         arg.BetID,
+		OutboxID:      arg.OutboxID,
+		EntryType:     arg.EntryType,
+		Account:       arg.Account,
+		Direction:     arg.Direction,
+		Amount:        arg.Amount,
+		CreatedAt:     s.clock.Now(),
+	}
+	s.data.ledger = append(s.data.ledger, e)
+	return e, nil
+}
+
+// LedgerEntries returns every ledger entry in insertion order.
+func (s *Store) LedgerEntries() []sqlc.LedgerEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.data.ledger)
+}
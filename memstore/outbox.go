@@ -0,0 +1,208 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+	"slices"
+	"time"
+)
+
+func (s *Store) InsertOutbox(ctx context.Context, arg sqlc.InsertOutboxParams) (sqlc.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.data.outbox {
+		if e.CreditTxID == arg.CreditTxID {
+			return sqlc.Outbox{}, ErrUniqueViolation
+		}
+	}
+
+	now := s.clock.Now()
+	e := sqlc.Outbox{
+		ID:            s.nextID(),
+		BetID:         arg.BetID,
+		OperatorID:    arg.OperatorID,
+		PlayerID:      arg.PlayerID,
+		Amount:        arg.Amount,
+		CreatedAt:     now,
+		Status:        sqlc.OutboxStatusPending,
+		NextAttemptAt: now,
+		UpdatedAt:     now,
+		CreditTxID:    arg.CreditTxID,
+	}
+	s.data.outbox[e.ID] = e
+	return e, nil
+}
+
+func (s *Store) ClaimPendingOutbox(ctx context.Context, arg sqlc.ClaimPendingOutboxParams) ([]sqlc.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := arg.Now
+	var due []sqlc.Outbox
+	for _, e := range s.data.outbox {
+		if e.Status != sqlc.OutboxStatusPending && e.Status != sqlc.OutboxStatusRetrying {
+			continue
+		}
+		if e.NextAttemptAt.After(now) {
+			continue
+		}
+		if e.LockedUntil.Valid && !e.LockedUntil.Time.Before(now) {
+			continue
+		}
+		due = append(due, e)
+	}
+
+	slices.SortFunc(due, func(a, b sqlc.Outbox) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+	if len(due) > 10 {
+		due = due[:10]
+	}
+
+	for i := range due {
+		due[i].LockedBy = sql.NullString{String: arg.WorkerID, Valid: true}
+		due[i].LockedUntil = sql.NullTime{Time: arg.LockedUntil, Valid: true}
+		s.data.outbox[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (s *Store) updateOutbox(id int32, fn func(*sqlc.Outbox) bool) (sqlc.Outbox, error) {
+	e, ok := s.data.outbox[id]
+	if !ok || !fn(&e) {
+		return sqlc.Outbox{}, sql.ErrNoRows
+	}
+	e.UpdatedAt = s.clock.Now()
+	s.data.outbox[id] = e
+	return e, nil
+}
+
+// finishOutbox applies fn to an entry workerID still holds the lease on,
+// and reports whether it did.
+func (s *Store) finishOutbox(id int32, workerID string, fn func(*sqlc.Outbox)) int64 {
+	e, ok := s.data.outbox[id]
+	if !ok || e.LockedBy.String != workerID {
+		return 0
+	}
+	if e.Status != sqlc.OutboxStatusPending && e.Status != sqlc.OutboxStatusRetrying {
+		return 0
+	}
+	_, _ = s.updateOutbox(id, func(e *sqlc.Outbox) bool {
+		fn(e)
+		e.Attempts++
+		e.LockedUntil = sql.NullTime{}
+		return true
+	})
+	return 1
+}
+
+func (s *Store) MarkOutboxSucceeded(ctx context.Context, arg sqlc.MarkOutboxSucceededParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishOutbox(arg.ID, arg.WorkerID, func(e *sqlc.Outbox) {
+		e.Status = sqlc.OutboxStatusSucceeded
+		e.LastError = sql.NullString{}
+	}), nil
+}
+
+func (s *Store) MarkOutboxRetry(ctx context.Context, arg sqlc.MarkOutboxRetryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishOutbox(arg.ID, arg.WorkerID, func(e *sqlc.Outbox) {
+		e.Status = sqlc.OutboxStatusRetrying
+		e.NextAttemptAt = arg.NextAttemptAt
+		e.LastError = arg.LastError
+	}), nil
+}
+
+func (s *Store) MarkOutboxDead(ctx context.Context, arg sqlc.MarkOutboxDeadParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishOutbox(arg.ID, arg.WorkerID, func(e *sqlc.Outbox) {
+		e.Status = sqlc.OutboxStatusDead
+		e.LastError = arg.LastError
+	}), nil
+}
+
+func (s *Store) GetOutboxByOperator(ctx context.Context, arg sqlc.GetOutboxByOperatorParams) (sqlc.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.outbox[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return sqlc.Outbox{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func redrive(e *sqlc.Outbox, now time.Time) {
+	e.Status = sqlc.OutboxStatusPending
+	e.Attempts = 0
+	e.NextAttemptAt = now
+	e.LastError = sql.NullString{}
+	e.LockedUntil = sql.NullTime{}
+}
+
+func (s *Store) RedriveOutbox(ctx context.Context, arg sqlc.RedriveOutboxParams) (sqlc.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateOutbox(arg.ID, func(e *sqlc.Outbox) bool {
+		if e.OperatorID != arg.OperatorID || e.Status != sqlc.OutboxStatusDead {
+			return false
+		}
+		redrive(e, arg.Now)
+		return true
+	})
+}
+
+func (s *Store) RedriveDeadOutboxByOperator(ctx context.Context, arg sqlc.RedriveDeadOutboxByOperatorParams) ([]sqlc.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []sqlc.Outbox
+	for _, e := range sortedValues(s.data.outbox) {
+		if e.OperatorID != arg.OperatorID || e.Status != sqlc.OutboxStatusDead {
+			continue
+		}
+		updated, _ := s.updateOutbox(e.ID, func(e *sqlc.Outbox) bool {
+			redrive(e, arg.Now)
+			return true
+		})
+		out = append(out, updated)
+	}
+	return out, nil
+}
+
+func (s *Store) ListOutboxByOperator(ctx context.Context, operatorID int32) ([]sqlc.Outbox, error) {
+	return s.listOutbox(func(e sqlc.Outbox) bool { return e.OperatorID == operatorID }), nil
+}
+
+func (s *Store) ListOutboxByOperatorStatus(ctx context.Context, arg sqlc.ListOutboxByOperatorStatusParams) ([]sqlc.Outbox, error) {
+	return s.listOutbox(func(e sqlc.Outbox) bool {
+		return e.OperatorID == arg.OperatorID && e.Status == arg.Status
+	}), nil
+}
+
+// listOutbox matches the queries: newest first, at most 200 rows.
+func (s *Store) listOutbox(match func(sqlc.Outbox) bool) []sqlc.Outbox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []sqlc.Outbox
+	all := sortedValues(s.data.outbox)
+	for i := len(all) - 1; i >= 0 && len(out) < 200; i-- {
+		if match(all[i]) {
+			out = append(out, all[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+)
+
+func (s *Store) CreateOperator(ctx context.Context, arg sqlc.CreateOperatorParams) (sqlc.Operator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, op := range s.data.operators {
+		if op.ApiKey == arg.ApiKey {
+			return sqlc.Operator{}, ErrUniqueViolation
+		}
+	}
+
+	op := sqlc.Operator{
+		ID:            s.nextID(),
+		Name:          arg.Name,
+		ApiKey:        arg.ApiKey,
+		WebhookUrl:    arg.WebhookUrl,
+		WebhookSecret: arg.WebhookSecret,
+		CreatedAt:     s.clock.Now(),
+	}
+	s.data.operators[op.ID] = op
+	return op, nil
+}
+
+func (s *Store) GetOperatorByID(ctx context.Context, id int32) (sqlc.Operator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.data.operators[id]
+	if !ok {
+		return sqlc.Operator{}, sql.ErrNoRows
+	}
+	return op, nil
+}
+
+func (s *Store) CreatePlayer(ctx context.Context, arg sqlc.CreatePlayerParams) (sqlc.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.data.players {
+		if p.OperatorID == arg.OperatorID && p.ExternalPlayerID == arg.ExternalPlayerID {
+			return sqlc.Player{}, ErrUniqueViolation
+		}
+	}
+
+	p := sqlc.Player{
+		ID:               s.nextID(),
+		OperatorID:       arg.OperatorID,
+		ExternalPlayerID: arg.ExternalPlayerID,
+		Jurisdiction:     arg.Jurisdiction,
+		CreatedAt:        s.clock.Now(),
+	}
+	s.data.players[p.ID] = p
+	return p, nil
+}
+
+func (s *Store) GetPlayer(ctx context.Context, arg sqlc.GetPlayerParams) (sqlc.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range sortedValues(s.data.players) {
+		if p.OperatorID == arg.OperatorID && p.ExternalPlayerID == arg.ExternalPlayerID {
+			return p, nil
+		}
+	}
+	return sqlc.Player{}, sql.ErrNoRows
+}
+
+func (s *Store) GetPlayerByID(ctx context.Context, arg sqlc.GetPlayerByIDParams) (sqlc.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.data.players[arg.ID]
+	if !ok || p.OperatorID != arg.OperatorID {
+		return sqlc.Player{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (s *Store) SetPlayerBlocked(ctx context.Context, arg sqlc.SetPlayerBlockedParams) (sqlc.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.data.players[arg.ID]
+	if !ok || p.OperatorID != arg.OperatorID {
+		return sqlc.Player{}, sql.ErrNoRows
+	}
+	p.Blocked = arg.Blocked
+	s.data.players[p.ID] = p
+	return p, nil
+}
+
+func (s *Store) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.sessions[arg.ID]; ok {
+		return sqlc.Session{}, ErrUniqueViolation
+	}
+
+	sess := sqlc.Session{
+		ID:          arg.ID,
+		OperatorID:  arg.OperatorID,
+		PlayerID:    arg.PlayerID,
+		LaunchToken: arg.LaunchToken,
+		ExpiresAt:   arg.ExpiresAt,
+		CreatedAt:   s.clock.Now(),
+	}
+	s.data.sessions[sess.ID] = sess
+	return sess, nil
+}
+
+func (s *Store) VerifySessionByToken(ctx context.Context, arg sqlc.VerifySessionByTokenParams) (sqlc.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := arg.Now
+	for _, sess := range s.data.sessions {
+		if sess.LaunchToken == arg.LaunchToken &&
+			sess.OperatorID == arg.OperatorID &&
+			!sess.Revoked &&
+			sess.ExpiresAt.After(now) {
+			return sess, nil
+		}
+	}
+	return sqlc.Session{}, sql.ErrNoRows
+}
+
+func (s *Store) GetSession(ctx context.Context, arg sqlc.GetSessionParams) (sqlc.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.data.sessions[arg.ID]
+	if !ok || sess.OperatorID != arg.OperatorID {
+		return sqlc.Session{}, sql.ErrNoRows
+	}
+	return sess, nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, arg sqlc.RevokeSessionParams) (sqlc.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.data.sessions[arg.ID]
+	if !ok || sess.OperatorID != arg.OperatorID {
+		return sqlc.Session{}, sql.ErrNoRows
+	}
+	sess.Revoked = true
+	s.data.sessions[arg.ID] = sess
+	return sess, nil
+}
@@ -0,0 +1,130 @@
+// Package memstore is an in-memory implementation of the service
+// repositories for tests that should not need Postgres. It mirrors the
+// semantics of the sqlc queries it replaces, including sql.ErrNoRows for
+// missing rows and the unique constraints services rely on.
+package memstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"rgs/clock"
+	"rgs/sqlc"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrUniqueViolation is returned where Postgres would reject a duplicate.
+var ErrUniqueViolation = errors.New("memstore: unique constraint violation")
+
+type state struct {
+	seq        int32
+	operators  map[int32]sqlc.Operator
+	limits     map[int32]sqlc.OperatorLimit
+	players    map[int32]sqlc.Player
+	sessions   map[uuid.UUID]sqlc.Session
+	rounds     map[int32]sqlc.Round
+	bets       map[int32]sqlc.Bet
+	outbox     map[int32]sqlc.Outbox
+	ledger     []sqlc.LedgerEntry
+	events     map[int32]sqlc.Event
+	webhooks   map[int32]sqlc.WebhookEvent
+	deliveries map[int32]sqlc.WebhookDelivery
+	endpoints  map[int32]sqlc.WebhookEndpoint
+	policies   map[int32]sqlc.WebhookRetryPolicy
+	auditLogs  []sqlc.AuditLog
+}
+
+func newState() state {
+	return state{
+		operators:  map[int32]sqlc.Operator{},
+		limits:     map[int32]sqlc.OperatorLimit{},
+		players:    map[int32]sqlc.Player{},
+		sessions:   map[uuid.UUID]sqlc.Session{},
+		rounds:     map[int32]sqlc.Round{},
+		bets:       map[int32]sqlc.Bet{},
+		outbox:     map[int32]sqlc.Outbox{},
+		events:     map[int32]sqlc.Event{},
+		webhooks:   map[int32]sqlc.WebhookEvent{},
+		deliveries: map[int32]sqlc.WebhookDelivery{},
+		endpoints:  map[int32]sqlc.WebhookEndpoint{},
+		policies:   map[int32]sqlc.WebhookRetryPolicy{},
+	}
+}
+
+func (s state) clone() state {
+	return state{
+		seq:        s.seq,
+		operators:  maps.Clone(s.operators),
+		limits:     maps.Clone(s.limits),
+		players:    maps.Clone(s.players),
+		sessions:   maps.Clone(s.sessions),
+		rounds:     maps.Clone(s.rounds),
+		bets:       maps.Clone(s.bets),
+		outbox:     maps.Clone(s.outbox),
+		ledger:     slices.Clone(s.ledger),
+		events:     maps.Clone(s.events),
+		webhooks:   maps.Clone(s.webhooks),
+		deliveries: maps.Clone(s.deliveries),
+		endpoints:  maps.Clone(s.endpoints),
+		policies:   maps.Clone(s.policies),
+		auditLogs:  slices.Clone(s.auditLogs),
+	}
+}
+
+// Store keeps every table in memory. NOW() in the queries it mirrors is
+// read from the clock; comparisons against now use the caller's value,
+// as the queries do.
+type Store struct {
+	clock clock.Clock
+	txMu  sync.Mutex
+	mu    sync.Mutex
+	data  state
+}
+
+func New(clk clock.Clock) *Store {
+	return &Store{clock: clk, data: newState()}
+}
+
+// nextID hands out ids from one sequence shared by all tables, which
+// keeps ids unique across tables and easy to tell apart in failures.
+func (s *Store) nextID() int32 {
+	s.data.seq++
+	return s.data.seq
+}
+
+// Tx returns a transaction runner for repository type R, which must be
+// an interface *Store implements. Transactions are serialised and roll
+// back by restoring a snapshot, so writes made outside a transaction
+// while one is rolling back are lost; tests should not rely on that.
+func Tx[R any](s *Store) func(context.Context, func(R) error) error {
+	return func(ctx context.Context, fn func(R) error) error {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+
+		s.mu.Lock()
+		snapshot := s.data.clone()
+		s.mu.Unlock()
+
+		if err := fn(any(s).(R)); err != nil {
+			s.mu.Lock()
+			s.data = snapshot
+			s.mu.Unlock()
+			return err
+		}
+
+		return nil
+	}
+}
+
+// sortedValues returns map values ordered by key, so listings are stable.
+func sortedValues[K int32, V any](m map[K]V) []V {
+	keys := slices.Sorted(maps.Keys(m))
+	out := make([]V, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, m[k])
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package memstore
+
+import (
+	"context"
+	"rgs/sqlc"
+)
+
+func (s *Store) InsertWebhookDelivery(ctx context.Context, arg sqlc.InsertWebhookDeliveryParams) (sqlc.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := sqlc.WebhookDelivery{
+		ID:             s.nextID(),
+		WebhookEventID: arg.WebhookEventID,
+		OperatorID:     arg.OperatorID,
+		EndpointID:     arg.EndpointID,
+		Attempt:        arg.Attempt,
+		Url:            arg.Url,
+		RequestHeaders: arg.RequestHeaders,
+		ResponseStatus: arg.ResponseStatus,
+		ResponseBody:   arg.ResponseBody,
+		LatencyMs:      arg.LatencyMs,
+		Error:          arg.Error,
+		AttemptedAt:    arg.AttemptedAt,
+	}
+	s.data.deliveries[d.ID] = d
+	return d, nil
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, arg sqlc.ListWebhookDeliveriesParams) ([]sqlc.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []sqlc.WebhookDelivery
+	for _, d := range sortedValues(s.data.deliveries) {
+		if d.WebhookEventID == arg.WebhookEventID && d.OperatorID == arg.OperatorID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
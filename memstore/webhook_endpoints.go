@@ -0,0 +1,137 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+	"slices"
+)
+
+func (s *Store) CreateWebhookEndpoint(ctx context.Context, arg sqlc.CreateWebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	e := sqlc.WebhookEndpoint{
+		ID:         s.nextID(),
+		OperatorID: arg.OperatorID,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: slices.Clone(arg.EventTypes),
+		Enabled:    arg.Enabled,
+		Ordered:    arg.Ordered,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	s.data.endpoints[e.ID] = e
+	return e, nil
+}
+
+func (s *Store) GetWebhookEndpoint(ctx context.Context, arg sqlc.GetWebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.endpoints[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return sqlc.WebhookEndpoint{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (s *Store) ListWebhookEndpoints(ctx context.Context, operatorID int32) ([]sqlc.WebhookEndpoint, error) {
+	return s.listEndpoints(func(e sqlc.WebhookEndpoint) bool { return e.OperatorID == operatorID }), nil
+}
+
+func (s *Store) ListEnabledWebhookEndpoints(ctx context.Context, operatorID int32) ([]sqlc.WebhookEndpoint, error) {
+	return s.listEndpoints(func(e sqlc.WebhookEndpoint) bool { return e.OperatorID == operatorID && e.Enabled }), nil
+}
+
+func (s *Store) listEndpoints(match func(sqlc.WebhookEndpoint) bool) []sqlc.WebhookEndpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []sqlc.WebhookEndpoint
+	for _, e := range sortedValues(s.data.endpoints) {
+		if match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (s *Store) UpdateWebhookEndpoint(ctx context.Context, arg sqlc.UpdateWebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.endpoints[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return sqlc.WebhookEndpoint{}, sql.ErrNoRows
+	}
+	e.Url = arg.Url
+	e.EventTypes = slices.Clone(arg.EventTypes)
+	e.Enabled = arg.Enabled
+	e.Ordered = arg.Ordered
+	e.UpdatedAt = s.clock.Now()
+	s.data.endpoints[e.ID] = e
+	return e, nil
+}
+
+func (s *Store) RotateWebhookEndpointSecret(ctx context.Context, arg sqlc.RotateWebhookEndpointSecretParams) (sqlc.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.endpoints[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return sqlc.WebhookEndpoint{}, sql.ErrNoRows
+	}
+	e.PreviousSecret = sql.NullString{String: e.Secret, Valid: true}
+	e.PreviousSecretExpiresAt = arg.PreviousSecretExpiresAt
+	e.Secret = arg.Secret
+	e.UpdatedAt = s.clock.Now()
+	s.data.endpoints[e.ID] = e
+	return e, nil
+}
+
+// DeleteWebhookEndpoint leaves the endpoint's webhook events and their
+// delivery attempts behind with no endpoint, like the foreign keys do.
+func (s *Store) DeleteWebhookEndpoint(ctx context.Context, arg sqlc.DeleteWebhookEndpointParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.endpoints[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return 0, nil
+	}
+	delete(s.data.endpoints, e.ID)
+	for id, w := range s.data.webhooks {
+		if w.EndpointID.Valid && w.EndpointID.Int32 == e.ID {
+			w.EndpointID = sql.NullInt32{}
+			s.data.webhooks[id] = w
+		}
+	}
+	for id, d := range s.data.deliveries {
+		if d.EndpointID.Valid && d.EndpointID.Int32 == e.ID {
+			d.EndpointID = sql.NullInt32{}
+			s.data.deliveries[id] = d
+		}
+	}
+	return 1, nil
+}
+
+func (s *Store) CancelPendingEndpointWebhooks(ctx context.Context, arg sqlc.CancelPendingEndpointWebhooksParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for _, w := range sortedValues(s.data.webhooks) {
+		if w.EndpointID != arg.EndpointID || w.OperatorID != arg.OperatorID || w.Status != "pending" {
+			continue
+		}
+		_, _ = s.updateWebhook(w.ID, func(e *sqlc.WebhookEvent) {
+			e.Status = "failed"
+			e.ErrorMessage = sql.NullString{String: "webhook endpoint deleted", Valid: true}
+		})
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"rgs/sqlc"
+)
+
+func (s *Store) GetWebhookRetryPolicy(ctx context.Context, operatorID int32) (sqlc.WebhookRetryPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.data.policies[operatorID]
+	if !ok {
+		return sqlc.WebhookRetryPolicy{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (s *Store) UpsertWebhookRetryPolicy(ctx context.Context, arg sqlc.UpsertWebhookRetryPolicyParams) (sqlc.WebhookRetryPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := sqlc.WebhookRetryPolicy{
+		OperatorID:       arg.OperatorID,
+		MaxAttempts:      arg.MaxAttempts,
+		MaxAgeSeconds:    arg.MaxAgeSeconds,
+		BaseDelaySeconds: arg.BaseDelaySeconds,
+		MaxDelaySeconds:  arg.MaxDelaySeconds,
+		Jitter:           arg.Jitter,
+		UpdatedAt:        s.clock.Now(),
+	}
+	s.data.policies[arg.OperatorID] = p
+	return p, nil
+}
+
+func (s *Store) DeleteWebhookRetryPolicy(ctx context.Context, operatorID int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.policies, operatorID)
+	return nil
+}
@@ -0,0 +1,253 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"rgs/sqlc"
+	"slices"
+)
+
+func (s *Store) InsertWebhookEvent(ctx context.Context, arg sqlc.InsertWebhookEventParams) (sqlc.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	e := sqlc.WebhookEvent{
+		ID:          s.nextID(),
+		OperatorID:  arg.OperatorID,
+		EventType:   arg.EventType,
+		Payload:     arg.Payload,
+		Status:      "pending",
+		NextRetryAt: arg.Now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		EndpointID:  arg.EndpointID,
+	}
+	s.data.webhooks[e.ID] = e
+	return e, nil
+}
+
+func (s *Store) ClaimPendingWebhookEvents(ctx context.Context, arg sqlc.ClaimPendingWebhookEventsParams) ([]sqlc.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := arg.Now
+	var due []sqlc.WebhookEvent
+	for _, e := range s.data.webhooks {
+		pending := e.Status == "pending" && !e.NextRetryAt.After(now)
+		stale := e.Status == "processing" && (!e.LockedUntil.Valid || e.LockedUntil.Time.Before(now))
+		if (pending || stale) && !s.blockedByEarlier(e) {
+			due = append(due, e)
+		}
+	}
+
+	byDue := func(a, b sqlc.WebhookEvent) int {
+		if c := a.NextRetryAt.Compare(b.NextRetryAt); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	}
+	slices.SortFunc(due, byDue)
+
+	perOperator := map[int32]int32{}
+	for i, id := range arg.InFlightOperators {
+		perOperator[id] = arg.InFlightCounts[i]
+	}
+	due = slices.DeleteFunc(due, func(e sqlc.WebhookEvent) bool {
+		perOperator[e.OperatorID]++
+		return perOperator[e.OperatorID] > arg.PerOperator
+	})
+	if len(due) > int(arg.BatchSize) {
+		due = due[:arg.BatchSize]
+	}
+
+	for i := range due {
+		due[i].Status = "processing"
+		due[i].LockedBy = sql.NullString{String: arg.WorkerID, Valid: true}
+		due[i].LockedUntil = sql.NullTime{Time: arg.LockedUntil, Valid: true}
+		due[i].UpdatedAt = s.clock.Now()
+		s.data.webhooks[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// blockedByEarlier reports whether e goes to an ordered endpoint that
+// still has an earlier delivery pending or processing.
+func (s *Store) blockedByEarlier(e sqlc.WebhookEvent) bool {
+	if !e.EndpointID.Valid || !s.data.endpoints[e.EndpointID.Int32].Ordered {
+		return false
+	}
+	for _, prev := range s.data.webhooks {
+		open := prev.Status == "pending" || prev.Status == "processing"
+		if open && prev.EndpointID == e.EndpointID && prev.ID < e.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) CountQueuedWebhooksByOperator(ctx context.Context) ([]sqlc.CountQueuedWebhooksByOperatorRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queued := map[int32]int32{}
+	for _, e := range s.data.webhooks {
+		if e.Status == "pending" || e.Status == "processing" {
+			queued[e.OperatorID]++
+		}
+	}
+
+	var out []sqlc.CountQueuedWebhooksByOperatorRow
+	for _, id := range slices.Sorted(maps.Keys(queued)) {
+		out = append(out, sqlc.CountQueuedWebhooksByOperatorRow{OperatorID: id, Queued: queued[id]})
+	}
+	return out, nil
+}
+
+func (s *Store) updateWebhook(id int32, fn func(*sqlc.WebhookEvent)) (sqlc.WebhookEvent, error) {
+	e, ok := s.data.webhooks[id]
+	if !ok {
+		return sqlc.WebhookEvent{}, sql.ErrNoRows
+	}
+	fn(&e)
+	e.LockedUntil = sql.NullTime{}
+	e.UpdatedAt = s.clock.Now()
+	s.data.webhooks[id] = e
+	return e, nil
+}
+
+// finishWebhook applies fn to a delivery workerID still holds the
+// lease on, and reports whether it did.
+func (s *Store) finishWebhook(id int32, workerID string, fn func(*sqlc.WebhookEvent)) int64 {
+	e, ok := s.data.webhooks[id]
+	if !ok || e.Status != "processing" || e.LockedBy.String != workerID {
+		return 0
+	}
+	_, _ = s.updateWebhook(id, fn)
+	return 1
+}
+
+func (s *Store) MarkWebhookCompleted(ctx context.Context, arg sqlc.MarkWebhookCompletedParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishWebhook(arg.ID, arg.WorkerID, func(e *sqlc.WebhookEvent) {
+		e.Status = "completed"
+	}), nil
+}
+
+func (s *Store) MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishWebhook(arg.ID, arg.WorkerID, func(e *sqlc.WebhookEvent) {
+		e.Status = "failed"
+		e.ErrorMessage = arg.ErrorMessage
+	}), nil
+}
+
+func (s *Store) MarkWebhookDead(ctx context.Context, arg sqlc.MarkWebhookDeadParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishWebhook(arg.ID, arg.WorkerID, func(e *sqlc.WebhookEvent) {
+		e.Status = "dead"
+		e.Retries = arg.Retries
+		e.ErrorMessage = arg.ErrorMessage
+	}), nil
+}
+
+func (s *Store) UpdateWebhookRetry(ctx context.Context, arg sqlc.UpdateWebhookRetryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.finishWebhook(arg.ID, arg.WorkerID, func(e *sqlc.WebhookEvent) {
+		e.Retries++
+		e.NextRetryAt = arg.NextRetryAt
+		e.Status = "pending"
+		e.ErrorMessage = arg.ErrorMessage
+	}), nil
+}
+
+func (s *Store) ResetWebhookForRetry(ctx context.Context, arg sqlc.ResetWebhookForRetryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.webhooks[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID || !slices.Contains([]string{"failed", "dead", "completed"}, e.Status) {
+		return 0, nil
+	}
+	now := arg.Now
+	_, _ = s.updateWebhook(arg.ID, func(e *sqlc.WebhookEvent) {
+		e.Status = "pending"
+		e.Retries = 0
+		e.NextRetryAt = now
+		e.ErrorMessage = sql.NullString{}
+		e.RequeuedAt = sql.NullTime{Time: now, Valid: true}
+	})
+	return 1, nil
+}
+
+func (s *Store) ReplayWebhookEvents(ctx context.Context, arg sqlc.ReplayWebhookEventsParams) ([]int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := arg.Now
+	var ids []int32
+	for _, e := range sortedValues(s.data.webhooks) {
+		match := e.OperatorID == arg.OperatorID &&
+			slices.Contains(arg.Statuses, e.Status) &&
+			(!arg.EventType.Valid || e.EventType == arg.EventType.String) &&
+			!e.CreatedAt.Before(arg.CreatedFrom) &&
+			e.CreatedAt.Before(arg.CreatedTo)
+		if !match {
+			continue
+		}
+		_, _ = s.updateWebhook(e.ID, func(e *sqlc.WebhookEvent) {
+			e.Status = "pending"
+			e.Retries = 0
+			e.NextRetryAt = now
+			e.ErrorMessage = sql.NullString{}
+			e.RequeuedAt = sql.NullTime{Time: now, Valid: true}
+		})
+		ids = append(ids, e.ID)
+	}
+	return ids, nil
+}
+
+func (s *Store) GetWebhookEventByID(ctx context.Context, arg sqlc.GetWebhookEventByIDParams) (sqlc.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.webhooks[arg.ID]
+	if !ok || e.OperatorID != arg.OperatorID {
+		return sqlc.WebhookEvent{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (s *Store) ListWebhooksByOperator(ctx context.Context, operatorID int32) ([]sqlc.WebhookEvent, error) {
+	return s.listWebhooks(func(e sqlc.WebhookEvent) bool { return e.OperatorID == operatorID }), nil
+}
+
+func (s *Store) ListWebhooksByOperatorStatus(ctx context.Context, arg sqlc.ListWebhooksByOperatorStatusParams) ([]sqlc.WebhookEvent, error) {
+	return s.listWebhooks(func(e sqlc.WebhookEvent) bool {
+		return e.OperatorID == arg.OperatorID && e.Status == arg.Status
+	}), nil
+}
+
+// listWebhooks matches the queries: newest first, at most 200 rows.
+func (s *Store) listWebhooks(match func(sqlc.WebhookEvent) bool) []sqlc.WebhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []sqlc.WebhookEvent
+	all := sortedValues(s.data.webhooks)
+	for i := len(all) - 1; i >= 0 && len(out) < 200; i-- {
+		if match(all[i]) {
+			out = append(out, all[i])
+		}
+	}
+	return out
+}
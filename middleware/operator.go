@@ -19,12 +19,21 @@ func OperatorFromContext(ctx context.Context) (sqlc.Operator, bool) {
 	return operator, ok
 }
 
+// TenantPinner binds the rest of a request's queries to its operator;
+// services.TenantDB is the row-level security implementation.
+type TenantPinner interface {
+	Pin(ctx context.Context, operatorID int32) (context.Context, func())
+}
+
 type OperatorMiddleware struct {
 	queries *sqlc.Queries
+	tenants TenantPinner
 }
 
-func NewOperatorMiddleware(q *sqlc.Queries) *OperatorMiddleware {
-	return &OperatorMiddleware{queries: q}
+// NewOperatorMiddleware authenticates operators with q. tenants is nil
+// unless row-level security is on.
+func NewOperatorMiddleware(q *sqlc.Queries, tenants TenantPinner) *OperatorMiddleware {
+	return &OperatorMiddleware{queries: q, tenants: tenants}
 }
 
 func (m *OperatorMiddleware) Handle(next http.Handler) http.Handler {
@@ -42,6 +51,11 @@ func (m *OperatorMiddleware) Handle(next http.Handler) http.Handler {
 		}
 
 		ctx := ContextWithOperator(r.Context(), operator)
+		if m.tenants != nil {
+			var release func()
+			ctx, release = m.tenants.Pin(ctx, operator.ID)
+			defer release()
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -22,6 +23,16 @@ func NewRateLimiter(rps int, burst int) *RateLimiter {
 	}
 }
 
+// NewRateLimiterPerMinute allows n requests a minute per operator, in
+// bursts of up to n.
+func NewRateLimiterPerMinute(n int) *RateLimiter {
+	return &RateLimiter{
+		limiters: make(map[int32]*rate.Limiter),
+		r:        rate.Every(time.Minute / time.Duration(n)),
+		burst:    n,
+	}
+}
+
 func (rl *RateLimiter) getLimiter(operatorID int32) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS ledger_entries_balanced ON ledger_entries;
+DROP FUNCTION IF EXISTS ledger_check_balanced();
+DROP TABLE IF EXISTS ledger_entries;
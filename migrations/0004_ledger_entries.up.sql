@@ -0,0 +1,41 @@
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL,
+    operator_id INT NOT NULL REFERENCES operators(id),
+    player_id INT REFERENCES players(id),
+    bet_id INT REFERENCES bets(id),
+    outbox_id INT REFERENCES outbox(id),
+    entry_type TEXT NOT NULL, -- stake / win / outbox_retry
+    account TEXT NOT NULL, -- player_wallet / house / settlement_payable
+    direction TEXT NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_entries_transaction_idx ON ledger_entries (transaction_id);
+CREATE INDEX ledger_entries_player_idx ON ledger_entries (operator_id, player_id, created_at);
+CREATE INDEX ledger_entries_account_idx ON ledger_entries (operator_id, account, created_at);
+CREATE INDEX ledger_entries_bet_idx ON ledger_entries (bet_id);
+
+-- Every ledger transaction must balance by the time it commits.
+CREATE FUNCTION ledger_check_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    imbalance NUMERIC;
+BEGIN
+    SELECT COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount ELSE -amount END), 0)
+    INTO imbalance
+    FROM ledger_entries
+    WHERE transaction_id = NEW.transaction_id;
+
+    IF imbalance <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % is unbalanced by %', NEW.transaction_id, imbalance;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
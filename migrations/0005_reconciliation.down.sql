@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reconciliation_discrepancies;
+DROP TABLE IF EXISTS reconciliation_runs;
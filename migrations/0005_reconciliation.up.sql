@@ -0,0 +1,30 @@
+CREATE TABLE reconciliation_runs (
+    id SERIAL PRIMARY KEY,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running', -- running / completed / failed
+    checked INT NOT NULL DEFAULT 0,
+    discrepancies INT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX reconciliation_runs_window_idx ON reconciliation_runs (window_start, window_end);
+
+CREATE TABLE reconciliation_discrepancies (
+    id SERIAL PRIMARY KEY,
+    run_id INT NOT NULL REFERENCES reconciliation_runs(id),
+    operator_id INT REFERENCES operators(id),
+    bet_id INT REFERENCES bets(id),
+    player_id INT NOT NULL,
+    request_id TEXT NOT NULL,
+    kind TEXT NOT NULL, -- missing / duplicated / mismatched / unexpected
+    direction TEXT NOT NULL, -- debit / credit
+    expected_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    actual_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX reconciliation_discrepancies_operator_idx ON reconciliation_discrepancies (operator_id, run_id);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS outbox_due_idx;
+
+ALTER TABLE outbox ADD COLUMN processed BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE outbox SET processed = TRUE WHERE status = 'succeeded';
+
+ALTER TABLE outbox
+    DROP COLUMN status,
+    DROP COLUMN attempts,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN last_error,
+    DROP COLUMN updated_at;
+
+DROP TYPE IF EXISTS outbox_status;
@@ -0,0 +1,14 @@
+CREATE TYPE outbox_status AS ENUM ('pending', 'retrying', 'succeeded', 'dead');
+
+ALTER TABLE outbox
+    ADD COLUMN status outbox_status NOT NULL DEFAULT 'pending',
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE outbox SET status = 'succeeded' WHERE processed;
+
+ALTER TABLE outbox DROP COLUMN processed;
+
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at) WHERE status IN ('pending', 'retrying');
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS webhook_events_due_idx;
+
+ALTER TABLE webhook_events
+    DROP COLUMN locked_by,
+    DROP COLUMN locked_until;
+
+ALTER TABLE outbox
+    DROP COLUMN locked_by,
+    DROP COLUMN locked_until;
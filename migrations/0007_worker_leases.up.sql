@@ -0,0 +1,9 @@
+ALTER TABLE outbox
+    ADD COLUMN locked_by TEXT,
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+ALTER TABLE webhook_events
+    ADD COLUMN locked_by TEXT,
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+CREATE INDEX webhook_events_due_idx ON webhook_events (next_retry_at) WHERE status IN ('pending', 'processing');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS outbox_credit_tx_id_idx;
+
+ALTER TABLE outbox DROP COLUMN credit_tx_id;
@@ -0,0 +1,13 @@
+ALTER TABLE outbox ADD COLUMN credit_tx_id TEXT;
+
+-- Existing entries keep the key of the inline attempt, which is the one
+-- that may have landed without the RGS seeing the response.
+UPDATE outbox o
+SET credit_tx_id = b.idempotency_key || '-win'
+FROM bets b
+WHERE b.id = o.bet_id;
+
+ALTER TABLE outbox ALTER COLUMN credit_tx_id SET NOT NULL;
+
+-- Idempotency keys are only unique per operator, so backfilled ids are too.
+CREATE UNIQUE INDEX outbox_credit_tx_id_idx ON outbox (operator_id, credit_tx_id);
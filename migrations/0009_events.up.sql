@@ -0,0 +1,13 @@
+CREATE TABLE events (
+    id SERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    operator_id INT NOT NULL REFERENCES operators(id),
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ,
+    locked_by TEXT,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX events_undispatched_idx ON events (id) WHERE dispatched_at IS NULL;
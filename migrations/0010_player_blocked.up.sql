@@ -0,0 +1 @@
+ALTER TABLE players ADD COLUMN blocked BOOLEAN NOT NULL DEFAULT FALSE;
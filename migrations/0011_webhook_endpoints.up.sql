@@ -0,0 +1,23 @@
+CREATE TABLE webhook_endpoints (
+    id SERIAL PRIMARY KEY,
+    operator_id INT NOT NULL REFERENCES operators(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_endpoints_operator_idx ON webhook_endpoints (operator_id);
+
+-- One delivery row per endpoint; rows enqueued before this migration have
+-- no endpoint and still go to the operator's webhook_url.
+ALTER TABLE webhook_events
+    ADD COLUMN endpoint_id INT REFERENCES webhook_endpoints(id) ON DELETE CASCADE;
+
+-- Keep existing operators receiving what they received before.
+INSERT INTO webhook_endpoints (operator_id, url, secret, event_types)
+SELECT id, webhook_url, webhook_secret, ARRAY['bet_settled', 'settlement_success']
+FROM operators
+WHERE webhook_url <> '';
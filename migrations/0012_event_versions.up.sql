@@ -0,0 +1,3 @@
+-- Schema version of the payload, stamped when the event is recorded so a
+-- dispatcher running other code still labels it correctly.
+ALTER TABLE events ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+DROP TABLE webhook_retry_policies;
@@ -0,0 +1,11 @@
+-- Per-operator override of the webhook retry policy; operators without a
+-- row use the configured defaults.
+CREATE TABLE webhook_retry_policies (
+    operator_id INT PRIMARY KEY REFERENCES operators(id) ON DELETE CASCADE,
+    max_attempts INT NOT NULL,
+    max_age_seconds INT NOT NULL,
+    base_delay_seconds INT NOT NULL,
+    max_delay_seconds INT NOT NULL,
+    jitter DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
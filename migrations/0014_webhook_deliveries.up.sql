@@ -0,0 +1,17 @@
+-- One row per delivery attempt; webhook_events keeps only the latest error.
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_event_id INT NOT NULL REFERENCES webhook_events(id) ON DELETE CASCADE,
+    operator_id INT NOT NULL REFERENCES operators(id),
+    endpoint_id INT REFERENCES webhook_endpoints(id) ON DELETE SET NULL,
+    attempt INT NOT NULL,
+    url TEXT NOT NULL,
+    request_headers JSONB NOT NULL,
+    response_status INT,
+    response_body TEXT,
+    latency_ms INT NOT NULL,
+    error TEXT,
+    attempted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_event_idx ON webhook_deliveries (webhook_event_id, id);
@@ -0,0 +1,5 @@
+-- The secret replaced by the last rotation keeps signing deliveries until
+-- previous_secret_expires_at, so receivers can switch without downtime.
+ALTER TABLE webhook_endpoints
+    ADD COLUMN previous_secret TEXT,
+    ADD COLUMN previous_secret_expires_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+DROP INDEX webhook_events_endpoint_open_idx;
+
+ALTER TABLE webhook_endpoints
+    DROP COLUMN ordered;
@@ -0,0 +1,8 @@
+-- An ordered endpoint gets its deliveries one at a time in enqueue order:
+-- a delivery waits while an earlier one to the same endpoint is pending.
+ALTER TABLE webhook_endpoints
+    ADD COLUMN ordered BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX webhook_events_endpoint_open_idx
+    ON webhook_events (endpoint_id, id)
+    WHERE status IN ('pending', 'processing');
@@ -0,0 +1,4 @@
+-- Set when an operator re-queues a webhook. Max age counts from here
+-- rather than created_at, so a redelivered event gets a fresh lifetime.
+ALTER TABLE webhook_events
+    ADD COLUMN requeued_at TIMESTAMPTZ;
@@ -0,0 +1,32 @@
+DROP POLICY operator_isolation ON audit_logs;
+ALTER TABLE audit_logs DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON webhook_events;
+ALTER TABLE webhook_events DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON outbox;
+ALTER TABLE outbox DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON bets;
+ALTER TABLE bets DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON rounds;
+ALTER TABLE rounds DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON sessions;
+ALTER TABLE sessions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY operator_isolation ON players;
+ALTER TABLE players DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION app_operator_id();
+
+-- rgs_api is cluster-wide and may serve other databases, so only this
+-- database's grants are removed.
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE USAGE, SELECT ON SEQUENCES FROM rgs_api;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM rgs_api;
+REVOKE ALL ON ALL SEQUENCES IN SCHEMA public FROM rgs_api;
+REVOKE ALL ON ALL TABLES IN SCHEMA public FROM rgs_api;
+REVOKE USAGE ON SCHEMA public FROM rgs_api;
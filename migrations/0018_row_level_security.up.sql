@@ -0,0 +1,55 @@
+-- Row-level security for the API's restricted role. The tables' owner,
+-- which workers and migrations connect as, bypasses these policies, so
+-- they only take effect when database.api_url logs in as a member of
+-- rgs_api. Creating the role needs CREATEROLE; create it beforehand
+-- where the migrator lacks it.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'rgs_api') THEN
+        CREATE ROLE rgs_api NOLOGIN;
+    END IF;
+END
+$$;
+
+GRANT USAGE ON SCHEMA public TO rgs_api;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO rgs_api;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO rgs_api;
+REVOKE ALL ON schema_migrations FROM rgs_api;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO rgs_api;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT USAGE, SELECT ON SEQUENCES TO rgs_api;
+
+-- The operator a request is pinned to, or NULL on a connection that was
+-- never pinned, which matches no row.
+CREATE FUNCTION app_operator_id() RETURNS INT
+    LANGUAGE sql STABLE
+    AS $$ SELECT NULLIF(current_setting('app.operator_id', true), '')::INT $$;
+
+ALTER TABLE players ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON players
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON sessions
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE rounds ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON rounds
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE bets ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON bets
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON outbox
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE webhook_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON webhook_events
+    USING (operator_id = app_operator_id());
+
+ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
+CREATE POLICY operator_isolation ON audit_logs
+    USING (operator_id = app_operator_id());
@@ -0,0 +1,3 @@
+-- Attached deliveries keep their endpoint: which ones were endpoint-less
+-- is not recorded, and the endpoint signs them the same way.
+SELECT 1;
@@ -0,0 +1,24 @@
+-- Deliveries queued before 0011 have no endpoint and were signed with the
+-- operator's webhook_secret, which cannot be rotated. Attach them to the
+-- endpoint 0011 created from the operator's webhook_url, so they sign and
+-- rotate like every other delivery.
+UPDATE webhook_events e
+SET endpoint_id = (
+    SELECT ep.id
+    FROM webhook_endpoints ep
+    JOIN operators o ON o.id = ep.operator_id
+    WHERE ep.operator_id = e.operator_id
+      AND ep.url = o.webhook_url
+    ORDER BY ep.id
+    LIMIT 1
+)
+WHERE e.endpoint_id IS NULL;
+
+-- Anything left has nowhere to go.
+UPDATE webhook_events
+SET status = 'dead',
+    locked_until = NULL,
+    error_message = 'no webhook endpoint',
+    updated_at = NOW()
+WHERE endpoint_id IS NULL
+  AND status IN ('pending', 'processing');
@@ -0,0 +1,4 @@
+ALTER TABLE webhook_events
+    DROP CONSTRAINT webhook_events_endpoint_id_fkey,
+    ADD CONSTRAINT webhook_events_endpoint_id_fkey
+        FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE;
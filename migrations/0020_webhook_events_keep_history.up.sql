@@ -0,0 +1,7 @@
+-- Deleting an endpoint used to cascade to every delivery made to it and,
+-- through webhook_events, to their attempt log. Keep the history; the
+-- endpoint's pending deliveries are cancelled by the delete instead.
+ALTER TABLE webhook_events
+    DROP CONSTRAINT webhook_events_endpoint_id_fkey,
+    ADD CONSTRAINT webhook_events_endpoint_id_fkey
+        FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE SET NULL;
@@ -0,0 +1,287 @@
+// Package migrations embeds the schema migrations and applies them using
+// the same schema_migrations bookkeeping as golang-migrate, so databases
+// migrated by either tool stay interchangeable.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"rgs/sqlc"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey serialises concurrent runners across replicas.
+const lockKey int64 = 0x7267735f6d6967 // "rgs_mig"
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+var (
+	ErrDirty  = errors.New("database is dirty, fix the failed migration and reset schema_migrations")
+	ErrLocked = errors.New("another migration is running")
+)
+
+type Migration struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"-"`
+	Down    string `json:"-"`
+}
+
+type Status struct {
+	Version int64       `json:"version"`
+	Dirty   bool        `json:"dirty"`
+	Latest  int64       `json:"latest"`
+	Pending []Migration `json:"pending"`
+}
+
+// Load returns the embedded migrations ordered by version.
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := fs.ReadFile(files, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if m[3] == "up" {
+			mig.Up = string(raw)
+		} else {
+			mig.Down = string(raw)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+	return out, nil
+}
+
+// Latest is the newest embedded migration version.
+func Latest() int64 {
+	all, err := Load()
+	if err != nil || len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
+
+type Runner struct {
+	db *sql.DB
+}
+
+func NewRunner(db *sql.DB) *Runner {
+	return &Runner{db: db}
+}
+
+// withLock runs fn on one connection holding the migration advisory lock.
+func (r *Runner) withLock(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	q := sqlc.New(conn)
+	acquired, err := q.TryAdvisoryLock(ctx, lockKey)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrLocked
+	}
+	defer func() { _, _ = q.AdvisoryUnlock(context.WithoutCancel(ctx), lockKey) }()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    dirty BOOLEAN NOT NULL
+)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// version returns -1 when no migration has been applied.
+func version(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var v int64
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, false, nil
+	}
+	return v, dirty, err
+}
+
+func setVersion(ctx context.Context, conn *sql.Conn, v int64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "TRUNCATE schema_migrations"); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if v >= 0 {
+		_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", v, dirty)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Runner) Status(ctx context.Context) (Status, error) {
+	all, err := Load()
+	if err != nil {
+		return Status{}, err
+	}
+
+	var st Status
+	err = r.withLock(ctx, func(conn *sql.Conn) error {
+		st.Version, st.Dirty, err = version(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return Status{}, err
+	}
+
+	st.Pending = []Migration{}
+	for _, m := range all {
+		st.Latest = m.Version
+		if m.Version > st.Version {
+			st.Pending = append(st.Pending, m)
+		}
+	}
+
+	return st, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = r.withLock(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", current, ErrDirty)
+		}
+
+		for _, m := range all {
+			if m.Version <= current {
+				continue
+			}
+
+			if err := setVersion(ctx, conn, m.Version, true); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if err := setVersion(ctx, conn, m.Version, false); err != nil {
+				return err
+			}
+
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the newest steps migrations.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = r.withLock(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", current, ErrDirty)
+		}
+
+		for i := len(all) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := all[i]
+			if m.Version > current {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+			}
+
+			prev := int64(-1)
+			if i > 0 {
+				prev = all[i-1].Version
+			}
+
+			if err := setVersion(ctx, conn, prev, true); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, m.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if err := setVersion(ctx, conn, prev, false); err != nil {
+				return err
+			}
+
+			reverted = append(reverted, m)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
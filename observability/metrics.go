@@ -31,6 +31,22 @@ var (
 		Help:    "Time spent processing bet settlement pipeline",
 		Buckets: prometheus.DefBuckets,
 	})
+
+	WebhookQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rgs_webhook_queue_depth",
+		Help: "Webhook deliveries pending or processing, by operator",
+	}, []string{"operator_id"})
+
+	WebhooksInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rgs_webhook_in_flight",
+		Help: "Webhook deliveries this worker has claimed and not finished, by operator",
+	}, []string{"operator_id"})
+
+	WebhookDeliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rgs_webhook_delivery_seconds",
+		Help:    "Latency of webhook delivery attempts, by operator and outcome",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operator_id", "outcome"})
 )
 
 func InitMetrics() {
@@ -39,4 +55,7 @@ func InitMetrics() {
 	prometheus.MustRegister(WalletDebitCalls)
 	prometheus.MustRegister(WalletDebitFailures)
 	prometheus.MustRegister(BetSettlementDuration)
+	prometheus.MustRegister(WebhookQueueDepth)
+	prometheus.MustRegister(WebhooksInFlight)
+	prometheus.MustRegister(WebhookDeliveryDuration)
 }
@@ -11,13 +11,13 @@ import (
 	"go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-func InitTracing() func(context.Context) error {
+func InitTracing(endpoint string) func(context.Context) error {
 	ctx := context.Background()
 
 	exp, err := otlptracegrpc.New(
 		ctx,
 		otlptracegrpc.WithInsecure(),
-		otlptracegrpc.WithEndpoint("otel-collector:4317"),
+		otlptracegrpc.WithEndpoint(endpoint),
 	)
 	if err != nil {
 		log.Fatal("failed to create OTLP exporter: ", err)
@@ -0,0 +1,83 @@
+package services
+
+import "time"
+
+type RetryPolicy struct {
+	MaxAttempts int32
+	// MaxAge bounds how long after creation an item may still be tried;
+	// zero means no bound.
+	MaxAge    time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction, between 0 and 1, by which Jittered may
+	// shorten a delay.
+	Jitter float64
+}
+
+var DefaultOutboxRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    10 * time.Minute,
+}
+
+// DefaultWebhookRetryPolicy keeps retrying for about a day, which rides
+// out a receiver that is down for hours.
+var DefaultWebhookRetryPolicy = RetryPolicy{
+	MaxAttempts: 25,
+	MaxAge:      24 * time.Hour,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    time.Hour,
+	Jitter:      0.2,
+}
+
+// Backoff returns the delay after the given number of failed attempts:
+// BaseDelay doubled per attempt, capped at MaxDelay.
+func (p RetryPolicy) Backoff(attempts int32) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Horizon is how long after the first failed attempt the last one can
+// come: the backoffs between attempts, or MaxAge if that is shorter. It
+// is zero when neither bounds the retries.
+func (p RetryPolicy) Horizon() time.Duration {
+	var total time.Duration
+	for i := int32(1); i < p.MaxAttempts; i++ {
+		total += p.Backoff(i)
+	}
+	if p.MaxAge > 0 && (p.MaxAttempts <= 0 || p.MaxAge < total) {
+		return p.MaxAge
+	}
+	return total
+}
+
+// Jittered shortens d by up to Jitter of itself, r being uniform in
+// [0, 1), so deliveries that failed together do not all retry together.
+func (p RetryPolicy) Jittered(d time.Duration, r float64) time.Duration {
+	return d - time.Duration(float64(d)*p.Jitter*r)
+}
+
+// Expired reports whether something created at created is past MaxAge.
+func (p RetryPolicy) Expired(created, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(created) > p.MaxAge
+}
+
+// Exhausted reports whether no attempt is left after the given number of
+// failed attempts.
+func (p RetryPolicy) Exhausted(attempts int32) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoffIsCapped(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: time.Minute}
+
+	want := []time.Duration{0, 5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for attempts, w := range want {
+		if got := p.Backoff(int32(attempts)); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, w)
+		}
+	}
+}
+
+func TestRetryPolicyJittered(t *testing.T) {
+	p := RetryPolicy{Jitter: 0.2}
+
+	if got := p.Jittered(10*time.Second, 0); got != 10*time.Second {
+		t.Errorf("Jittered(10s, 0) = %v, want 10s", got)
+	}
+	if got := p.Jittered(10*time.Second, 0.5); got != 9*time.Second {
+		t.Errorf("Jittered(10s, 0.5) = %v, want 9s", got)
+	}
+	if got := (RetryPolicy{}).Jittered(10*time.Second, 0.9); got != 10*time.Second {
+		t.Errorf("no jitter: got %v, want 10s", got)
+	}
+}
+
+func TestRetryPolicyExpired(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := RetryPolicy{MaxAge: time.Hour}
+
+	if p.Expired(created, created.Add(time.Hour)) {
+		t.Error("expired at exactly MaxAge")
+	}
+	if !p.Expired(created, created.Add(time.Hour+time.Second)) {
+		t.Error("not expired past MaxAge")
+	}
+	if (RetryPolicy{}).Expired(created, created.Add(1000*time.Hour)) {
+		t.Error("zero MaxAge expired")
+	}
+}
+
+func TestRetryPolicyHorizon(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: 5 * time.Second, MaxDelay: 10 * time.Second}
+
+	if got := p.Horizon(); got != 25*time.Second {
+		t.Errorf("Horizon() = %v, want 5s+10s+10s", got)
+	}
+	p.MaxAge = 20 * time.Second
+	if got := p.Horizon(); got != 20*time.Second {
+		t.Errorf("Horizon() with MaxAge = %v, want 20s", got)
+	}
+	if got := (RetryPolicy{MaxAge: time.Hour}).Horizon(); got != time.Hour {
+		t.Errorf("unlimited attempts: got %v, want MaxAge", got)
+	}
+}
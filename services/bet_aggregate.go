@@ -3,44 +3,49 @@ package services
 import (
 	"context"
 	"crypto/rand"
-	"database/sql"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"rgs/game"
 	"rgs/observability"
 	"rgs/sqlc"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
 type BetAggregate struct {
-	queries    *sqlc.Queries
-	db         *sql.DB
-	wallet     *WalletClient
-	bus        *EventBus
+	repo       BetRepo
+	tx         Tx[BetRepo]
+	wallet     Wallet
+	events     *EventDispatcher
 	compliance *ComplianceService
+	ledger     *LedgerService
+	newSeed    func() (string, error)
 }
 
 func NewBetAggregate(
-	q *sqlc.Queries,
-	wallet *WalletClient,
-	bus *EventBus,
-	db *sql.DB,
+	repo BetRepo,
+	tx Tx[BetRepo],
+	wallet Wallet,
+	events *EventDispatcher,
 	compliance *ComplianceService,
+	ledger *LedgerService,
 ) *BetAggregate {
 	return &BetAggregate{
-		queries:    q,
-		db:         db,
+		repo:       repo,
+		tx:         tx,
 		wallet:     wallet,
-		bus:        bus,
+		events:     events,
 		compliance: compliance,
+		ledger:     ledger,
+		newSeed:    generateRandomSeed,
 	}
 }
 
+// ErrPlayerNotFound is also what another operator's player id gets.
+var ErrPlayerNotFound = errors.New("player not found")
+
 type PlaceBetParams struct {
 	OperatorID     int32
 	PlayerID       int32
@@ -48,6 +53,13 @@ type PlaceBetParams struct {
 	IdempotencyKey string
 }
 
+// CreditTxID is the wallet request id of a bet's win credit. It is derived
+// from the bet alone so the inline attempt and every outbox retry share it,
+// letting the wallet dedupe a credit that landed but timed out.
+func CreditTxID(betID int32) string {
+	return fmt.Sprintf("bet-%d-win", betID)
+}
+
 func generateRandomSeed() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
@@ -57,22 +69,6 @@ func generateRandomSeed() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func (b *BetAggregate) withTx(ctx context.Context, fn func(*sqlc.Queries) error) error {
-	tx, err := b.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	qtx := b.queries.WithTx(tx)
-
-	if err := fn(qtx); err != nil {
-		_ = tx.Rollback()
-		return err
-	}
-
-	return tx.Commit()
-}
-
 func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Round, sqlc.Bet, error) {
 	var round sqlc.Round
 	var bet sqlc.Bet
@@ -80,35 +76,45 @@ func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Rou
 	timer := prometheus.NewTimer(observability.BetSettlementDuration)
 	defer timer.ObserveDuration()
 
-	err := b.withTx(ctx, func(q *sqlc.Queries) error {
+	err := b.tx(ctx, func(q BetRepo) error {
 		existing, err := q.GetBetByIdempotency(ctx, sqlc.GetBetByIdempotencyParams{
 			OperatorID:     p.OperatorID,
 			IdempotencyKey: p.IdempotencyKey,
 		})
 		if err == nil {
-			round, _ = q.GetRound(ctx, existing.RoundID)
+			round, _ = q.GetRound(ctx, sqlc.GetRoundParams{
+				ID:         existing.RoundID,
+				OperatorID: p.OperatorID,
+			})
 			bet = existing
 			return nil
 		}
 
-		player, err := b.queries.GetPlayerByID(ctx, p.PlayerID)
+		player, err := b.repo.GetPlayerByID(ctx, sqlc.GetPlayerByIDParams{
+			ID:         p.PlayerID,
+			OperatorID: p.OperatorID,
+		})
 		if err != nil {
-			return errors.New("player not found")
+			return ErrPlayerNotFound
 		}
 
 		jurisdiction := player.Jurisdiction
 
 		if b.compliance != nil {
+			if err := b.compliance.CheckPlayer(ctx, player); err != nil {
+				return err
+			}
+
 			if err := b.compliance.Check(ctx, p.OperatorID, p.PlayerID, jurisdiction, p.Amount); err != nil {
 				return err
 			}
 		}
 
-		serverSeed, err := generateRandomSeed()
+		serverSeed, err := b.newSeed()
 		if err != nil {
 			return err
 		}
-		clientSeed, err := generateRandomSeed()
+		clientSeed, err := b.newSeed()
 		if err != nil {
 			return err
 		}
@@ -126,20 +132,15 @@ func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Rou
 			return err
 		}
 
-		if b.bus != nil {
-			b.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: p.OperatorID,
-				EventType:  "round.finished",
-				Data: map[string]any{
-					"round_id":    round.ID,
-					"player_id":   p.PlayerID,
-					"server_seed": serverSeed,
-					"client_seed": clientSeed,
-					"outcome":     pf.Outcome,
-				},
-				CreatedAt: time.Now(),
-			})
+		err = b.events.Record(ctx, q, p.OperatorID, "round.finished", RoundFinished{
+			RoundID:    round.ID,
+			PlayerID:   p.PlayerID,
+			ServerSeed: serverSeed,
+			ClientSeed: clientSeed,
+			Outcome:    pf.Outcome,
+		})
+		if err != nil {
+			return err
 		}
 
 		bet, err = q.CreateBet(ctx, sqlc.CreateBetParams{
@@ -168,21 +169,21 @@ func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Rou
 			return errors.New("wallet debit failed")
 		}
 
-		if b.bus != nil {
-			b.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: p.OperatorID,
-				EventType:  "wallet.debit",
-				Data: map[string]any{
-					"player_id": p.PlayerID,
-					"amount":    p.Amount,
-				},
-				CreatedAt: time.Now(),
-			})
+		if err := b.ledger.PostStake(ctx, q, bet); err != nil {
+			return err
+		}
+
+		err = b.events.Record(ctx, q, p.OperatorID, "wallet.debit", WalletDebited{
+			PlayerID: p.PlayerID,
+			Amount:   p.Amount,
+		})
+		if err != nil {
+			return err
 		}
 
 		winAmount := 0.0
 		status := "lost"
+		creditTxID := ""
 
 		if pf.Outcome == 6 {
 			winAmount = p.Amount * 5
@@ -190,18 +191,28 @@ func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Rou
 		}
 
 		if status == "won" {
-			creditKey := p.IdempotencyKey + "-win"
+			creditTxID = CreditTxID(bet.ID)
 
-			okCredit, errCredit := b.wallet.Credit(ctx, p.PlayerID, winAmount, creditKey)
+			okCredit, errCredit := b.wallet.Credit(ctx, p.PlayerID, winAmount, creditTxID)
 			if errCredit != nil || !okCredit {
 				status = "pending_settlement"
 
-				_, _ = q.InsertOutbox(ctx, sqlc.InsertOutboxParams{
+				entry, err := q.InsertOutbox(ctx, sqlc.InsertOutboxParams{
 					BetID:      bet.ID,
 					OperatorID: p.OperatorID,
 					PlayerID:   p.PlayerID,
 					Amount:     winAmount,
+					CreditTxID: creditTxID,
 				})
+				if err != nil {
+					return err
+				}
+
+				if err := b.ledger.PostPendingWin(ctx, q, bet, entry.ID, winAmount); err != nil {
+					return err
+				}
+			} else if err := b.ledger.PostWin(ctx, q, bet, winAmount); err != nil {
+				return err
 			}
 		}
 
@@ -214,56 +225,29 @@ func (b *BetAggregate) PlaceBet(ctx context.Context, p PlaceBetParams) (sqlc.Rou
 			return err
 		}
 
-		if b.bus != nil {
-			eventType := "settlement.lost"
-			if status == "won" {
-				eventType = "settlement.won"
-			} else if status == "pending_settlement" {
-				eventType = "settlement.pending"
-			}
-
-			b.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: p.OperatorID,
-				EventType:  eventType,
-				Data: map[string]any{
-					"bet_id":    bet.ID,
-					"round_id":  round.ID,
-					"player_id": p.PlayerID,
-					"amount":    winAmount,
-					"status":    status,
-				},
-				CreatedAt: time.Now(),
-			})
+		eventType := "settlement.lost"
+		if status == "won" {
+			eventType = "settlement.won"
+		} else if status == "pending_settlement" {
+			eventType = "settlement.pending"
 		}
 
-		b.emitWebhookEvent(ctx, p.OperatorID, "bet_settled", map[string]any{
-			"bet_id":    bet.ID,
-			"round_id":  round.ID,
-			"player_id": p.PlayerID,
-			"amount":    winAmount,
-			"status":    status,
+		// The settlement event also becomes the bet_settled webhook.
+		return b.events.Record(ctx, q, p.OperatorID, eventType, BetSettled{
+			BetID:      bet.ID,
+			RoundID:    round.ID,
+			PlayerID:   p.PlayerID,
+			Amount:     winAmount,
+			Status:     status,
+			CreditTxID: creditTxID,
 		})
-
-		return nil
 	})
 
 	if err != nil {
 		return sqlc.Round{}, sqlc.Bet{}, err
 	}
 
-	return round, bet, nil
-}
-
-func (b *BetAggregate) emitWebhookEvent(ctx context.Context, operatorID int32, eventType string, payload interface{}) {
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		return
-	}
+	b.events.Notify()
 
-	_, _ = b.queries.InsertWebhookEvent(ctx, sqlc.InsertWebhookEventParams{
-		OperatorID: operatorID,
-		EventType:  eventType,
-		Payload:    raw,
-	})
+	return round, bet, nil
 }
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"rgs/memstore"
+	"rgs/sqlc"
+	"slices"
+	"testing"
+)
+
+func newTestAggregate(env *testEnv) *BetAggregate {
+	return NewBetAggregate(
+		env.store, memstore.Tx[BetRepo](env.store),
+		env.wallet, env.events,
+		NewComplianceService(env.store), env.ledger,
+	)
+}
+
+func ledgerBalance(env *testEnv, account string) float64 {
+	var total float64
+	for _, e := range env.store.LedgerEntries() {
+		if e.Account != account {
+			continue
+		}
+		if e.Direction == DirectionCredit {
+			total += e.Amount
+		} else {
+			total -= e.Amount
+		}
+	}
+	return total
+}
+
+func TestPlaceBetLost(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 3)
+
+	round, bet, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err != nil {
+		t.Fatalf("PlaceBet: %v", err)
+	}
+
+	if round.Outcome != 3 || bet.Status != "lost" || bet.WinAmount != 0 {
+		t.Fatalf("got outcome %d status %q win %v", round.Outcome, bet.Status, bet.WinAmount)
+	}
+	if got := env.wallet.Balance(player.ID); got != 90 {
+		t.Fatalf("wallet balance = %v, want 90", got)
+	}
+	if got := ledgerBalance(env, AccountHouse); got != 10 {
+		t.Fatalf("house balance = %v, want 10", got)
+	}
+
+	want := []string{"round.finished", "wallet.debit", "settlement.lost"}
+	if got := env.eventTypes(); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestPlaceBetWonCreditsInline(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 6)
+
+	_, bet, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err != nil {
+		t.Fatalf("PlaceBet: %v", err)
+	}
+
+	if bet.Status != "won" || bet.WinAmount != 50 {
+		t.Fatalf("got status %q win %v", bet.Status, bet.WinAmount)
+	}
+	if got := env.wallet.Balance(player.ID); got != 140 {
+		t.Fatalf("wallet balance = %v, want 140", got)
+	}
+
+	calls := env.wallet.Calls()
+	if last := calls[len(calls)-1]; last.Op != "credit" || last.RequestID != CreditTxID(bet.ID) {
+		t.Fatalf("last wallet call = %+v, want credit %s", last, CreditTxID(bet.ID))
+	}
+}
+
+func TestPlaceBetWonCreditFailureQueuesOutbox(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+	env.wallet.FailCredits(errors.New("wallet timeout"))
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 6)
+
+	_, bet, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err != nil {
+		t.Fatalf("PlaceBet: %v", err)
+	}
+	if bet.Status != "pending_settlement" {
+		t.Fatalf("status = %q, want pending_settlement", bet.Status)
+	}
+
+	entries, _ := env.store.ListOutboxByOperator(env.ctx, op.ID)
+	if len(entries) != 1 || entries[0].Amount != 50 || entries[0].CreditTxID != CreditTxID(bet.ID) {
+		t.Fatalf("outbox = %+v", entries)
+	}
+	if got := ledgerBalance(env, AccountSettlementPayable); got != 50 {
+		t.Fatalf("settlement_payable = %v, want 50", got)
+	}
+}
+
+func TestPlaceBetIdempotentReplay(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 2)
+
+	params := PlaceBetParams{OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1"}
+	_, first, err := agg.PlaceBet(env.ctx, params)
+	if err != nil {
+		t.Fatalf("first PlaceBet: %v", err)
+	}
+	round, second, err := agg.PlaceBet(env.ctx, params)
+	if err != nil {
+		t.Fatalf("replayed PlaceBet: %v", err)
+	}
+
+	if second.ID != first.ID || round.ID != first.RoundID {
+		t.Fatalf("replay returned bet %d round %d, want bet %d round %d", second.ID, round.ID, first.ID, first.RoundID)
+	}
+	if n := len(env.wallet.Calls()); n != 1 {
+		t.Fatalf("wallet calls = %d, want 1", n)
+	}
+	if n := len(env.store.Bets()); n != 1 {
+		t.Fatalf("bets = %d, want 1", n)
+	}
+}
+
+func TestPlaceBetDebitFailureRollsBack(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 5)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 6)
+
+	_, _, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err == nil {
+		t.Fatal("PlaceBet succeeded with insufficient funds")
+	}
+
+	if n := len(env.store.Bets()); n != 0 {
+		t.Fatalf("bets = %d, want 0 after rollback", n)
+	}
+	if n := len(env.store.Events()); n != 0 {
+		t.Fatalf("events = %d, want 0 after rollback", n)
+	}
+	if n := len(env.store.LedgerEntries()); n != 0 {
+		t.Fatalf("ledger entries = %d, want 0 after rollback", n)
+	}
+}
+
+func TestPlaceBetBlockedPlayer(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+	if _, err := env.store.SetPlayerBlocked(env.ctx, sqlc.SetPlayerBlockedParams{
+		ID: player.ID, OperatorID: op.ID, Blocked: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	agg := newTestAggregate(env)
+	_, _, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if !errors.Is(err, ErrPlayerBlocked) {
+		t.Fatalf("err = %v, want ErrPlayerBlocked", err)
+	}
+	if n := len(env.wallet.Calls()); n != 0 {
+		t.Fatalf("wallet calls = %d, want 0", n)
+	}
+}
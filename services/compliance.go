@@ -9,11 +9,11 @@ import (
 )
 
 type ComplianceService struct {
-	queries *sqlc.Queries
+	repo ComplianceRepo
 }
 
-func NewComplianceService(q *sqlc.Queries) *ComplianceService {
-	return &ComplianceService{queries: q}
+func NewComplianceService(repo ComplianceRepo) *ComplianceService {
+	return &ComplianceService{repo: repo}
 }
 
 func (s *ComplianceService) Log(
@@ -33,7 +33,7 @@ func (s *ComplianceService) Log(
 		pid = *playerID
 	}
 
-	_ = s.queries.InsertAuditLog(ctx, sqlc.InsertAuditLogParams{
+	_ = s.repo.InsertAuditLog(ctx, sqlc.InsertAuditLogParams{
 		OperatorID: operatorID,
 		PlayerID: sql.NullInt32{
 			Int32: pid,
@@ -49,7 +49,7 @@ func (s *ComplianceService) CheckJurisdiction(
 	operatorID int32,
 	jurisdiction string,
 ) error {
-	limits, err := s.queries.GetOperatorLimits(ctx, operatorID)
+	limits, err := s.repo.GetOperatorLimits(ctx, operatorID)
 	if err != nil {
 		return nil
 	}
@@ -68,7 +68,7 @@ func (s *ComplianceService) CheckJurisdiction(
 }
 
 func (s *ComplianceService) CheckMaxBet(ctx context.Context, operatorID int32, amount float64) error {
-	limits, err := s.queries.GetOperatorLimits(ctx, operatorID)
+	limits, err := s.repo.GetOperatorLimits(ctx, operatorID)
 	if err != nil {
 		return nil
 	}
@@ -84,6 +84,19 @@ func (s *ComplianceService) CheckMaxBet(ctx context.Context, operatorID int32, a
 	return nil
 }
 
+var ErrPlayerBlocked = errors.New("player is blocked")
+
+func (s *ComplianceService) CheckPlayer(ctx context.Context, player sqlc.Player) error {
+	if !player.Blocked {
+		return nil
+	}
+
+	s.Log(ctx, player.OperatorID, &player.ID, "compliance.player_blocked", map[string]any{
+		"external_player_id": player.ExternalPlayerID,
+	})
+	return ErrPlayerBlocked
+}
+
 func (s *ComplianceService) Check(
 	ctx context.Context,
 	operatorID int32,
@@ -115,14 +128,14 @@ func (s *ComplianceService) ListLogs(
 	limit, offset int32,
 ) ([]sqlc.AuditLog, error) {
 	if playerID == nil {
-		return s.queries.ListAuditLogsByOperator(ctx, sqlc.ListAuditLogsByOperatorParams{
+		return s.repo.ListAuditLogsByOperator(ctx, sqlc.ListAuditLogsByOperatorParams{
 			OperatorID: operatorID,
 			Limit:      limit,
 			Offset:     offset,
 		})
 	}
 
-	return s.queries.ListAuditLogsByOperatorPlayer(ctx, sqlc.ListAuditLogsByOperatorPlayerParams{
+	return s.repo.ListAuditLogsByOperatorPlayer(ctx, sqlc.ListAuditLogsByOperatorPlayerParams{
 		OperatorID: operatorID,
 		PlayerID: sql.NullInt32{
 			Int32: *playerID,
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"rgs/clock"
+	"rgs/observability"
+	"rgs/sqlc"
+	"rgs/webhooksdk"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EventSink receives events once they are committed and dispatched.
+// *EventBus is one.
+type EventSink interface {
+	Publish(evt SSEEvent)
+}
+
+// webhookTypes renames domain events for webhooks; settlements go out as
+// the bet_settled type operators integrated against. Other events keep
+// their own type and reach only endpoints subscribed to it.
+var webhookTypes = map[string]string{
+	"settlement.won":     webhooksdk.TypeBetSettled,
+	"settlement.lost":    webhooksdk.TypeBetSettled,
+	"settlement.pending": webhooksdk.TypeBetSettled,
+	"settlement.success": webhooksdk.TypeSettlementSuccess,
+}
+
+// eventLease bounds how long a claimed batch stays invisible to other
+// replicas.
+const eventLease = time.Minute
+
+// EventDispatcher is the transactional outbox for domain events. Services
+// Record events with the queries of their own transaction; after commit
+// the dispatcher enqueues the matching webhook and publishes to the sinks,
+// so a rolled-back change never emits anything.
+//
+// The sinks are in-process, so only the replica that claims an event
+// publishes it to its SSE streams.
+type EventDispatcher struct {
+	repo     EventRepo
+	tx       Tx[EventRepo]
+	clock    clock.Clock
+	sinks    []EventSink
+	id       string
+	interval time.Duration
+	hb       *Heartbeat
+	wake     chan struct{}
+}
+
+func NewEventDispatcher(
+	repo EventRepo,
+	tx Tx[EventRepo],
+	clk clock.Clock,
+	workerID string,
+	interval time.Duration,
+	hb *Heartbeat,
+	sinks ...EventSink,
+) *EventDispatcher {
+	return &EventDispatcher{
+		repo:     repo,
+		tx:       tx,
+		clock:    clk,
+		sinks:    sinks,
+		id:       workerID,
+		interval: interval,
+		hb:       hb,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Record stores an event. q must be the transaction-scoped repository of
+// the change the event describes.
+func (d *EventDispatcher) Record(
+	ctx context.Context,
+	q EventWriter,
+	operatorID int32,
+	eventType string,
+	data EventData,
+) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.InsertEvent(ctx, sqlc.InsertEventParams{
+		EventID:    uuid.New(),
+		OperatorID: operatorID,
+		EventType:  eventType,
+		Payload:    raw,
+		Version:    data.Version(),
+	})
+	return err
+}
+
+// Notify wakes the dispatcher after a commit so events go out without
+// waiting for the next poll.
+func (d *EventDispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches until ctx is cancelled, then makes a last pass so events
+// committed during shutdown are not left for the next start.
+func (d *EventDispatcher) Run(ctx context.Context) {
+	for {
+		d.dispatchPending()
+		d.hb.Beat()
+
+		select {
+		case <-ctx.Done():
+			d.dispatchPending()
+			return
+		case <-d.wake:
+		case <-d.clock.After(d.interval):
+		}
+	}
+}
+
+func (d *EventDispatcher) dispatchPending() {
+	ctx := context.Background()
+
+	now := d.clock.Now()
+	events, err := d.repo.ClaimUndispatchedEvents(ctx, sqlc.ClaimUndispatchedEventsParams{
+		WorkerID:    d.id,
+		LockedUntil: now.Add(eventLease),
+		Now:         now,
+	})
+	if err != nil {
+		observability.Logger.Error("failed to claim events", zap.Error(err))
+		return
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	for _, e := range events {
+		err := d.tx(ctx, func(q EventRepo) error {
+			if err := enqueueWebhooks(ctx, q, e, d.clock.Now()); err != nil {
+				return err
+			}
+
+			return leaseHeld(q.MarkEventDispatched(ctx, sqlc.MarkEventDispatchedParams{
+				ID:       e.ID,
+				WorkerID: d.id,
+			}))
+		})
+		if errors.Is(err, errLeaseLost) {
+			observability.Logger.Warn("event lease lost", zap.Int32("event_id", e.ID))
+			continue
+		}
+		if err != nil {
+			observability.Logger.Error("failed to dispatch event",
+				zap.Int32("event_id", e.ID),
+				zap.String("event_type", e.EventType),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		evt := SSEEvent{
+			ID:         e.EventID.String(),
+			OperatorID: e.OperatorID,
+			EventType:  e.EventType,
+			Data:       e.Payload,
+			CreatedAt:  e.CreatedAt,
+		}
+		for _, sink := range d.sinks {
+			sink.Publish(evt)
+		}
+	}
+}
+
+// enqueueWebhooks fans an event out into one delivery per enabled
+// endpoint subscribed to it, so each endpoint retries on its own schedule.
+// Every delivery carries the same envelope, built once here, so retries
+// and endpoints all see the same event id. The deliveries are due at now.
+func enqueueWebhooks(ctx context.Context, q EventRepo, e sqlc.Event, now time.Time) error {
+	webhookType, ok := webhookTypes[e.EventType]
+	if !ok {
+		webhookType = e.EventType
+	}
+
+	payload, err := json.Marshal(newWebhookEnvelope(e, webhookType))
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := q.ListEnabledWebhookEndpoints(ctx, e.OperatorID)
+	if err != nil {
+		return err
+	}
+
+	for _, ep := range endpoints {
+		if !Subscribes(ep.EventTypes, webhookType) {
+			continue
+		}
+
+		_, err := q.InsertWebhookEvent(ctx, sqlc.InsertWebhookEventParams{
+			OperatorID: e.OperatorID,
+			EventType:  webhookType,
+			Payload:    payload,
+			EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+			Now:        now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package services
+
+import "rgs/webhooksdk"
+
+// EventData is the payload of a recorded event. Version is its schema
+// version and travels in the webhook envelope; bump it when a field is
+// removed or changes meaning, never for an added field.
+type EventData interface {
+	Version() int32
+}
+
+// The payloads are part of the webhook contract, so they live in
+// webhooksdk where receivers decode them.
+type (
+	RoundFinished       = webhooksdk.RoundFinished       // round.finished
+	WalletDebited       = webhooksdk.WalletDebited       // wallet.debit
+	BetSettled          = webhooksdk.BetSettled          // settlement.won, .lost and .pending
+	SettlementSucceeded = webhooksdk.SettlementSucceeded // settlement.success
+	SettlementFailed    = webhooksdk.SettlementFailed    // settlement.failed
+	SettlementDead      = webhooksdk.SettlementDead      // settlement.dead
+	SessionLaunched     = webhooksdk.SessionLaunched     // session.launched
+	SessionVerified     = webhooksdk.SessionVerified     // session.verified
+	SessionRevoked      = webhooksdk.SessionRevoked      // session.revoked
+	Ping                = webhooksdk.Ping                // sent by POST /webhooks/test only
+)
@@ -20,6 +20,8 @@ type EventBus struct {
 	subscribers map[int32][]Subscriber
 	buffer      map[int32][]SSEEvent
 	bufferSize  int
+	done        chan struct{}
+	closeOnce   sync.Once
 }
 
 func NewEventBus(bufferSize int) *EventBus {
@@ -27,9 +29,19 @@ func NewEventBus(bufferSize int) *EventBus {
 		subscribers: make(map[int32][]Subscriber),
 		buffer:      make(map[int32][]SSEEvent),
 		bufferSize:  bufferSize,
+		done:        make(chan struct{}),
 	}
 }
 
+// Close tells open streams the server is going away.
+func (b *EventBus) Close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+func (b *EventBus) Done() <-chan struct{} {
+	return b.done
+}
+
 func (b *EventBus) Publish(evt SSEEvent) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
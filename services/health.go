@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// readinessTimeout bounds each dependency check.
+const readinessTimeout = 2 * time.Second
+
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+type Readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type MigrationStatus struct {
+	Version  int64 `json:"version"`
+	Expected int64 `json:"expected"`
+	Dirty    bool  `json:"dirty"`
+}
+
+type HealthService struct {
+	db            *sql.DB
+	wallet        *WalletClient
+	heartbeats    *Heartbeats
+	schemaVersion int64
+	shuttingDown  atomic.Bool
+}
+
+func NewHealthService(db *sql.DB, wallet *WalletClient, heartbeats *Heartbeats, schemaVersion int64) *HealthService {
+	return &HealthService{
+		db:            db,
+		wallet:        wallet,
+		heartbeats:    heartbeats,
+		schemaVersion: schemaVersion,
+	}
+}
+
+// MarkShuttingDown makes readiness fail so load balancers stop sending
+// traffic while the server drains.
+func (s *HealthService) MarkShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *HealthService) Ready(ctx context.Context) Readiness {
+	checks := map[string]func(context.Context) (any, error){
+		"database":   s.checkDatabase,
+		"wallet":     s.checkWallet,
+		"workers":    s.checkWorkers,
+		"migrations": s.checkMigrations,
+	}
+
+	res := Readiness{Status: CheckOK, Checks: make(map[string]CheckResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check(checkCtx)
+
+			r := CheckResult{Status: CheckOK, LatencyMs: time.Since(start).Milliseconds(), Details: details}
+			if err != nil {
+				r.Status = CheckFail
+				r.Error = err.Error()
+			}
+
+			mu.Lock()
+			res.Checks[name] = r
+			if err != nil {
+				res.Status = CheckFail
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if s.shuttingDown.Load() {
+		res.Status = CheckFail
+		res.Checks["shutdown"] = CheckResult{Status: CheckFail, Error: "server is shutting down"}
+	}
+
+	return res
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) (any, error) {
+	return nil, s.db.PingContext(ctx)
+}
+
+func (s *HealthService) checkWallet(ctx context.Context) (any, error) {
+	return nil, s.wallet.Ping(ctx)
+}
+
+func (s *HealthService) checkWorkers(context.Context) (any, error) {
+	beats := s.heartbeats.Snapshot()
+
+	var stale []string
+	for _, b := range beats {
+		if b.Stale {
+			stale = append(stale, b.Name)
+		}
+	}
+	if len(stale) > 0 {
+		return beats, fmt.Errorf("stale workers: %v", stale)
+	}
+
+	return beats, nil
+}
+
+// checkMigrations reads the schema_migrations bookkeeping table, which is not
+// part of the sqlc schema.
+func (s *HealthService) checkMigrations(ctx context.Context) (any, error) {
+	st := MigrationStatus{Expected: s.schemaVersion}
+
+	err := s.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").
+		Scan(&st.Version, &st.Dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, errors.New("no migrations applied")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if st.Dirty {
+		return st, fmt.Errorf("migration %d is dirty", st.Version)
+	}
+	if st.Version < st.Expected {
+		return st, fmt.Errorf("schema version %d is behind %d", st.Version, st.Expected)
+	}
+
+	return st, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"rgs/clock"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Heartbeats tracks when each worker last finished a loop so readiness can
+// tell a stuck worker from an idle one.
+type Heartbeats struct {
+	mu    sync.RWMutex
+	clock clock.Clock
+	beats map[string]*Heartbeat
+}
+
+type Heartbeat struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	last   time.Time
+	maxAge time.Duration
+}
+
+type HeartbeatStatus struct {
+	Name     string    `json:"name"`
+	LastBeat time.Time `json:"last_beat"`
+	MaxAge   string    `json:"max_age"`
+	Stale    bool      `json:"stale"`
+}
+
+func NewHeartbeats(clk clock.Clock) *Heartbeats {
+	return &Heartbeats{clock: clk, beats: make(map[string]*Heartbeat)}
+}
+
+// Register adds a worker that must beat at least every maxAge.
+func (h *Heartbeats) Register(name string, maxAge time.Duration) *Heartbeat {
+	hb := &Heartbeat{clock: h.clock, last: h.clock.Now(), maxAge: maxAge}
+
+	h.mu.Lock()
+	h.beats[name] = hb
+	h.mu.Unlock()
+
+	return hb
+}
+
+func (hb *Heartbeat) Beat() {
+	if hb == nil {
+		return
+	}
+
+	hb.mu.Lock()
+	hb.last = hb.clock.Now()
+	hb.mu.Unlock()
+}
+
+func (h *Heartbeats) Snapshot() []HeartbeatStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := h.clock.Now()
+	out := make([]HeartbeatStatus, 0, len(h.beats))
+	for name, hb := range h.beats {
+		hb.mu.Lock()
+		out = append(out, HeartbeatStatus{
+			Name:     name,
+			LastBeat: hb.last,
+			MaxAge:   hb.maxAge.String(),
+			Stale:    now.Sub(hb.last) > hb.maxAge,
+		})
+		hb.mu.Unlock()
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"rgs/clock"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatGoesStaleAfterMaxAge(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	hbs := NewHeartbeats(clk)
+	hb := hbs.Register("worker", time.Minute)
+
+	stale := func() bool { return hbs.Snapshot()[0].Stale }
+
+	clk.Advance(time.Minute)
+	if stale() {
+		t.Fatal("stale at max age")
+	}
+
+	clk.Advance(time.Second)
+	if !stale() {
+		t.Fatal("not stale past max age")
+	}
+
+	hb.Beat()
+	if stale() {
+		t.Fatal("stale right after a beat")
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"rgs/observability"
+	"rgs/sqlc"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// LeaderElector holds a session-level Postgres advisory lock on a pinned
+// connection. The replica holding the lock runs singleton jobs; when its
+// connection dies Postgres drops the lock and another replica takes over.
+type LeaderElector struct {
+	db       *sql.DB
+	name     string
+	key      int64
+	workerID string
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func NewLeaderElector(db *sql.DB, name, workerID string) *LeaderElector {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return &LeaderElector{
+		db:       db,
+		name:     name,
+		key:      int64(h.Sum64()),
+		workerID: workerID,
+	}
+}
+
+// IsLeader reports whether this replica holds the lock, trying to take it
+// when it does not.
+func (l *LeaderElector) IsLeader(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true
+		}
+
+		observability.Logger.Warn("lost leadership",
+			zap.String("lock", l.name),
+			zap.String("worker_id", l.workerID),
+		)
+		_ = l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		observability.Logger.Error("leader election failed", zap.String("lock", l.name), zap.Error(err))
+		return false
+	}
+
+	acquired, err := sqlc.New(conn).TryAdvisoryLock(ctx, l.key)
+	if err != nil || !acquired {
+		if err != nil {
+			observability.Logger.Error("leader election failed", zap.String("lock", l.name), zap.Error(err))
+		}
+		_ = conn.Close()
+		return false
+	}
+
+	l.conn = conn
+	observability.Logger.Info("acquired leadership",
+		zap.String("lock", l.name),
+		zap.String("worker_id", l.workerID),
+	)
+	return true
+}
+
+// Release gives up the lock so another replica can take over right away.
+func (l *LeaderElector) Release(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return
+	}
+
+	if _, err := sqlc.New(l.conn).AdvisoryUnlock(ctx, l.key); err != nil {
+		observability.Logger.Error("failed to release leadership", zap.String("lock", l.name), zap.Error(err))
+	}
+	_ = l.conn.Close()
+	l.conn = nil
+}
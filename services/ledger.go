@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"rgs/sqlc"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ledger entry types.
+const (
+	EntryStake       = "stake"
+	EntryWin         = "win"
+	EntryOutboxRetry = "outbox_retry"
+)
+
+// Ledger accounts. player_wallet tracks money moved to/from the player,
+// house is the operator's game result, settlement_payable holds wins the
+// wallet has not accepted yet.
+const (
+	AccountPlayerWallet      = "player_wallet"
+	AccountHouse             = "house"
+	AccountSettlementPayable = "settlement_payable"
+)
+
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+var ErrUnbalancedLedger = errors.New("ledger transaction is unbalanced")
+
+type LedgerPosting struct {
+	Account   string
+	Direction string
+	Amount    float64
+}
+
+type LedgerTransaction struct {
+	OperatorID int32
+	PlayerID   int32
+	BetID      int32
+	OutboxID   int32
+	EntryType  string
+	Postings   []LedgerPosting
+}
+
+type LedgerService struct {
+	queries *sqlc.Queries
+}
+
+func NewLedgerService(q *sqlc.Queries) *LedgerService {
+	return &LedgerService{queries: q}
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func nullInt32(v int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: v, Valid: v != 0}
+}
+
+// Post writes a balanced set of postings. q must be the transaction-scoped
+// queries of the state change the postings describe.
+func (s *LedgerService) Post(ctx context.Context, q LedgerWriter, t LedgerTransaction) (uuid.UUID, error) {
+	if len(t.Postings) < 2 {
+		return uuid.Nil, ErrUnbalancedLedger
+	}
+
+	var balance int64
+	for _, p := range t.Postings {
+		if p.Amount <= 0 {
+			return uuid.Nil, fmt.Errorf("ledger posting on %s must be positive", p.Account)
+		}
+		switch p.Direction {
+		case DirectionDebit:
+			balance += toCents(p.Amount)
+		case DirectionCredit:
+			balance -= toCents(p.Amount)
+		default:
+			return uuid.Nil, fmt.Errorf("unknown ledger direction %q", p.Direction)
+		}
+	}
+	if balance != 0 {
+		return uuid.Nil, ErrUnbalancedLedger
+	}
+
+	txID := uuid.New()
+	for _, p := range t.Postings {
+		_, err := q.InsertLedgerEntry(ctx, sqlc.InsertLedgerEntryParams{
+			TransactionID: txID,
+			OperatorID:    t.OperatorID,
+			PlayerID:      nullInt32(t.PlayerID),
+			BetID:         nullInt32(t.BetID),
+			OutboxID:      nullInt32(t.OutboxID),
+			EntryType:     t.EntryType,
+			Account:       p.Account,
+			Direction:     p.Direction,
+			Amount:        p.Amount,
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return txID, nil
+}
+
+func transfer(from, to string, amount float64) []LedgerPosting {
+	return []LedgerPosting{
+		{Account: from, Direction: DirectionDebit, Amount: amount},
+		{Account: to, Direction: DirectionCredit, Amount: amount},
+	}
+}
+
+func (s *LedgerService) PostStake(ctx context.Context, q LedgerWriter, bet sqlc.Bet) error {
+	_, err := s.Post(ctx, q, LedgerTransaction{
+		OperatorID: bet.OperatorID,
+		PlayerID:   bet.PlayerID,
+		BetID:      bet.ID,
+		EntryType:  EntryStake,
+		Postings:   transfer(AccountPlayerWallet, AccountHouse, bet.Amount),
+	})
+	return err
+}
+
+// PostWin books a win paid straight to the wallet.
+func (s *LedgerService) PostWin(ctx context.Context, q LedgerWriter, bet sqlc.Bet, amount float64) error {
+	_, err := s.Post(ctx, q, LedgerTransaction{
+		OperatorID: bet.OperatorID,
+		PlayerID:   bet.PlayerID,
+		BetID:      bet.ID,
+		EntryType:  EntryWin,
+		Postings:   transfer(AccountHouse, AccountPlayerWallet, amount),
+	})
+	return err
+}
+
+// PostPendingWin books a win the wallet did not accept yet; the amount is
+// held in settlement_payable until the outbox delivers it.
+func (s *LedgerService) PostPendingWin(ctx context.Context, q LedgerWriter, bet sqlc.Bet, outboxID int32, amount float64) error {
+	_, err := s.Post(ctx, q, LedgerTransaction{
+		OperatorID: bet.OperatorID,
+		PlayerID:   bet.PlayerID,
+		BetID:      bet.ID,
+		OutboxID:   outboxID,
+		EntryType:  EntryWin,
+		Postings:   transfer(AccountHouse, AccountSettlementPayable, amount),
+	})
+	return err
+}
+
+func (s *LedgerService) PostOutboxRetry(ctx context.Context, q LedgerWriter, e sqlc.Outbox) error {
+	_, err := s.Post(ctx, q, LedgerTransaction{
+		OperatorID: e.OperatorID,
+		PlayerID:   e.PlayerID,
+		BetID:      e.BetID,
+		OutboxID:   e.ID,
+		EntryType:  EntryOutboxRetry,
+		Postings:   transfer(AccountSettlementPayable, AccountPlayerWallet, e.Amount),
+	})
+	return err
+}
+
+type BalancePoint struct {
+	Day     time.Time `json:"day"`
+	Debits  float64   `json:"debits"`
+	Credits float64   `json:"credits"`
+	Balance float64   `json:"balance"`
+}
+
+type PlayerBalance struct {
+	PlayerID int32          `json:"player_id"`
+	Opening  float64        `json:"opening"`
+	Closing  float64        `json:"closing"`
+	History  []BalancePoint `json:"history"`
+}
+
+func (s *LedgerService) PlayerBalance(
+	ctx context.Context,
+	operatorID int32,
+	playerID int32,
+	from, to time.Time,
+) (PlayerBalance, error) {
+	opening, err := s.queries.GetPlayerLedgerBalance(ctx, sqlc.GetPlayerLedgerBalanceParams{
+		OperatorID: operatorID,
+		PlayerID:   nullInt32(playerID),
+		AsOf:       from,
+	})
+	if err != nil {
+		return PlayerBalance{}, err
+	}
+
+	rows, err := s.queries.ListPlayerLedgerHistory(ctx, sqlc.ListPlayerLedgerHistoryParams{
+		OperatorID: operatorID,
+		PlayerID:   nullInt32(playerID),
+		FromTime:   from,
+		ToTime:     to,
+	})
+	if err != nil {
+		return PlayerBalance{}, err
+	}
+
+	res := PlayerBalance{PlayerID: playerID, Opening: opening, History: []BalancePoint{}}
+	running := opening
+	for _, r := range rows {
+		running += r.Credits - r.Debits
+		res.History = append(res.History, BalancePoint{
+			Day:     r.Bucket,
+			Debits:  r.Debits,
+			Credits: r.Credits,
+			Balance: running,
+		})
+	}
+	res.Closing = running
+
+	return res, nil
+}
+
+type AccountBalance struct {
+	Account string         `json:"account"`
+	Opening float64        `json:"opening"`
+	Closing float64        `json:"closing"`
+	History []BalancePoint `json:"history"`
+}
+
+func (s *LedgerService) OperatorBalances(
+	ctx context.Context,
+	operatorID int32,
+	from, to time.Time,
+) ([]AccountBalance, error) {
+	openings, err := s.queries.ListOperatorLedgerBalances(ctx, sqlc.ListOperatorLedgerBalancesParams{
+		OperatorID: operatorID,
+		AsOf:       from,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListOperatorLedgerHistory(ctx, sqlc.ListOperatorLedgerHistoryParams{
+		OperatorID: operatorID,
+		FromTime:   from,
+		ToTime:     to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := map[string]*AccountBalance{}
+	var order []string
+	get := func(account string) *AccountBalance {
+		a, ok := accounts[account]
+		if !ok {
+			a = &AccountBalance{Account: account, History: []BalancePoint{}}
+			accounts[account] = a
+			order = append(order, account)
+		}
+		return a
+	}
+
+	for _, o := range openings {
+		a := get(o.Account)
+		a.Opening = o.Balance
+		a.Closing = o.Balance
+	}
+
+	for _, r := range rows {
+		a := get(r.Account)
+		a.Closing += r.Credits - r.Debits
+		a.History = append(a.History, BalancePoint{
+			Day:     r.Bucket,
+			Debits:  r.Debits,
+			Credits: r.Credits,
+			Balance: a.Closing,
+		})
+	}
+
+	res := make([]AccountBalance, 0, len(order))
+	for _, account := range order {
+		res = append(res, *accounts[account])
+	}
+
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"rgs/clock"
+	"rgs/fakes"
+	"rgs/game"
+	"rgs/memstore"
+	"rgs/observability"
+	"rgs/sqlc"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	_ ComplianceRepo      = (*memstore.Store)(nil)
+	_ SessionRepo         = (*memstore.Store)(nil)
+	_ BetRepo             = (*memstore.Store)(nil)
+	_ OutboxRepo          = (*memstore.Store)(nil)
+	_ WebhookRepo         = (*memstore.Store)(nil)
+	_ EventRepo           = (*memstore.Store)(nil)
+	_ WebhookEndpointRepo = (*memstore.Store)(nil)
+)
+
+func TestMain(m *testing.M) {
+	observability.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// testEnv wires services against memstore, a fake wallet and a fake clock.
+type testEnv struct {
+	ctx    context.Context
+	clock  *clock.Fake
+	store  *memstore.Store
+	wallet *fakes.Wallet
+	events *EventDispatcher
+	ledger *LedgerService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	clk := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := memstore.New(clk)
+
+	return &testEnv{
+		ctx:    context.Background(),
+		clock:  clk,
+		store:  store,
+		wallet: fakes.NewWallet(),
+		events: NewEventDispatcher(store, memstore.Tx[EventRepo](store), clk, "test", time.Second, nil),
+		ledger: NewLedgerService(nil),
+	}
+}
+
+func (e *testEnv) operator(t *testing.T, webhookURL string) sqlc.Operator {
+	t.Helper()
+
+	op, err := e.store.CreateOperator(e.ctx, sqlc.CreateOperatorParams{
+		Name:          "op",
+		ApiKey:        fmt.Sprintf("key-%d", e.clock.Now().UnixNano()),
+		WebhookUrl:    webhookURL,
+		WebhookSecret: "whsec",
+	})
+	if err != nil {
+		t.Fatalf("create operator: %v", err)
+	}
+	return op
+}
+
+func (e *testEnv) player(t *testing.T, operatorID int32, balance float64) sqlc.Player {
+	t.Helper()
+
+	p, err := e.store.CreatePlayer(e.ctx, sqlc.CreatePlayerParams{
+		OperatorID:       operatorID,
+		ExternalPlayerID: fmt.Sprintf("ext-%d", operatorID),
+		Jurisdiction:     "MT",
+	})
+	if err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+	e.wallet.SetBalance(p.ID, balance)
+	return p
+}
+
+func (e *testEnv) eventTypes() []string {
+	var out []string
+	for _, evt := range e.store.Events() {
+		out = append(out, evt.EventType)
+	}
+	return out
+}
+
+// seedsFor returns a seed generator whose first two seeds produce outcome.
+func seedsFor(t *testing.T, outcome int32) func() (string, error) {
+	t.Helper()
+
+	for i := 0; i < 1000; i++ {
+		server, client := fmt.Sprintf("server-%d", i), fmt.Sprintf("client-%d", i)
+		if game.GenerateOutcome(server, client).Outcome != outcome {
+			continue
+		}
+
+		seeds := []string{server, client}
+		return func() (string, error) {
+			s := seeds[0]
+			seeds = append(seeds[1:], s)
+			return s, nil
+		}
+	}
+
+	t.Fatalf("no seeds found for outcome %d", outcome)
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"rgs/sqlc"
+)
+
+// OperatorService holds the admin operations behind the operator and
+// player CLI subcommands.
+type OperatorService struct {
+	queries    *sqlc.Queries
+	tx         Tx[OperatorRepo]
+	compliance *ComplianceService
+}
+
+func NewOperatorService(q *sqlc.Queries, tx Tx[OperatorRepo], comp *ComplianceService) *OperatorService {
+	return &OperatorService{queries: q, tx: tx, compliance: comp}
+}
+
+// Create registers an operator with a freshly generated API key and
+// webhook secret.
+func (s *OperatorService) Create(ctx context.Context, name, webhookURL string) (sqlc.Operator, error) {
+	apiKey, err := generateSecureToken()
+	if err != nil {
+		return sqlc.Operator{}, err
+	}
+
+	secret, err := generateSecureToken()
+	if err != nil {
+		return sqlc.Operator{}, err
+	}
+
+	var op sqlc.Operator
+	err = s.tx(ctx, func(q OperatorRepo) error {
+		op, err = q.CreateOperator(ctx, sqlc.CreateOperatorParams{
+			Name:          name,
+			ApiKey:        apiKey,
+			WebhookUrl:    webhookURL,
+			WebhookSecret: secret,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The operator's first endpoint shares its secret and receives
+		// what webhook_url always did.
+		if webhookURL == "" {
+			return nil
+		}
+		_, err = q.CreateWebhookEndpoint(ctx, sqlc.CreateWebhookEndpointParams{
+			OperatorID: op.ID,
+			Url:        webhookURL,
+			Secret:     secret,
+			EventTypes: DefaultEndpointEventTypes,
+			Enabled:    true,
+		})
+		return err
+	})
+	if err != nil {
+		return sqlc.Operator{}, err
+	}
+
+	s.compliance.Log(ctx, op.ID, nil, "operator.created", map[string]any{
+		"name": name,
+	})
+
+	return op, nil
+}
+
+// RotateKey replaces the operator's API key; the old one stops working
+// immediately.
+func (s *OperatorService) RotateKey(ctx context.Context, operatorID int32) (sqlc.Operator, error) {
+	apiKey, err := generateSecureToken()
+	if err != nil {
+		return sqlc.Operator{}, err
+	}
+
+	op, err := s.queries.RotateOperatorApiKey(ctx, sqlc.RotateOperatorApiKeyParams{
+		ID:     operatorID,
+		ApiKey: apiKey,
+	})
+	if err != nil {
+		return sqlc.Operator{}, err
+	}
+
+	s.compliance.Log(ctx, op.ID, nil, "operator.api_key_rotated", map[string]any{})
+
+	return op, nil
+}
+
+func (s *OperatorService) SetLimits(ctx context.Context, limits sqlc.UpsertOperatorLimitsParams) (sqlc.OperatorLimit, error) {
+	if limits.AllowedJurisdictions == nil {
+		limits.AllowedJurisdictions = []string{}
+	}
+
+	out, err := s.queries.UpsertOperatorLimits(ctx, limits)
+	if err != nil {
+		return sqlc.OperatorLimit{}, err
+	}
+
+	s.compliance.Log(ctx, out.OperatorID, nil, "operator.limits_updated", out)
+
+	return out, nil
+}
+
+func (s *OperatorService) SetPlayerBlocked(
+	ctx context.Context,
+	operatorID int32,
+	playerID int32,
+	blocked bool,
+) (sqlc.Player, error) {
+	player, err := s.queries.SetPlayerBlocked(ctx, sqlc.SetPlayerBlockedParams{
+		ID:         playerID,
+		OperatorID: operatorID,
+		Blocked:    blocked,
+	})
+	if err != nil {
+		return sqlc.Player{}, err
+	}
+
+	action := "player.blocked"
+	if !blocked {
+		action = "player.unblocked"
+	}
+	s.compliance.Log(ctx, operatorID, &player.ID, action, map[string]any{})
+
+	return player, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rgs/memstore"
+	"rgs/sqlc"
+	"testing"
+)
+
+// failingEndpoints rejects every endpoint, as a constraint violation
+// would.
+type failingEndpoints struct {
+	OperatorRepo
+}
+
+func (failingEndpoints) CreateWebhookEndpoint(context.Context, sqlc.CreateWebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	return sqlc.WebhookEndpoint{}, errors.New("endpoint rejected")
+}
+
+func TestCreateOperatorRollsBackWhenEndpointFails(t *testing.T) {
+	env := newTestEnv(t)
+	storeTx := memstore.Tx[OperatorRepo](env.store)
+	tx := func(ctx context.Context, fn func(OperatorRepo) error) error {
+		return storeTx(ctx, func(q OperatorRepo) error {
+			return fn(failingEndpoints{q})
+		})
+	}
+	svc := NewOperatorService(nil, tx, NewComplianceService(env.store))
+
+	if _, err := svc.Create(env.ctx, "op", "https://example.test/hook"); err == nil {
+		t.Fatal("Create succeeded without its endpoint")
+	}
+	if _, err := env.store.GetOperatorByID(env.ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("operator left behind: err = %v", err)
+	}
+}
@@ -2,15 +2,21 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"rgs/clock"
 	"rgs/sqlc"
 )
 
+var ErrOutboxNotDead = errors.New("outbox entry is not dead")
+
 type OutboxService struct {
-	queries *sqlc.Queries
+	repo  OutboxRepo
+	clock clock.Clock
 }
 
-func NewOutboxService(q *sqlc.Queries) *OutboxService {
-	return &OutboxService{queries: q}
+func NewOutboxService(repo OutboxRepo, clk clock.Clock) *OutboxService {
+	return &OutboxService{repo: repo, clock: clk}
 }
 
 func (s *OutboxService) ListOutbox(
@@ -19,27 +25,67 @@ func (s *OutboxService) ListOutbox(
 	status *string,
 ) ([]sqlc.Outbox, error) {
 	if status == nil {
-		return s.queries.ListOutboxByOperator(ctx, operatorID)
+		return s.repo.ListOutboxByOperator(ctx, operatorID)
 	}
 
-	var processedBool bool
+	var outboxStatus sqlc.OutboxStatus
 
 	switch *status {
-	case "processed", "completed":
-		processedBool = true
+	case "processed", "completed", "succeeded":
+		outboxStatus = sqlc.OutboxStatusSucceeded
+
+	case "pending":
+		outboxStatus = sqlc.OutboxStatusPending
 
-	case "pending", "retrying":
-		processedBool = false
+	case "retrying":
+		outboxStatus = sqlc.OutboxStatusRetrying
+
+	case "dead":
+		outboxStatus = sqlc.OutboxStatusDead
 
 	default:
-		return s.queries.ListOutboxByOperator(ctx, operatorID)
+		return s.repo.ListOutboxByOperator(ctx, operatorID)
 	}
 
-	return s.queries.ListOutboxByOperatorStatus(
+	return s.repo.ListOutboxByOperatorStatus(
 		ctx,
 		sqlc.ListOutboxByOperatorStatusParams{
 			OperatorID: operatorID,
-			Processed:  processedBool,
+			Status:     outboxStatus,
 		},
 	)
 }
+
+func (s *OutboxService) GetOutbox(ctx context.Context, operatorID, id int32) (sqlc.Outbox, error) {
+	return s.repo.GetOutboxByOperator(ctx, sqlc.GetOutboxByOperatorParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+}
+
+// Redrive puts a dead entry back in the queue with a fresh attempt budget.
+func (s *OutboxService) Redrive(ctx context.Context, operatorID, id int32) (sqlc.Outbox, error) {
+	e, err := s.repo.RedriveOutbox(ctx, sqlc.RedriveOutboxParams{
+		ID:         id,
+		OperatorID: operatorID,
+		Now:        s.clock.Now(),
+	})
+	if err == nil {
+		return e, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sqlc.Outbox{}, err
+	}
+
+	if _, err := s.GetOutbox(ctx, operatorID, id); err != nil {
+		return sqlc.Outbox{}, err
+	}
+	return sqlc.Outbox{}, ErrOutboxNotDead
+}
+
+func (s *OutboxService) RedriveAll(ctx context.Context, operatorID int32) ([]sqlc.Outbox, error) {
+	return s.repo.RedriveDeadOutboxByOperator(ctx, sqlc.RedriveDeadOutboxByOperatorParams{
+		OperatorID: operatorID,
+		Now:        s.clock.Now(),
+	})
+}
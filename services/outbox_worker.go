@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"errors"
+	"rgs/clock"
 	"rgs/observability"
 	"rgs/sqlc"
 	"time"
@@ -12,114 +14,237 @@ import (
 )
 
 type OutboxWorker struct {
-	queries *sqlc.Queries
-	wallet  *WalletClient
-	bus     *EventBus
+	repo     OutboxRepo
+	tx       Tx[OutboxRepo]
+	clock    clock.Clock
+	wallet   Wallet
+	bus      *EventBus
+	events   *EventDispatcher
+	ledger   *LedgerService
+	policy   RetryPolicy
+	id       string
+	interval time.Duration
+	hb       *Heartbeat
 }
 
-func NewOutboxWorker(q *sqlc.Queries, wallet *WalletClient, bus *EventBus) *OutboxWorker {
-	return &OutboxWorker{queries: q, wallet: wallet, bus: bus}
+// outboxLease bounds how long a claimed batch stays invisible to other
+// replicas; it must outlast a batch of wallet calls.
+const outboxLease = time.Minute
+
+func NewOutboxWorker(
+	repo OutboxRepo,
+	tx Tx[OutboxRepo],
+	clk clock.Clock,
+	wallet Wallet,
+	bus *EventBus,
+	events *EventDispatcher,
+	ledger *LedgerService,
+	policy RetryPolicy,
+	workerID string,
+	interval time.Duration,
+	hb *Heartbeat,
+) *OutboxWorker {
+	return &OutboxWorker{
+		repo:     repo,
+		tx:       tx,
+		clock:    clk,
+		wallet:   wallet,
+		bus:      bus,
+		events:   events,
+		ledger:   ledger,
+		policy:   policy,
+		id:       workerID,
+		interval: interval,
+		hb:       hb,
+	}
 }
 
-func (w *OutboxWorker) Start() {
-	go func() {
-		for {
-			w.processPending()
-			time.Sleep(3 * time.Second)
+// Run processes due entries every interval until ctx is cancelled. An
+// entry already sent to the wallet is finished before Run returns.
+func (w *OutboxWorker) Run(ctx context.Context) {
+	for {
+		w.processPending(ctx)
+		w.hb.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
 		}
-	}()
+	}
 }
 
-func (w *OutboxWorker) processPending() {
-	ctx := context.Background()
+func (w *OutboxWorker) processPending(runCtx context.Context) {
+	ctx := context.WithoutCancel(runCtx)
 
-	events, err := w.queries.GetPendingOutbox(ctx)
+	now := w.clock.Now()
+	events, err := w.repo.ClaimPendingOutbox(ctx, sqlc.ClaimPendingOutboxParams{
+		WorkerID:    w.id,
+		LockedUntil: now.Add(outboxLease),
+		Now:         now,
+	})
 	if err != nil {
 		observability.Logger.Error("failed to fetch outbox", zap.Error(err))
 		return
 	}
 
 	for _, e := range events {
-		creditKey := fmt.Sprintf("bet-%d-retry-%d", e.BetID, e.ID)
+		if runCtx.Err() != nil {
+			return
+		}
+
 		if w.bus != nil {
 			w.bus.Publish(SSEEvent{
 				ID:         uuid.NewString(),
 				OperatorID: e.OperatorID,
 				EventType:  "settlement.retry",
 				Data: map[string]any{
-					"bet_id":    e.BetID,
-					"player_id": e.PlayerID,
-					"amount":    e.Amount,
-					"outbox_id": e.ID,
-					"retry_key": creditKey,
+					"bet_id":       e.BetID,
+					"player_id":    e.PlayerID,
+					"amount":       e.Amount,
+					"outbox_id":    e.ID,
+					"credit_tx_id": e.CreditTxID,
 				},
-				CreatedAt: time.Now(),
+				CreatedAt: w.clock.Now(),
 			})
 		}
 
-		ok, errCredit := w.wallet.Credit(ctx, e.PlayerID, e.Amount, creditKey)
+		ok, errCredit := w.wallet.Credit(ctx, e.PlayerID, e.Amount, e.CreditTxID)
 		if errCredit != nil || !ok {
-			observability.Logger.Error("retry credit failed", zap.Error(err))
-
 			errorMsg := "wallet declined"
 			if errCredit != nil {
 				errorMsg = errCredit.Error()
 			}
 
-			if w.bus != nil {
-				w.bus.Publish(SSEEvent{
-					ID:         uuid.NewString(),
-					OperatorID: e.OperatorID,
-					EventType:  "settlement.failed",
-					Data: map[string]any{
-						"bet_id":    e.BetID,
-						"player_id": e.PlayerID,
-						"amount":    e.Amount,
-						"error":     errorMsg,
-						"outbox_id": e.ID,
-					},
-					CreatedAt: time.Now(),
-				})
+			w.handleFailure(ctx, e, errorMsg)
+			continue
+		}
+
+		err = w.tx(ctx, func(q OutboxRepo) error {
+			// Checked first, so a worker whose lease expired mid-credit
+			// cannot settle the bet a second time.
+			err := leaseHeld(q.MarkOutboxSucceeded(ctx, sqlc.MarkOutboxSucceededParams{
+				ID:       e.ID,
+				WorkerID: w.id,
+			}))
+			if err != nil {
+				return err
 			}
 
+			bet, err := q.MarkBetAsWon(ctx, e.BetID)
+			if err != nil {
+				return err
+			}
+
+			if err := w.ledger.PostOutboxRetry(ctx, q, e); err != nil {
+				return err
+			}
+
+			// Also delivered as the settlement_success webhook.
+			return w.events.Record(ctx, q, e.OperatorID, "settlement.success", SettlementSucceeded{
+				BetID:      e.BetID,
+				RoundID:    bet.RoundID,
+				PlayerID:   e.PlayerID,
+				Amount:     e.Amount,
+				Status:     bet.Status,
+				OutboxID:   e.ID,
+				CreditTxID: e.CreditTxID,
+			})
+		})
+		if errors.Is(err, errLeaseLost) {
+			observability.Logger.Warn("outbox lease lost", zap.Int32("outbox_id", e.ID))
 			continue
 		}
-
-		err = w.queries.MarkBetAsWon(ctx, e.BetID)
 		if err != nil {
-			observability.Logger.Error("failed to mark bet as won", zap.Error(err))
+			observability.Logger.Error("failed to settle outbox entry", zap.Int32("outbox_id", e.ID), zap.Error(err))
 			continue
 		}
-		if w.bus != nil {
-			w.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: e.OperatorID,
-				EventType:  "settlement.success",
-				Data: map[string]any{
-					"bet_id":    e.BetID,
-					"player_id": e.PlayerID,
-					"amount":    e.Amount,
-					"outbox_id": e.ID,
-				},
-				CreatedAt: time.Now(),
+		w.events.Notify()
+	}
+}
+
+// handleFailure schedules the next attempt with exponential backoff, or
+// moves the entry to dead-letter once the policy is exhausted.
+func (w *OutboxWorker) handleFailure(ctx context.Context, e sqlc.Outbox, errorMsg string) {
+	attempts := e.Attempts + 1
+	lastError := sql.NullString{String: errorMsg, Valid: true}
+
+	if w.policy.Exhausted(attempts) {
+		err := w.tx(ctx, func(q OutboxRepo) error {
+			err := leaseHeld(q.MarkOutboxDead(ctx, sqlc.MarkOutboxDeadParams{
+				ID:        e.ID,
+				LastError: lastError,
+				WorkerID:  w.id,
+			}))
+			if err != nil {
+				return err
+			}
+
+			return w.events.Record(ctx, q, e.OperatorID, "settlement.dead", SettlementDead{
+				BetID:      e.BetID,
+				PlayerID:   e.PlayerID,
+				Amount:     e.Amount,
+				Error:      errorMsg,
+				OutboxID:   e.ID,
+				Attempts:   attempts,
+				CreditTxID: e.CreditTxID,
 			})
+		})
+		if errors.Is(err, errLeaseLost) {
+			observability.Logger.Warn("outbox lease lost", zap.Int32("outbox_id", e.ID))
+			return
+		}
+		if err != nil {
+			observability.Logger.Error("failed to move outbox entry to dead-letter", zap.Error(err))
+			return
 		}
 
-		_, _ = w.queries.InsertWebhookEvent(ctx, sqlc.InsertWebhookEventParams{
-			OperatorID: e.OperatorID,
-			EventType:  "settlement_success",
-			Payload: []byte(fmt.Sprintf(`{
-						"bet_id": %d,
-						"round_id": 0,
-						"amount": %.2f,
-						"status": "won",
-						"player_id": %d
-					}`, e.BetID, e.Amount, e.PlayerID)),
-		})
+		observability.Logger.Error("outbox entry moved to dead-letter",
+			zap.Int32("outbox_id", e.ID),
+			zap.Int32("bet_id", e.BetID),
+			zap.Int32("attempts", attempts),
+			zap.String("error", errorMsg),
+		)
+		w.events.Notify()
+		return
+	}
 
-		_, err = w.queries.MarkOutboxProcessed(ctx, e.ID)
+	delay := w.policy.Backoff(attempts)
+	err := w.tx(ctx, func(q OutboxRepo) error {
+		err := leaseHeld(q.MarkOutboxRetry(ctx, sqlc.MarkOutboxRetryParams{
+			ID:            e.ID,
+			NextAttemptAt: w.clock.Now().Add(delay),
+			LastError:     lastError,
+			WorkerID:      w.id,
+		}))
 		if err != nil {
-			observability.Logger.Error("failed to mark outbox processed", zap.Error(err))
+			return err
 		}
+
+		return w.events.Record(ctx, q, e.OperatorID, "settlement.failed", SettlementFailed{
+			BetID:    e.BetID,
+			PlayerID: e.PlayerID,
+			Amount:   e.Amount,
+			Error:    errorMsg,
+			OutboxID: e.ID,
+			Attempts: attempts,
+			RetryIn:  delay.Seconds(),
+		})
+	})
+	if errors.Is(err, errLeaseLost) {
+		observability.Logger.Warn("outbox lease lost", zap.Int32("outbox_id", e.ID))
+		return
 	}
+	if err != nil {
+		observability.Logger.Error("failed to schedule outbox retry", zap.Error(err))
+		return
+	}
+
+	observability.Logger.Warn("retry credit failed",
+		zap.Int32("outbox_id", e.ID),
+		zap.Int32("attempts", attempts),
+		zap.Duration("retry_in", delay),
+		zap.String("error", errorMsg),
+	)
+	w.events.Notify()
 }
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"rgs/memstore"
+	"rgs/sqlc"
+	"testing"
+	"time"
+)
+
+func newTestOutboxWorker(env *testEnv, policy RetryPolicy) *OutboxWorker {
+	return NewOutboxWorker(
+		env.store, memstore.Tx[OutboxRepo](env.store), env.clock,
+		env.wallet, nil, env.events, env.ledger,
+		policy, "test", time.Second, nil,
+	)
+}
+
+// pendingWin places a winning bet whose inline credit fails, leaving one
+// outbox entry.
+func pendingWin(t *testing.T, env *testEnv) (sqlc.Bet, sqlc.Outbox) {
+	t.Helper()
+
+	op := env.operator(t, "")
+	player := env.player(t, op.ID, 100)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 6)
+
+	env.wallet.FailCredits(errors.New("wallet timeout"))
+	_, bet, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: op.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err != nil {
+		t.Fatalf("PlaceBet: %v", err)
+	}
+
+	entries, _ := env.store.ListOutboxByOperator(env.ctx, op.ID)
+	if len(entries) != 1 {
+		t.Fatalf("outbox entries = %d, want 1", len(entries))
+	}
+	return bet, entries[0]
+}
+
+func getOutbox(t *testing.T, env *testEnv, e sqlc.Outbox) sqlc.Outbox {
+	t.Helper()
+
+	got, err := env.store.GetOutboxByOperator(env.ctx, sqlc.GetOutboxByOperatorParams{
+		ID: e.ID, OperatorID: e.OperatorID,
+	})
+	if err != nil {
+		t.Fatalf("get outbox: %v", err)
+	}
+	return got
+}
+
+func TestOutboxWorkerRetriesWithBackoffThenSucceeds(t *testing.T) {
+	env := newTestEnv(t)
+	bet, entry := pendingWin(t, env)
+
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+	w := newTestOutboxWorker(env, policy)
+
+	w.processPending(context.Background())
+
+	got := getOutbox(t, env, entry)
+	if got.Status != sqlc.OutboxStatusRetrying || got.Attempts != 1 {
+		t.Fatalf("after failure: status %q attempts %d", got.Status, got.Attempts)
+	}
+	if want := env.clock.Now().Add(policy.Backoff(1)); !got.NextAttemptAt.Equal(want) {
+		t.Fatalf("next attempt = %v, want %v", got.NextAttemptAt, want)
+	}
+
+	// Not due yet: nothing is claimed.
+	env.wallet.FailCredits(nil)
+	w.processPending(context.Background())
+	if got := getOutbox(t, env, entry); got.Status != sqlc.OutboxStatusRetrying {
+		t.Fatalf("entry processed before it was due: %q", got.Status)
+	}
+
+	env.clock.Advance(policy.Backoff(1))
+	w.processPending(context.Background())
+
+	got = getOutbox(t, env, entry)
+	if got.Status != sqlc.OutboxStatusSucceeded || got.Attempts != 2 {
+		t.Fatalf("after success: status %q attempts %d", got.Status, got.Attempts)
+	}
+	if got := env.wallet.Balance(bet.PlayerID); got != 140 {
+		t.Fatalf("wallet balance = %v, want 140", got)
+	}
+	if got := ledgerBalance(env, AccountSettlementPayable); got != 0 {
+		t.Fatalf("settlement_payable = %v, want 0", got)
+	}
+
+	for _, b := range env.store.Bets() {
+		if b.ID == bet.ID && b.Status != "won" {
+			t.Fatalf("bet status = %q, want won", b.Status)
+		}
+	}
+}
+
+func TestOutboxWorkerDeadLettersWhenExhausted(t *testing.T) {
+	env := newTestEnv(t)
+	_, entry := pendingWin(t, env)
+
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Second}
+	w := newTestOutboxWorker(env, policy)
+
+	w.processPending(context.Background())
+	env.clock.Advance(time.Second)
+	w.processPending(context.Background())
+
+	got := getOutbox(t, env, entry)
+	if got.Status != sqlc.OutboxStatusDead || got.Attempts != 2 {
+		t.Fatalf("status %q attempts %d, want dead after 2", got.Status, got.Attempts)
+	}
+	if !got.LastError.Valid || got.LastError.String != "wallet timeout" {
+		t.Fatalf("last error = %+v", got.LastError)
+	}
+
+	types := env.eventTypes()
+	if types[len(types)-1] != "settlement.dead" {
+		t.Fatalf("last event = %q, want settlement.dead", types[len(types)-1])
+	}
+}
+
+func TestRedrivenOutboxIsDueAtTheClock(t *testing.T) {
+	env := newTestEnv(t)
+	_, entry := pendingWin(t, env)
+
+	w := newTestOutboxWorker(env, RetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second})
+	w.processPending(context.Background())
+	if got := getOutbox(t, env, entry); got.Status != sqlc.OutboxStatusDead {
+		t.Fatalf("status %q, want dead", got.Status)
+	}
+
+	env.clock.Advance(time.Hour)
+	got, err := NewOutboxService(env.store, env.clock).Redrive(env.ctx, entry.OperatorID, entry.ID)
+	if err != nil {
+		t.Fatalf("redrive: %v", err)
+	}
+	if got.Status != sqlc.OutboxStatusPending || got.Attempts != 0 || !got.NextAttemptAt.Equal(env.clock.Now()) {
+		t.Fatalf("status %q attempts %d next attempt %v, want pending now", got.Status, got.Attempts, got.NextAttemptAt)
+	}
+
+	env.wallet.FailCredits(nil)
+	w.processPending(context.Background())
+	if got := getOutbox(t, env, entry); got.Status != sqlc.OutboxStatusSucceeded {
+		t.Fatalf("redriven entry is %q, want succeeded", got.Status)
+	}
+}
+
+// stallingWallet runs stall inside the first credit, standing in for a
+// wallet call that outlives the worker's lease.
+type stallingWallet struct {
+	Wallet
+	stall func()
+}
+
+func (w *stallingWallet) Credit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error) {
+	if stall := w.stall; stall != nil {
+		w.stall = nil
+		stall()
+	}
+	return w.Wallet.Credit(ctx, playerID, amount, requestID)
+}
+
+func TestOutboxWorkerDoesNotSettleAfterLosingItsLease(t *testing.T) {
+	env := newTestEnv(t)
+	bet, entry := pendingWin(t, env)
+	env.wallet.FailCredits(nil)
+
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second}
+	other := newTestOutboxWorker(env, policy)
+	other.id = "other"
+
+	// The stale worker's credit outlives its lease; meanwhile other claims
+	// the entry and its own credit fails.
+	stale := newTestOutboxWorker(env, policy)
+	stale.wallet = &stallingWallet{Wallet: env.wallet, stall: func() {
+		env.clock.Advance(outboxLease + time.Second)
+		env.wallet.FailCredits(errors.New("wallet timeout"))
+		other.processPending(context.Background())
+		env.wallet.FailCredits(nil)
+	}}
+	stale.processPending(context.Background())
+
+	got := getOutbox(t, env, entry)
+	if got.Status != sqlc.OutboxStatusRetrying || got.Attempts != 1 || got.LockedBy.String != "other" {
+		t.Fatalf("status %q attempts %d locked by %q, want other's retry", got.Status, got.Attempts, got.LockedBy.String)
+	}
+	if got := ledgerBalance(env, AccountSettlementPayable); got == 0 {
+		t.Fatal("stale worker settled the entry")
+	}
+
+	env.clock.Advance(time.Second)
+	other.processPending(context.Background())
+
+	if got := getOutbox(t, env, entry); got.Status != sqlc.OutboxStatusSucceeded {
+		t.Fatalf("status %q, want succeeded", got.Status)
+	}
+	if got := env.wallet.Balance(bet.PlayerID); got != 140 {
+		t.Fatalf("wallet balance = %v, want 140", got)
+	}
+	if got := ledgerBalance(env, AccountSettlementPayable); got != 0 {
+		t.Fatalf("settlement_payable = %v, want 0", got)
+	}
+	var settled int
+	for _, typ := range env.eventTypes() {
+		if typ == "settlement.success" {
+			settled++
+		}
+	}
+	if settled != 1 {
+		t.Fatalf("recorded %d settlement.success events, want 1", settled)
+	}
+}
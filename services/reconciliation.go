@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rgs/observability"
+	"rgs/sqlc"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Discrepancy kinds.
+const (
+	DiscrepancyMissing    = "missing"
+	DiscrepancyDuplicated = "duplicated"
+	DiscrepancyMismatched = "mismatched"
+	DiscrepancyUnexpected = "unexpected"
+)
+
+// reconciliationSlack widens the wallet side of the window so movements
+// made just before a bet row's timestamp crosses midnight are still seen.
+const reconciliationSlack = 5 * time.Minute
+
+type ReconciliationService struct {
+	queries *sqlc.Queries
+	wallet  *WalletClient
+	// horizon is how long after a bet its win may still be credited by
+	// the outbox.
+	horizon time.Duration
+}
+
+func NewReconciliationService(q *sqlc.Queries, wallet *WalletClient, outbox RetryPolicy) *ReconciliationService {
+	return &ReconciliationService{queries: q, wallet: wallet, horizon: outbox.Horizon() + reconciliationSlack}
+}
+
+// expectedMovement is one wallet movement the RGS believes happened. For a
+// pending settlement settled is false and the wallet must not have it yet.
+type expectedMovement struct {
+	operatorID int32
+	betID      int32
+	playerID   int32
+	direction  string
+	amount     float64
+	requestIDs []string
+	settled    bool
+}
+
+// betCreditRequestIDs lists the request ids a bet's win may have been
+// credited under. Besides the credit tx id it keeps the keys used before
+// one was persisted, so older windows still reconcile.
+func betCreditRequestIDs(bet sqlc.Bet, outbox []sqlc.Outbox) []string {
+	ids := []string{CreditTxID(bet.ID), bet.IdempotencyKey + "-win"}
+	for _, e := range outbox {
+		if e.CreditTxID != ids[0] && e.CreditTxID != ids[1] {
+			ids = append(ids, e.CreditTxID)
+		}
+		ids = append(ids, fmt.Sprintf("bet-%d-retry-%d", e.BetID, e.ID))
+	}
+	return ids
+}
+
+// Reconcile compares bets and outbox settlements created in [from, to)
+// against the wallet transaction log and persists what does not match.
+func (s *ReconciliationService) Reconcile(ctx context.Context, from, to time.Time) (sqlc.ReconciliationRun, error) {
+	run, err := s.queries.CreateReconciliationRun(ctx, sqlc.CreateReconciliationRunParams{
+		WindowStart: from,
+		WindowEnd:   to,
+	})
+	if err != nil {
+		return sqlc.ReconciliationRun{}, err
+	}
+
+	checked, found, err := s.compare(ctx, from, to)
+	if err != nil {
+		_ = s.queries.FailReconciliationRun(ctx, sqlc.FailReconciliationRunParams{
+			ID:           run.ID,
+			ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+		})
+		return run, err
+	}
+
+	for _, d := range found {
+		d.RunID = run.ID
+		if err := s.queries.InsertReconciliationDiscrepancy(ctx, d); err != nil {
+			_ = s.queries.FailReconciliationRun(ctx, sqlc.FailReconciliationRunParams{
+				ID:           run.ID,
+				ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+			})
+			return run, err
+		}
+	}
+
+	observability.Logger.Info("reconciliation finished",
+		zap.Int32("run_id", run.ID),
+		zap.Time("from", from),
+		zap.Time("to", to),
+		zap.Int("checked", checked),
+		zap.Int("discrepancies", len(found)),
+	)
+
+	return s.queries.CompleteReconciliationRun(ctx, sqlc.CompleteReconciliationRunParams{
+		ID:            run.ID,
+		Checked:       int32(checked),
+		Discrepancies: int32(len(found)),
+	})
+}
+
+// ReconcileIfMissing runs the window unless a completed run already exists.
+func (s *ReconciliationService) ReconcileIfMissing(ctx context.Context, from, to time.Time) error {
+	_, err := s.queries.GetCompletedReconciliationRun(ctx, sqlc.GetCompletedReconciliationRunParams{
+		WindowStart: from,
+		WindowEnd:   to,
+	})
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = s.Reconcile(ctx, from, to)
+	return err
+}
+
+func (s *ReconciliationService) compare(ctx context.Context, from, to time.Time) (int, []sqlc.InsertReconciliationDiscrepancyParams, error) {
+	w := reconciliationWindow{from: from, to: to, earlier: map[string]bool{}}
+
+	var err error
+	w.bets, w.outbox, err = s.betsCreatedBetween(ctx, from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// A win on a bet from just before the window may be credited inside
+	// it by an outbox retry; that credit belongs to the earlier run.
+	earlierBets, earlierOutbox, err := s.betsCreatedBetween(ctx, from.Add(-s.horizon), from)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, id := range betRequestIDs(earlierBets, earlierOutbox) {
+		w.earlier[id] = true
+	}
+
+	w.walletTxs, err = s.wallet.Transactions(ctx, from.Add(-reconciliationSlack), to.Add(s.horizon))
+	if err != nil {
+		return 0, nil, fmt.Errorf("fetch wallet transactions: %w", err)
+	}
+
+	checked, found := w.discrepancies(func(playerID int32) (int32, bool) {
+		operatorID, err := s.queries.GetPlayerOperatorID(ctx, playerID)
+		return operatorID, err == nil
+	})
+	return checked, found, nil
+}
+
+func (s *ReconciliationService) betsCreatedBetween(ctx context.Context, from, to time.Time) ([]sqlc.Bet, []sqlc.Outbox, error) {
+	bets, err := s.queries.ListBetsCreatedBetween(ctx, sqlc.ListBetsCreatedBetweenParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outbox, err := s.queries.ListOutboxForBetsCreatedBetween(ctx, sqlc.ListOutboxForBetsCreatedBetweenParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return bets, outbox, nil
+}
+
+// betRequestIDs lists every wallet request id the bets' debits and credits
+// may have used.
+func betRequestIDs(bets []sqlc.Bet, outbox []sqlc.Outbox) []string {
+	outboxByBet := map[int32][]sqlc.Outbox{}
+	for _, e := range outbox {
+		outboxByBet[e.BetID] = append(outboxByBet[e.BetID], e)
+	}
+
+	var ids []string
+	for _, bet := range bets {
+		ids = append(ids, bet.IdempotencyKey)
+		ids = append(ids, betCreditRequestIDs(bet, outboxByBet[bet.ID])...)
+	}
+	return ids
+}
+
+// reconciliationWindow holds the bets created in [from, to), their outbox
+// entries and the wallet log around them. The wallet log reaches past to
+// by the outbox retry horizon so late credits still match their bet.
+type reconciliationWindow struct {
+	from, to  time.Time
+	bets      []sqlc.Bet
+	outbox    []sqlc.Outbox
+	walletTxs []WalletTransaction
+	// earlier holds the request ids of bets created before from, whose
+	// movements may land in the window but are reconciled with them.
+	earlier map[string]bool
+}
+
+// discrepancies compares the window's expected movements with its wallet
+// log and returns how many it checked and what did not match. operatorOf
+// attributes wallet movements that match no bet.
+func (w reconciliationWindow) discrepancies(operatorOf func(playerID int32) (int32, bool)) (int, []sqlc.InsertReconciliationDiscrepancyParams) {
+	outboxByBet := map[int32][]sqlc.Outbox{}
+	for _, e := range w.outbox {
+		outboxByBet[e.BetID] = append(outboxByBet[e.BetID], e)
+	}
+
+	var expected []expectedMovement
+	for _, bet := range w.bets {
+		expected = append(expected, expectedMovement{
+			operatorID: bet.OperatorID,
+			betID:      bet.ID,
+			playerID:   bet.PlayerID,
+			direction:  DirectionDebit,
+			amount:     bet.Amount,
+			requestIDs: []string{bet.IdempotencyKey},
+			settled:    true,
+		})
+
+		switch bet.Status {
+		case "won":
+			expected = append(expected, expectedMovement{
+				operatorID: bet.OperatorID,
+				betID:      bet.ID,
+				playerID:   bet.PlayerID,
+				direction:  DirectionCredit,
+				amount:     bet.WinAmount,
+				requestIDs: betCreditRequestIDs(bet, outboxByBet[bet.ID]),
+				settled:    true,
+			})
+		case "pending_settlement":
+			expected = append(expected, expectedMovement{
+				operatorID: bet.OperatorID,
+				betID:      bet.ID,
+				playerID:   bet.PlayerID,
+				direction:  DirectionCredit,
+				amount:     bet.WinAmount,
+				requestIDs: betCreditRequestIDs(bet, outboxByBet[bet.ID]),
+			})
+		}
+	}
+
+	byRequest := map[string][]WalletTransaction{}
+	for _, t := range w.walletTxs {
+		byRequest[t.RequestID] = append(byRequest[t.RequestID], t)
+	}
+	consumed := map[string]bool{}
+
+	var found []sqlc.InsertReconciliationDiscrepancyParams
+	add := func(m expectedMovement, requestID, kind string, actual float64, details string) {
+		found = append(found, sqlc.InsertReconciliationDiscrepancyParams{
+			OperatorID:     nullInt32(m.operatorID),
+			BetID:          nullInt32(m.betID),
+			PlayerID:       m.playerID,
+			RequestID:      requestID,
+			Kind:           kind,
+			Direction:      m.direction,
+			ExpectedAmount: m.amount,
+			ActualAmount:   actual,
+			Details:        details,
+		})
+	}
+
+	for _, m := range expected {
+		var matches []WalletTransaction
+		for _, id := range m.requestIDs {
+			matches = append(matches, byRequest[id]...)
+			consumed[id] = true
+		}
+
+		if !m.settled {
+			if len(matches) > 0 {
+				add(m, matches[0].RequestID, DiscrepancyMismatched, matches[0].Amount,
+					"wallet credited a bet that is still pending settlement")
+			}
+			continue
+		}
+
+		if len(matches) == 0 {
+			add(m, m.requestIDs[0], DiscrepancyMissing, 0, "no wallet transaction found")
+			continue
+		}
+
+		if len(matches) > 1 {
+			total := 0.0
+			for _, t := range matches {
+				total += t.Amount
+			}
+			add(m, matches[0].RequestID, DiscrepancyDuplicated, total,
+				fmt.Sprintf("%d wallet transactions for one %s", len(matches), m.direction))
+			continue
+		}
+
+		t := matches[0]
+		switch {
+		case t.Type != m.direction:
+			add(m, t.RequestID, DiscrepancyMismatched, t.Amount,
+				fmt.Sprintf("wallet recorded %s, expected %s", t.Type, m.direction))
+		case t.PlayerID != m.playerID:
+			add(m, t.RequestID, DiscrepancyMismatched, t.Amount,
+				fmt.Sprintf("wallet recorded player %d, expected %d", t.PlayerID, m.playerID))
+		case toCents(t.Amount) != toCents(m.amount):
+			add(m, t.RequestID, DiscrepancyMismatched, t.Amount, "amount differs")
+		}
+	}
+
+	for _, t := range w.walletTxs {
+		if consumed[t.RequestID] || w.earlier[t.RequestID] || t.CreatedAt.Before(w.from) || !t.CreatedAt.Before(w.to) {
+			continue
+		}
+
+		m := expectedMovement{playerID: t.PlayerID, direction: t.Type}
+		if operatorID, ok := operatorOf(t.PlayerID); ok {
+			m.operatorID = operatorID
+		}
+		add(m, t.RequestID, DiscrepancyUnexpected, t.Amount, "wallet transaction has no matching bet or settlement")
+	}
+
+	return len(expected), found
+}
+
+func (s *ReconciliationService) ListDiscrepancies(
+	ctx context.Context,
+	operatorID int32,
+	runID *int32,
+	limit, offset int32,
+) ([]sqlc.ReconciliationDiscrepancy, error) {
+	if runID == nil {
+		return s.queries.ListDiscrepanciesByOperator(ctx, sqlc.ListDiscrepanciesByOperatorParams{
+			OperatorID: nullInt32(operatorID),
+			Limit:      limit,
+			Offset:     offset,
+		})
+	}
+
+	return s.queries.ListDiscrepanciesByOperatorRun(ctx, sqlc.ListDiscrepanciesByOperatorRunParams{
+		OperatorID: nullInt32(operatorID),
+		RunID:      *runID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"rgs/sqlc"
+	"testing"
+	"time"
+)
+
+func TestReconciliationWindowMatchesCreditsAcrossMidnight(t *testing.T) {
+	midnight := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	bet := sqlc.Bet{
+		ID: 1, OperatorID: 1, PlayerID: 7, Amount: 1, WinAmount: 5,
+		Status: "won", IdempotencyKey: "k1", CreatedAt: midnight.Add(-30 * time.Second),
+	}
+	entry := sqlc.Outbox{ID: 3, BetID: 1, OperatorID: 1, PlayerID: 7, Amount: 5, CreditTxID: CreditTxID(1)}
+	walletTxs := []WalletTransaction{
+		{RequestID: "k1", PlayerID: 7, Type: DirectionDebit, Amount: 1, CreatedAt: bet.CreatedAt},
+		{RequestID: CreditTxID(1), PlayerID: 7, Type: DirectionCredit, Amount: 5, CreatedAt: midnight.Add(40 * time.Minute)},
+		{RequestID: "stray", PlayerID: 7, Type: DirectionCredit, Amount: 2, CreatedAt: midnight.Add(time.Hour)},
+	}
+	operatorOf := func(int32) (int32, bool) { return 1, true }
+
+	day := reconciliationWindow{
+		from: midnight.Add(-24 * time.Hour), to: midnight,
+		bets: []sqlc.Bet{bet}, outbox: []sqlc.Outbox{entry},
+		walletTxs: walletTxs, earlier: map[string]bool{},
+	}
+	if checked, found := day.discrepancies(operatorOf); checked != 2 || len(found) != 0 {
+		t.Fatalf("bet's day: checked %d, found %+v", checked, found)
+	}
+
+	next := reconciliationWindow{
+		from: midnight, to: midnight.Add(24 * time.Hour),
+		walletTxs: walletTxs, earlier: map[string]bool{},
+	}
+	for _, id := range betRequestIDs([]sqlc.Bet{bet}, []sqlc.Outbox{entry}) {
+		next.earlier[id] = true
+	}
+	_, found := next.discrepancies(operatorOf)
+	if len(found) != 1 || found[0].RequestID != "stray" || found[0].Kind != DiscrepancyUnexpected {
+		t.Fatalf("next day: found %+v, want only the stray credit as unexpected", found)
+	}
+}
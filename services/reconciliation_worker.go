@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"rgs/clock"
+	"rgs/observability"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReconciliationWorker reconciles the previous UTC day once it has ended
+// and the outbox retry horizon has passed.
+// It checks every interval (hourly by default) so a missed or failed run is
+// picked up again. With a leader elector only the replica holding the lock
+// runs it.
+type ReconciliationWorker struct {
+	svc      *ReconciliationService
+	leader   *LeaderElector
+	clock    clock.Clock
+	interval time.Duration
+	hb       *Heartbeat
+}
+
+func NewReconciliationWorker(
+	svc *ReconciliationService,
+	leader *LeaderElector,
+	clk clock.Clock,
+	interval time.Duration,
+	hb *Heartbeat,
+) *ReconciliationWorker {
+	return &ReconciliationWorker{svc: svc, leader: leader, clock: clk, interval: interval, hb: hb}
+}
+
+func (w *ReconciliationWorker) Run(ctx context.Context) {
+	for {
+		w.runPreviousDay(ctx)
+		w.hb.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
+		}
+	}
+}
+
+func (w *ReconciliationWorker) runPreviousDay(runCtx context.Context) {
+	// A started run is finished even when shutdown begins.
+	ctx := context.WithoutCancel(runCtx)
+
+	if w.leader != nil && !w.leader.IsLeader(ctx) {
+		return
+	}
+
+	// The day is left until the outbox can no longer credit its wins, so
+	// none is still pending settlement when it is compared.
+	to := w.clock.Now().UTC().Add(-w.svc.horizon).Truncate(24 * time.Hour)
+	from := to.Add(-24 * time.Hour)
+
+	if err := w.svc.ReconcileIfMissing(ctx, from, to); err != nil {
+		observability.Logger.Error("reconciliation failed",
+			zap.Time("from", from),
+			zap.Time("to", to),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"rgs/sqlc"
+)
+
+// The repositories below are the slices of sqlc.Queries each service
+// uses. *sqlc.Queries implements all of them; memstore provides an
+// in-memory implementation for tests.
+
+type EventWriter interface {
+	InsertEvent(ctx context.Context, arg sqlc.InsertEventParams) (sqlc.Event, error)
+}
+
+type LedgerWriter interface {
+	InsertLedgerEntry(ctx context.Context, arg sqlc.InsertLedgerEntryParams) (sqlc.LedgerEntry, error)
+}
+
+type AuditRepo interface {
+	InsertAuditLog(ctx context.Context, arg sqlc.InsertAuditLogParams) error
+	ListAuditLogsByOperator(ctx context.Context, arg sqlc.ListAuditLogsByOperatorParams) ([]sqlc.AuditLog, error)
+	ListAuditLogsByOperatorPlayer(ctx context.Context, arg sqlc.ListAuditLogsByOperatorPlayerParams) ([]sqlc.AuditLog, error)
+}
+
+type ComplianceRepo interface {
+	AuditRepo
+	GetOperatorLimits(ctx context.Context, operatorID int32) (sqlc.OperatorLimit, error)
+}
+
+type SessionRepo interface {
+	EventWriter
+	GetPlayer(ctx context.Context, arg sqlc.GetPlayerParams) (sqlc.Player, error)
+	CreatePlayer(ctx context.Context, arg sqlc.CreatePlayerParams) (sqlc.Player, error)
+	CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error)
+	VerifySessionByToken(ctx context.Context, arg sqlc.VerifySessionByTokenParams) (sqlc.Session, error)
+	RevokeSession(ctx context.Context, arg sqlc.RevokeSessionParams) (sqlc.Session, error)
+}
+
+type BetRepo interface {
+	EventWriter
+	LedgerWriter
+	GetPlayerByID(ctx context.Context, arg sqlc.GetPlayerByIDParams) (sqlc.Player, error)
+	GetBetByIdempotency(ctx context.Context, arg sqlc.GetBetByIdempotencyParams) (sqlc.Bet, error)
+	CreateRound(ctx context.Context, arg sqlc.CreateRoundParams) (sqlc.Round, error)
+	GetRound(ctx context.Context, arg sqlc.GetRoundParams) (sqlc.Round, error)
+	CreateBet(ctx context.Context, arg sqlc.CreateBetParams) (sqlc.Bet, error)
+	UpdateBetStatus(ctx context.Context, arg sqlc.UpdateBetStatusParams) (sqlc.Bet, error)
+	InsertOutbox(ctx context.Context, arg sqlc.InsertOutboxParams) (sqlc.Outbox, error)
+}
+
+// OperatorRepo is what OperatorService.Create writes in one transaction.
+type OperatorRepo interface {
+	CreateOperator(ctx context.Context, arg sqlc.CreateOperatorParams) (sqlc.Operator, error)
+	CreateWebhookEndpoint(ctx context.Context, arg sqlc.CreateWebhookEndpointParams) (sqlc.WebhookEndpoint, error)
+}
+
+type OutboxRepo interface {
+	EventWriter
+	LedgerWriter
+	ClaimPendingOutbox(ctx context.Context, arg sqlc.ClaimPendingOutboxParams) ([]sqlc.Outbox, error)
+	MarkBetAsWon(ctx context.Context, id int32) (sqlc.Bet, error)
+	MarkOutboxSucceeded(ctx context.Context, arg sqlc.MarkOutboxSucceededParams) (int64, error)
+	MarkOutboxRetry(ctx context.Context, arg sqlc.MarkOutboxRetryParams) (int64, error)
+	MarkOutboxDead(ctx context.Context, arg sqlc.MarkOutboxDeadParams) (int64, error)
+	GetOutboxByOperator(ctx context.Context, arg sqlc.GetOutboxByOperatorParams) (sqlc.Outbox, error)
+	ListOutboxByOperator(ctx context.Context, operatorID int32) ([]sqlc.Outbox, error)
+	ListOutboxByOperatorStatus(ctx context.Context, arg sqlc.ListOutboxByOperatorStatusParams) ([]sqlc.Outbox, error)
+	RedriveOutbox(ctx context.Context, arg sqlc.RedriveOutboxParams) (sqlc.Outbox, error)
+	RedriveDeadOutboxByOperator(ctx context.Context, arg sqlc.RedriveDeadOutboxByOperatorParams) ([]sqlc.Outbox, error)
+}
+
+type WebhookRepo interface {
+	ClaimPendingWebhookEvents(ctx context.Context, arg sqlc.ClaimPendingWebhookEventsParams) ([]sqlc.WebhookEvent, error)
+	CountQueuedWebhooksByOperator(ctx context.Context) ([]sqlc.CountQueuedWebhooksByOperatorRow, error)
+	GetWebhookEndpoint(ctx context.Context, arg sqlc.GetWebhookEndpointParams) (sqlc.WebhookEndpoint, error)
+	MarkWebhookCompleted(ctx context.Context, arg sqlc.MarkWebhookCompletedParams) (int64, error)
+	MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (int64, error)
+	MarkWebhookDead(ctx context.Context, arg sqlc.MarkWebhookDeadParams) (int64, error)
+	UpdateWebhookRetry(ctx context.Context, arg sqlc.UpdateWebhookRetryParams) (int64, error)
+	GetWebhookEventByID(ctx context.Context, arg sqlc.GetWebhookEventByIDParams) (sqlc.WebhookEvent, error)
+	ResetWebhookForRetry(ctx context.Context, arg sqlc.ResetWebhookForRetryParams) (int64, error)
+	ReplayWebhookEvents(ctx context.Context, arg sqlc.ReplayWebhookEventsParams) ([]int32, error)
+	ListWebhooksByOperator(ctx context.Context, operatorID int32) ([]sqlc.WebhookEvent, error)
+	ListWebhooksByOperatorStatus(ctx context.Context, arg sqlc.ListWebhooksByOperatorStatusParams) ([]sqlc.WebhookEvent, error)
+	InsertWebhookDelivery(ctx context.Context, arg sqlc.InsertWebhookDeliveryParams) (sqlc.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, arg sqlc.ListWebhookDeliveriesParams) ([]sqlc.WebhookDelivery, error)
+	GetWebhookRetryPolicy(ctx context.Context, operatorID int32) (sqlc.WebhookRetryPolicy, error)
+	UpsertWebhookRetryPolicy(ctx context.Context, arg sqlc.UpsertWebhookRetryPolicyParams) (sqlc.WebhookRetryPolicy, error)
+	DeleteWebhookRetryPolicy(ctx context.Context, operatorID int32) error
+}
+
+type EventRepo interface {
+	EventWriter
+	ClaimUndispatchedEvents(ctx context.Context, arg sqlc.ClaimUndispatchedEventsParams) ([]sqlc.Event, error)
+	ListEnabledWebhookEndpoints(ctx context.Context, operatorID int32) ([]sqlc.WebhookEndpoint, error)
+	InsertWebhookEvent(ctx context.Context, arg sqlc.InsertWebhookEventParams) (sqlc.WebhookEvent, error)
+	MarkEventDispatched(ctx context.Context, arg sqlc.MarkEventDispatchedParams) (int64, error)
+}
+
+var (
+	_ ComplianceRepo = (*sqlc.Queries)(nil)
+	_ SessionRepo    = (*sqlc.Queries)(nil)
+	_ BetRepo        = (*sqlc.Queries)(nil)
+	_ OperatorRepo   = (*sqlc.Queries)(nil)
+	_ OutboxRepo     = (*sqlc.Queries)(nil)
+	_ WebhookRepo    = (*sqlc.Queries)(nil)
+	_ EventRepo      = (*sqlc.Queries)(nil)
+)
+
+// Tx runs fn atomically, handing it a repository bound to the
+// transaction. fn's error rolls everything back.
+type Tx[R any] func(ctx context.Context, fn func(R) error) error
+
+// SQLTx runs transactions on db. R must be one of the repository
+// interfaces above.
+func SQLTx[R any](db DB, q *sqlc.Queries) Tx[R] {
+	return func(ctx context.Context, fn func(R) error) error {
+		return withTx(ctx, db, q, func(qtx *sqlc.Queries) error {
+			return fn(any(qtx).(R))
+		})
+	}
+}
+
+// Wallet is the part of WalletClient that moves money.
+type Wallet interface {
+	Debit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error)
+	Credit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"rgs/game"
+	"rgs/sqlc"
+)
+
+type RoundVerification struct {
+	RoundID         int32  `json:"round_id"`
+	ServerSeed      string `json:"server_seed"`
+	ClientSeed      string `json:"client_seed"`
+	Hash            string `json:"hash"`
+	StoredOutcome   int32  `json:"stored_outcome"`
+	ComputedOutcome int32  `json:"computed_outcome"`
+	Valid           bool   `json:"valid"`
+}
+
+// VerifyRound recomputes one of the operator's rounds from its seeds.
+func VerifyRound(ctx context.Context, q *sqlc.Queries, operatorID, id int32) (RoundVerification, error) {
+	round, err := q.GetRound(ctx, sqlc.GetRoundParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+	if err != nil {
+		return RoundVerification{}, err
+	}
+
+	result := game.GenerateOutcome(round.ServerSeed, round.ClientSeed)
+
+	return RoundVerification{
+		RoundID:         round.ID,
+		ServerSeed:      round.ServerSeed,
+		ClientSeed:      round.ClientSeed,
+		Hash:            result.Hash,
+		StoredOutcome:   round.Outcome,
+		ComputedOutcome: result.Outcome,
+		Valid:           result.Outcome == round.Outcome,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"rgs/clock"
 	"time"
 
 	"rgs/sqlc"
@@ -12,17 +13,21 @@ import (
 )
 
 type SessionsService struct {
-	queries    *sqlc.Queries
-	bus        *EventBus
+	repo       SessionRepo
+	tx         Tx[SessionRepo]
+	clock      clock.Clock
+	events     *EventDispatcher
 	compliance *ComplianceService
 }
 
 func NewSessionsService(
-	q *sqlc.Queries,
-	bus *EventBus,
+	repo SessionRepo,
+	tx Tx[SessionRepo],
+	clk clock.Clock,
+	events *EventDispatcher,
 	comp *ComplianceService,
 ) *SessionsService {
-	return &SessionsService{queries: q, bus: bus, compliance: comp}
+	return &SessionsService{repo: repo, tx: tx, clock: clk, events: events, compliance: comp}
 }
 
 type LaunchSessionParams struct {
@@ -47,7 +52,7 @@ func (s *SessionsService) getOrCreatePlayer(
 	externalID string,
 	jurisdiction string,
 ) (sqlc.Player, error) {
-	player, err := s.queries.GetPlayer(ctx, sqlc.GetPlayerParams{
+	player, err := s.repo.GetPlayer(ctx, sqlc.GetPlayerParams{
 		OperatorID:       operatorID,
 		ExternalPlayerID: externalID,
 	})
@@ -56,7 +61,7 @@ func (s *SessionsService) getOrCreatePlayer(
 		return player, nil
 	}
 
-	return s.queries.CreatePlayer(ctx, sqlc.CreatePlayerParams{
+	return s.repo.CreatePlayer(ctx, sqlc.CreatePlayerParams{
 		OperatorID:       operatorID,
 		ExternalPlayerID: externalID,
 		Jurisdiction:     jurisdiction,
@@ -75,21 +80,38 @@ func (s *SessionsService) LaunchSession(ctx context.Context, p LaunchSessionPara
 		return sqlc.Session{}, err
 	}
 
+	if err := s.compliance.CheckPlayer(ctx, player); err != nil {
+		return sqlc.Session{}, err
+	}
+
 	launchToken, err := generateSecureToken()
 	if err != nil {
 		return sqlc.Session{}, err
 	}
 
-	session, err := s.queries.CreateSession(ctx, sqlc.CreateSessionParams{
-		ID:          id,
-		OperatorID:  p.OperatorID,
-		PlayerID:    player.ID,
-		LaunchToken: launchToken,
-		ExpiresAt:   time.Now().Add(p.TTL),
+	var session sqlc.Session
+	err = s.tx(ctx, func(q SessionRepo) error {
+		session, err = q.CreateSession(ctx, sqlc.CreateSessionParams{
+			ID:          id,
+			OperatorID:  p.OperatorID,
+			PlayerID:    player.ID,
+			LaunchToken: launchToken,
+			ExpiresAt:   s.clock.Now().Add(p.TTL),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, q, p.OperatorID, "session.launched", SessionLaunched{
+			SessionID: session.ID,
+			PlayerID:  player.ID,
+			ExpiresAt: session.ExpiresAt,
+		})
 	})
 	if err != nil {
 		return sqlc.Session{}, err
 	}
+	s.events.Notify()
 
 	s.compliance.Log(ctx, p.OperatorID, &player.ID, "session.launch", map[string]any{
 		"external_player_id": p.ExternalPlayerID,
@@ -97,56 +119,50 @@ func (s *SessionsService) LaunchSession(ctx context.Context, p LaunchSessionPara
 		"session_id":         session.ID.String(),
 	})
 
-	s.bus.Publish(SSEEvent{
-		ID:         uuid.NewString(),
-		OperatorID: p.OperatorID,
-		EventType:  "session.launched",
-		Data: map[string]any{
-			"session_id": session.ID,
-			"player_id":  player.ID,
-			"expires_at": session.ExpiresAt,
-		},
-		CreatedAt: time.Now(),
-	})
-
 	return session, nil
 }
 
 func (s *SessionsService) VerifySession(ctx context.Context, token string, operatorID int32) (sqlc.Session, error) {
-	session, err := s.queries.VerifySessionByToken(ctx, sqlc.VerifySessionByTokenParams{
+	session, err := s.repo.VerifySessionByToken(ctx, sqlc.VerifySessionByTokenParams{
 		LaunchToken: token,
 		OperatorID:  operatorID,
+		Now:         s.clock.Now(),
 	})
 	if err != nil {
 		return sqlc.Session{}, err
 	}
 
-	s.bus.Publish(SSEEvent{
-		ID:         uuid.NewString(),
-		OperatorID: operatorID,
-		EventType:  "session.verified",
-		Data:       session,
-		CreatedAt:  time.Now(),
+	err = s.events.Record(ctx, s.repo, operatorID, "session.verified", SessionVerified{
+		SessionID: session.ID,
+		PlayerID:  session.PlayerID,
+		ExpiresAt: session.ExpiresAt,
 	})
+	if err != nil {
+		return sqlc.Session{}, err
+	}
+	s.events.Notify()
 
 	return session, nil
 }
 
 func (s *SessionsService) RevokeSession(ctx context.Context, id uuid.UUID, operatorID int32) error {
-	err := s.queries.RevokeSession(ctx, id)
+	err := s.tx(ctx, func(q SessionRepo) error {
+		_, err := q.RevokeSession(ctx, sqlc.RevokeSessionParams{
+			ID:         id,
+			OperatorID: operatorID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Record(ctx, q, operatorID, "session.revoked", SessionRevoked{
+			SessionID: id,
+		})
+	})
 	if err != nil {
 		return err
 	}
-
-	s.bus.Publish(SSEEvent{
-		ID:         uuid.NewString(),
-		OperatorID: operatorID,
-		EventType:  "session.revoked",
-		Data: map[string]any{
-			"session_id": id.String(),
-		},
-		CreatedAt: time.Now(),
-	})
+	s.events.Notify()
 
 	return nil
 }
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"rgs/memstore"
+	"testing"
+	"time"
+)
+
+func newTestSessions(env *testEnv) *SessionsService {
+	return NewSessionsService(
+		env.store, memstore.Tx[SessionRepo](env.store), env.clock,
+		env.events, NewComplianceService(env.store),
+	)
+}
+
+func TestSessionExpiresAfterTTL(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "http://example.invalid")
+	svc := newTestSessions(env)
+
+	session, err := svc.LaunchSession(env.ctx, LaunchSessionParams{
+		OperatorID:       op.ID,
+		ExternalPlayerID: "p1",
+		Jurisdiction:     "MT",
+		TTL:              30 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("launch: %v", err)
+	}
+	if want := env.clock.Now().Add(30 * time.Minute); !session.ExpiresAt.Equal(want) {
+		t.Fatalf("expires_at = %v, want %v", session.ExpiresAt, want)
+	}
+
+	env.clock.Advance(29 * time.Minute)
+	if _, err := svc.VerifySession(env.ctx, session.LaunchToken, op.ID); err != nil {
+		t.Fatalf("verify before expiry: %v", err)
+	}
+
+	env.clock.Advance(time.Minute)
+	_, err = svc.VerifySession(env.ctx, session.LaunchToken, op.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("verify at expiry: err = %v, want sql.ErrNoRows", err)
+	}
+}
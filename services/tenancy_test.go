@@ -0,0 +1,109 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"rgs/sqlc"
+	"testing"
+	"time"
+)
+
+// twoOperators returns an owner and an intruder that must not see the
+// owner's rows.
+func twoOperators(t *testing.T, env *testEnv) (owner, intruder sqlc.Operator) {
+	t.Helper()
+
+	owner = env.operator(t, "")
+	env.clock.Advance(time.Second)
+	intruder = env.operator(t, "")
+	return owner, intruder
+}
+
+func TestPlaceBetRejectsAnotherOperatorsPlayer(t *testing.T) {
+	env := newTestEnv(t)
+	owner, intruder := twoOperators(t, env)
+	player := env.player(t, owner.ID, 100)
+
+	agg := newTestAggregate(env)
+	agg.newSeed = seedsFor(t, 3)
+
+	_, _, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: intruder.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if !errors.Is(err, ErrPlayerNotFound) {
+		t.Fatalf("err = %v, want ErrPlayerNotFound", err)
+	}
+	if n := len(env.wallet.Calls()); n != 0 {
+		t.Fatalf("wallet calls = %d, want 0", n)
+	}
+
+	round, _, err := agg.PlaceBet(env.ctx, PlaceBetParams{
+		OperatorID: owner.ID, PlayerID: player.ID, Amount: 10, IdempotencyKey: "bet-1",
+	})
+	if err != nil {
+		t.Fatalf("owner bet: %v", err)
+	}
+	_, err = env.store.GetRound(env.ctx, sqlc.GetRoundParams{ID: round.ID, OperatorID: intruder.ID})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("intruder round lookup: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestRevokeSessionIsScopedToOperator(t *testing.T) {
+	env := newTestEnv(t)
+	owner, intruder := twoOperators(t, env)
+	svc := newTestSessions(env)
+
+	session, err := svc.LaunchSession(env.ctx, LaunchSessionParams{
+		OperatorID:       owner.ID,
+		ExternalPlayerID: "p1",
+		Jurisdiction:     "MT",
+		TTL:              time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("launch: %v", err)
+	}
+
+	if err := svc.RevokeSession(env.ctx, session.ID, intruder.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("intruder revoke: err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := svc.VerifySession(env.ctx, session.LaunchToken, owner.ID); err != nil {
+		t.Fatalf("session revoked by another operator: %v", err)
+	}
+	for _, typ := range env.eventTypes() {
+		if typ == "session.revoked" {
+			t.Fatal("recorded session.revoked for a failed revoke")
+		}
+	}
+
+	if err := svc.RevokeSession(env.ctx, session.ID, owner.ID); err != nil {
+		t.Fatalf("owner revoke: %v", err)
+	}
+}
+
+func TestWebhookRetryAndAttemptsAreScopedToOperator(t *testing.T) {
+	env := newTestEnv(t)
+	owner, intruder := twoOperators(t, env)
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+
+	e := enqueueWebhook(t, env, owner.ID)
+	claimWebhook(t, env, e)
+	_, _ = env.store.MarkWebhookDead(env.ctx, sqlc.MarkWebhookDeadParams{ID: e.ID, WorkerID: "claimer"})
+
+	if err := svc.RetryWebhook(env.ctx, intruder.ID, e.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("intruder retry: err = %v, want sql.ErrNoRows", err)
+	}
+	if got := getWebhook(t, env, owner.ID, e.ID); got.Status != "dead" {
+		t.Fatalf("status = %q after intruder retry, want dead", got.Status)
+	}
+	if _, err := svc.Attempts(env.ctx, intruder.ID, e.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("intruder attempts: err = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := svc.RetryWebhook(env.ctx, owner.ID, e.ID); err != nil {
+		t.Fatalf("owner retry: %v", err)
+	}
+	if got := getWebhook(t, env, owner.ID, e.ID); got.Status != "pending" {
+		t.Fatalf("status = %q after owner retry, want pending", got.Status)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"rgs/observability"
+	"rgs/sqlc"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// DB is what SQLTx and sqlc.New need: *sql.DB, or *TenantDB in row-level
+// security mode.
+type DB interface {
+	sqlc.DBTX
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+var (
+	_ DB = (*sql.DB)(nil)
+	_ DB = (*TenantDB)(nil)
+)
+
+type tenantKey struct{}
+
+// pinnedConn is the connection a request's queries share. It is taken
+// from the pool on the request's first query, so requests that never
+// query, such as SSE streams, hold none.
+type pinnedConn struct {
+	operatorID int32
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// TenantDB runs queries over a pool whose role is subject to the
+// operator_isolation policies. Pin binds a request to one operator: its
+// queries and transactions share a connection with app.operator_id set
+// to that operator, so Postgres hides every other operator's rows even
+// from a query that forgot its operator_id filter.
+//
+// Unpinned queries run on the pool, where app.operator_id is unset and
+// the policies match nothing; the API key lookup in OperatorMiddleware
+// relies on that being harmless for operators, which has no policy.
+type TenantDB struct {
+	db *sql.DB
+}
+
+func NewTenantDB(db *sql.DB) *TenantDB {
+	return &TenantDB{db: db}
+}
+
+// Pin returns a context whose queries run as operatorID. The caller must
+// call release once the request is done with the database.
+func (t *TenantDB) Pin(ctx context.Context, operatorID int32) (context.Context, func()) {
+	p := &pinnedConn{operatorID: operatorID}
+	return context.WithValue(ctx, tenantKey{}, p), func() { t.release(p) }
+}
+
+// conn returns the connection pinned to ctx, or nil if ctx is not pinned.
+func (t *TenantDB) conn(ctx context.Context) (*sql.Conn, error) {
+	p, ok := ctx.Value(tenantKey{}).(*pinnedConn)
+	if !ok {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		return p.conn, nil
+	}
+
+	conn, err := t.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.ExecContext(ctx, "SELECT set_config('app.operator_id', $1, false)",
+		strconv.Itoa(int(p.operatorID)))
+	if err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+
+	p.conn = conn
+	return conn, nil
+}
+
+func (t *TenantDB) release(p *pinnedConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return
+	}
+
+	// The connection goes back to the pool, where the next request must
+	// not inherit this operator.
+	if _, err := p.conn.ExecContext(context.Background(), "RESET app.operator_id"); err != nil {
+		observability.Logger.Warn("discarding tenant connection", zap.Error(err))
+		discardConn(p.conn)
+	} else {
+		_ = p.conn.Close()
+	}
+	p.conn = nil
+}
+
+// discardConn closes conn's underlying connection instead of returning it
+// to the pool.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
+func (t *TenantDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	conn, err := t.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return t.db.ExecContext(ctx, query, args...)
+	}
+	return conn.ExecContext(ctx, query, args...)
+}
+
+func (t *TenantDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	conn, err := t.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return t.db.PrepareContext(ctx, query)
+	}
+	return conn.PrepareContext(ctx, query)
+}
+
+func (t *TenantDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	conn, err := t.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return t.db.QueryContext(ctx, query, args...)
+	}
+	return conn.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext cannot return an error of its own, so if pinning fails
+// the query runs unpinned and matches nothing the policies cover.
+func (t *TenantDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	conn, err := t.conn(ctx)
+	if err != nil || conn == nil {
+		return t.db.QueryRowContext(ctx, query, args...)
+	}
+	return conn.QueryRowContext(ctx, query, args...)
+}
+
+func (t *TenantDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	conn, err := t.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return t.db.BeginTx(ctx, opts)
+	}
+	return conn.BeginTx(ctx, opts)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"rgs/sqlc"
+)
+
+// errLeaseLost means a worker's lease on a claimed row expired and another
+// worker claimed it; the stale worker's completion is rolled back and the
+// row is left to the new owner.
+var errLeaseLost = errors.New("lease lost")
+
+// leaseHeld turns the rows affected by a lease-checked completion query
+// into errLeaseLost when the row was no longer ours.
+func leaseHeld(n int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+func withTx(ctx context.Context, db DB, queries *sqlc.Queries, fn func(*sqlc.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	qtx := queries.WithTx(tx)
+
+	if err := fn(qtx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
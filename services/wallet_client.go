@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"rgs/observability"
 	"time"
 
@@ -34,25 +35,41 @@ type walletResponse struct {
 	Balance float64 `json:"balance"`
 }
 
-func NewWalletClient(baseURL, secret string) *WalletClient {
+func NewWalletClient(baseURL, secret string, timeout time.Duration) *WalletClient {
 	return &WalletClient{
 		baseURL: baseURL,
 		secret:  secret,
 		client: &http.Client{
-			Timeout: 3 * time.Second,
+			Timeout: timeout,
 		},
 	}
 }
 
-func (w *WalletClient) sign(playerID int32, amount float64, requestID string) string {
-	payload := fmt.Sprintf("%d:%f:%s", playerID, amount, requestID)
+type WalletTransaction struct {
+	ID           int64     `json:"id"`
+	RequestID    string    `json:"request_id"`
+	PlayerID     int32     `json:"player_id"`
+	Type         string    `json:"type"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type walletTransactionsResponse struct {
+	Transactions []WalletTransaction `json:"transactions"`
+}
 
+func (w *WalletClient) signPayload(payload string) string {
 	mac := hmac.New(sha256.New, []byte(w.secret))
 	mac.Write([]byte(payload))
 
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
+func (w *WalletClient) sign(playerID int32, amount float64, requestID string) string {
+	return w.signPayload(fmt.Sprintf("%d:%f:%s", playerID, amount, requestID))
+}
+
 func (w *WalletClient) call(ctx context.Context, path string, playerID int32, amount float64, requestID string) (bool, error) {
 	reqBody := walletRequest{
 		PlayerID:  playerID,
@@ -100,3 +117,63 @@ func (w *WalletClient) Debit(ctx context.Context, playerID int32, amount float64
 func (w *WalletClient) Credit(ctx context.Context, playerID int32, amount float64, requestID string) (bool, error) {
 	return w.call(ctx, "/wallet/credit", playerID, amount, requestID)
 }
+
+// Transactions fetches the wallet's transaction log for [from, to).
+func (w *WalletClient) Transactions(ctx context.Context, from, to time.Time) ([]WalletTransaction, error) {
+	fromParam := from.UTC().Format(time.RFC3339)
+	toParam := to.UTC().Format(time.RFC3339)
+
+	query := url.Values{}
+	query.Set("from", fromParam)
+	query.Set("to", toParam)
+	query.Set("signature", w.signPayload(fromParam+":"+toParam))
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", w.baseURL+"/wallet/transactions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		observability.Logger.Error("wallet http request failed", zap.Error(err))
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			observability.Logger.Error("failed to close wallet call", zap.Error(err))
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wallet transactions returned status %d", resp.StatusCode)
+	}
+
+	var res walletTransactionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		observability.Logger.Error("failed to decode wallet transactions", zap.Error(err))
+		return nil, err
+	}
+
+	return res.Transactions, nil
+}
+
+// Ping checks the wallet answers its health endpoint.
+func (w *WalletClient) Ping(ctx context.Context) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", w.baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wallet health returned %d", resp.StatusCode)
+	}
+
+	return nil
+}
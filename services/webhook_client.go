@@ -3,14 +3,13 @@ package services
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"rgs/clock"
 	"rgs/observability"
+	"rgs/webhooksdk"
 	"strconv"
 	"time"
 
@@ -19,46 +18,71 @@ import (
 
 type WebhookClient struct {
 	client *http.Client
-	secret string
+	clock  clock.Clock
 }
 
-func NewWebhookClient(secret string) *WebhookClient {
+// NewWebhookClient is shared by every delivery so connections to a
+// receiver are reused. idlePerHost should cover the deliveries that can be
+// in flight to one host at once.
+func NewWebhookClient(clk clock.Clock, idlePerHost int) *WebhookClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = idlePerHost
+
 	return &WebhookClient{
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: transport,
 		},
-		secret: secret,
+		clock: clk,
 	}
 }
 
-func (wc *WebhookClient) signPayload(timestamp string, payload []byte) string {
-	mac := hmac.New(sha256.New, []byte(wc.secret))
-	mac.Write([]byte(timestamp))
-	mac.Write(payload)
-	return hex.EncodeToString(mac.Sum(nil))
+// maxResponseBody is how much of a receiver's response is kept for the
+// delivery log.
+const maxResponseBody = 1024
+
+// WebhookAttempt is what one delivery sent and got back. StatusCode is 0
+// when no response arrived.
+type WebhookAttempt struct {
+	RequestHeaders map[string]string
+	StatusCode     int
+	ResponseBody   string
+	Latency        time.Duration
 }
 
-func (wc *WebhookClient) Send(ctx context.Context, webhookURL string, payload interface{}) error {
+// Send posts payload signed with every secret in secrets, so a receiver
+// holding any of them can verify during a rotation. The error is non-nil
+// unless the receiver answered 2xx.
+func (wc *WebhookClient) Send(ctx context.Context, webhookURL string, secrets []string, payload interface{}) (WebhookAttempt, error) {
+	var attempt WebhookAttempt
+
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return attempt, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	signature := wc.signPayload(timestamp, body)
+	timestamp := strconv.FormatInt(wc.clock.Now().Unix(), 10)
+	signature := webhooksdk.Sign(timestamp, body, secrets...)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		return attempt, fmt.Errorf("failed to create webhook request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-RGS-Timestamp", timestamp)
-	req.Header.Set("X-RGS-Signature", signature)
+	req.Header.Set(webhooksdk.TimestampHeader, timestamp)
+	req.Header.Set(webhooksdk.SignatureHeader, signature)
 
+	attempt.RequestHeaders = map[string]string{}
+	for name := range req.Header {
+		attempt.RequestHeaders[name] = req.Header.Get(name)
+	}
+
+	start := wc.clock.Now()
 	resp, err := wc.client.Do(req)
+	attempt.Latency = wc.clock.Now().Sub(start)
 	if err != nil {
-		return fmt.Errorf("webhook request failed: %w", err)
+		return attempt, fmt.Errorf("webhook request failed: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -67,9 +91,13 @@ func (wc *WebhookClient) Send(ctx context.Context, webhookURL string, payload in
 		}
 	}(resp.Body)
 
+	attempt.StatusCode = resp.StatusCode
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.ResponseBody = string(respBody)
+
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
+		return attempt, nil
 	}
 
-	return fmt.Errorf("webhook delivery returned status %d", resp.StatusCode)
+	return attempt, fmt.Errorf("webhook delivery returned status %d", resp.StatusCode)
 }
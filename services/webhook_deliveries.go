@@ -0,0 +1,71 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"rgs/sqlc"
+	"time"
+)
+
+// DeliveryAttempt is one entry of a webhook's delivery log, as served by
+// GET /webhooks/{id}/attempts and carried by webhook.failed.
+type DeliveryAttempt struct {
+	Attempt        int32             `json:"attempt"`
+	EndpointID     *int32            `json:"endpoint_id,omitempty"`
+	URL            string            `json:"url"`
+	RequestHeaders map[string]string `json:"request_headers"`
+	ResponseStatus *int32            `json:"response_status,omitempty"`
+	ResponseBody   string            `json:"response_body,omitempty"`
+	LatencyMs      int32             `json:"latency_ms"`
+	Error          string            `json:"error,omitempty"`
+	AttemptedAt    time.Time         `json:"attempted_at"`
+}
+
+func newDeliveryAttempt(d sqlc.WebhookDelivery) DeliveryAttempt {
+	a := DeliveryAttempt{
+		Attempt:      d.Attempt,
+		URL:          d.Url,
+		ResponseBody: d.ResponseBody.String,
+		LatencyMs:    d.LatencyMs,
+		Error:        d.Error.String,
+		AttemptedAt:  d.AttemptedAt,
+	}
+	if d.EndpointID.Valid {
+		a.EndpointID = &d.EndpointID.Int32
+	}
+	if d.ResponseStatus.Valid {
+		a.ResponseStatus = &d.ResponseStatus.Int32
+	}
+	_ = json.Unmarshal(d.RequestHeaders, &a.RequestHeaders)
+	return a
+}
+
+// newSentAttempt describes an attempt WebhookClient.Send just made.
+func newSentAttempt(
+	n int32,
+	endpointID sql.NullInt32,
+	url string,
+	attemptedAt time.Time,
+	sent WebhookAttempt,
+	sendErr error,
+) DeliveryAttempt {
+	a := DeliveryAttempt{
+		Attempt:        n,
+		URL:            url,
+		RequestHeaders: sent.RequestHeaders,
+		ResponseBody:   sent.ResponseBody,
+		LatencyMs:      int32(sent.Latency.Milliseconds()),
+		AttemptedAt:    attemptedAt,
+	}
+	if endpointID.Valid {
+		a.EndpointID = &endpointID.Int32
+	}
+	if sent.StatusCode != 0 {
+		status := int32(sent.StatusCode)
+		a.ResponseStatus = &status
+	}
+	if sendErr != nil {
+		a.Error = sendErr.Error()
+	}
+	return a
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"rgs/clock"
+	"rgs/sqlc"
+	"strings"
+	"time"
+)
+
+var ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+
+// DefaultSecretOverlap is how long a rotated-out secret keeps signing
+// when the caller does not say; MaxSecretOverlap bounds what it may say.
+const (
+	DefaultSecretOverlap = 24 * time.Hour
+	MaxSecretOverlap     = 7 * 24 * time.Hour
+)
+
+// DefaultEndpointEventTypes are what an operator's webhook_url received
+// before endpoints existed; the endpoint created with an operator keeps
+// that subscription.
+var DefaultEndpointEventTypes = []string{"bet_settled", "settlement_success"}
+
+type WebhookEndpointRepo interface {
+	CreateWebhookEndpoint(ctx context.Context, arg sqlc.CreateWebhookEndpointParams) (sqlc.WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, arg sqlc.GetWebhookEndpointParams) (sqlc.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context, operatorID int32) ([]sqlc.WebhookEndpoint, error)
+	UpdateWebhookEndpoint(ctx context.Context, arg sqlc.UpdateWebhookEndpointParams) (sqlc.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, arg sqlc.DeleteWebhookEndpointParams) (int64, error)
+	CancelPendingEndpointWebhooks(ctx context.Context, arg sqlc.CancelPendingEndpointWebhooksParams) (int64, error)
+	RotateWebhookEndpointSecret(ctx context.Context, arg sqlc.RotateWebhookEndpointSecretParams) (sqlc.WebhookEndpoint, error)
+}
+
+var _ WebhookEndpointRepo = (*sqlc.Queries)(nil)
+
+// SigningSecrets returns the secrets deliveries to ep are signed with at
+// now: its secret, then the previous one while its overlap lasts.
+func SigningSecrets(ep sqlc.WebhookEndpoint, now time.Time) []string {
+	secrets := []string{ep.Secret}
+	if ep.PreviousSecret.Valid && ep.PreviousSecretExpiresAt.Valid && now.Before(ep.PreviousSecretExpiresAt.Time) {
+		secrets = append(secrets, ep.PreviousSecret.String)
+	}
+	return secrets
+}
+
+// Subscribes reports whether an endpoint subscribed to patterns wants
+// eventType. A pattern is an exact type, "*" for everything, or a prefix
+// wildcard such as "session.*".
+func Subscribes(patterns []string, eventType string) bool {
+	for _, p := range patterns {
+		switch {
+		case p == "*", p == eventType:
+			return true
+		case strings.HasSuffix(p, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookEndpointParams struct {
+	URL        string
+	EventTypes []string
+	Enabled    bool
+	// Ordered delivers one event at a time in enqueue order; a failing
+	// delivery holds back later ones until it succeeds or goes dead.
+	Ordered bool
+}
+
+func (p WebhookEndpointParams) validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidEndpoint)
+	}
+
+	if len(p.EventTypes) == 0 {
+		return fmt.Errorf("%w: event_types is empty", ErrInvalidEndpoint)
+	}
+	for _, t := range p.EventTypes {
+		if t == "*" {
+			continue
+		}
+		base := strings.TrimSuffix(t, ".*")
+		if base == "" || strings.ContainsAny(base, "* \t") {
+			return fmt.Errorf("%w: event type %q", ErrInvalidEndpoint, t)
+		}
+	}
+
+	return nil
+}
+
+type WebhookEndpointService struct {
+	repo       WebhookEndpointRepo
+	tx         Tx[WebhookEndpointRepo]
+	clock      clock.Clock
+	compliance *ComplianceService
+}
+
+func NewWebhookEndpointService(repo WebhookEndpointRepo, tx Tx[WebhookEndpointRepo], clk clock.Clock, comp *ComplianceService) *WebhookEndpointService {
+	return &WebhookEndpointService{repo: repo, tx: tx, clock: clk, compliance: comp}
+}
+
+// Create adds an endpoint with a fresh signing secret, returned only here.
+func (s *WebhookEndpointService) Create(ctx context.Context, operatorID int32, p WebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	if err := p.validate(); err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	secret, err := generateSecureToken()
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	ep, err := s.repo.CreateWebhookEndpoint(ctx, sqlc.CreateWebhookEndpointParams{
+		OperatorID: operatorID,
+		Url:        p.URL,
+		Secret:     secret,
+		EventTypes: p.EventTypes,
+		Enabled:    p.Enabled,
+		Ordered:    p.Ordered,
+	})
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook_endpoint.created", map[string]any{
+		"endpoint_id": ep.ID,
+		"url":         ep.Url,
+		"event_types": ep.EventTypes,
+		"ordered":     ep.Ordered,
+	})
+
+	return ep, nil
+}
+
+func (s *WebhookEndpointService) List(ctx context.Context, operatorID int32) ([]sqlc.WebhookEndpoint, error) {
+	return s.repo.ListWebhookEndpoints(ctx, operatorID)
+}
+
+func (s *WebhookEndpointService) Get(ctx context.Context, operatorID, id int32) (sqlc.WebhookEndpoint, error) {
+	return s.repo.GetWebhookEndpoint(ctx, sqlc.GetWebhookEndpointParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+}
+
+// Update replaces the URL, subscription, enabled state and ordering. Deliveries
+// already queued keep going to the endpoint unless it is disabled.
+func (s *WebhookEndpointService) Update(ctx context.Context, operatorID, id int32, p WebhookEndpointParams) (sqlc.WebhookEndpoint, error) {
+	if err := p.validate(); err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	ep, err := s.repo.UpdateWebhookEndpoint(ctx, sqlc.UpdateWebhookEndpointParams{
+		ID:         id,
+		OperatorID: operatorID,
+		Url:        p.URL,
+		EventTypes: p.EventTypes,
+		Enabled:    p.Enabled,
+		Ordered:    p.Ordered,
+	})
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook_endpoint.updated", map[string]any{
+		"endpoint_id": ep.ID,
+		"url":         ep.Url,
+		"event_types": ep.EventTypes,
+		"enabled":     ep.Enabled,
+		"ordered":     ep.Ordered,
+	})
+
+	return ep, nil
+}
+
+// RotateSecret gives the endpoint a new secret, returned only here. The
+// old one keeps signing alongside it for overlap, replacing any secret
+// still overlapping from an earlier rotation.
+func (s *WebhookEndpointService) RotateSecret(ctx context.Context, operatorID, id int32, overlap time.Duration) (sqlc.WebhookEndpoint, error) {
+	if overlap < 0 || overlap > MaxSecretOverlap {
+		return sqlc.WebhookEndpoint{}, fmt.Errorf("%w: overlap must be between 0 and %s", ErrInvalidEndpoint, MaxSecretOverlap)
+	}
+
+	secret, err := generateSecureToken()
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	expiresAt := s.clock.Now().Add(overlap)
+	ep, err := s.repo.RotateWebhookEndpointSecret(ctx, sqlc.RotateWebhookEndpointSecretParams{
+		ID:                      id,
+		OperatorID:              operatorID,
+		Secret:                  secret,
+		PreviousSecretExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return sqlc.WebhookEndpoint{}, err
+	}
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook_endpoint.secret_rotated", map[string]any{
+		"endpoint_id":                ep.ID,
+		"overlap_seconds":            overlap.Seconds(),
+		"previous_secret_expires_at": expiresAt,
+	})
+
+	return ep, nil
+}
+
+// Delete removes the endpoint and fails its pending deliveries. Deliveries
+// already made keep their attempt log, with no endpoint.
+func (s *WebhookEndpointService) Delete(ctx context.Context, operatorID, id int32) error {
+	var n int64
+	err := s.tx(ctx, func(q WebhookEndpointRepo) error {
+		_, err := q.CancelPendingEndpointWebhooks(ctx, sqlc.CancelPendingEndpointWebhooksParams{
+			EndpointID: sql.NullInt32{Int32: id, Valid: true},
+			OperatorID: operatorID,
+		})
+		if err != nil {
+			return err
+		}
+
+		n, err = q.DeleteWebhookEndpoint(ctx, sqlc.DeleteWebhookEndpointParams{
+			ID:         id,
+			OperatorID: operatorID,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook_endpoint.deleted", map[string]any{
+		"endpoint_id": id,
+	})
+
+	return nil
+}
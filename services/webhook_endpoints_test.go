@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"rgs/memstore"
+	"rgs/sqlc"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribes(t *testing.T) {
+	tests := []struct {
+		patterns  []string
+		eventType string
+		want      bool
+	}{
+		{[]string{"bet_settled"}, "bet_settled", true},
+		{[]string{"bet_settled"}, "settlement_success", false},
+		{[]string{"*"}, "session.launched", true},
+		{[]string{"session.*"}, "session.launched", true},
+		{[]string{"session.*"}, "sessions.launched", false},
+		{[]string{"session.*"}, "session", false},
+		{nil, "bet_settled", false},
+	}
+
+	for _, tt := range tests {
+		if got := Subscribes(tt.patterns, tt.eventType); got != tt.want {
+			t.Errorf("Subscribes(%v, %q) = %v, want %v", tt.patterns, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func newTestEndpoint(t *testing.T, env *testEnv, operatorID int32, url string, enabled bool, types ...string) sqlc.WebhookEndpoint {
+	t.Helper()
+
+	ep, err := env.store.CreateWebhookEndpoint(env.ctx, sqlc.CreateWebhookEndpointParams{
+		OperatorID: operatorID,
+		Url:        url,
+		Secret:     "epsec",
+		EventTypes: types,
+		Enabled:    enabled,
+	})
+	if err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	return ep
+}
+
+func TestEventDispatcherFansOutToSubscribedEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+
+	settled := newTestEndpoint(t, env, op.ID, "http://a.example", true, "bet_settled")
+	all := newTestEndpoint(t, env, op.ID, "http://b.example", true, "*")
+	newTestEndpoint(t, env, op.ID, "http://c.example", true, "session.*")
+	newTestEndpoint(t, env, op.ID, "http://d.example", false, "*")
+
+	err := env.events.Record(env.ctx, env.store, op.ID, "settlement.won", BetSettled{BetID: 1})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	env.events.dispatchPending()
+
+	webhooks, err := env.store.ListWebhooksByOperator(env.ctx, op.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+
+	got := map[int32]string{}
+	for _, w := range webhooks {
+		got[w.EndpointID.Int32] = w.EventType
+	}
+	want := map[int32]string{settled.ID: "bet_settled", all.ID: "bet_settled"}
+	if len(got) != len(want) || got[settled.ID] != want[settled.ID] || got[all.ID] != want[all.ID] {
+		t.Fatalf("deliveries by endpoint = %v, want %v", got, want)
+	}
+}
+
+func TestWebhookWorkerDeliversToEndpoint(t *testing.T) {
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	operatorURL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery went to the operator webhook_url")
+	}))
+	defer operatorURL.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, operatorURL.URL)
+	ep := newTestEndpoint(t, env, op.ID, receiver.URL, true, "bet_settled")
+
+	e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+		OperatorID: op.ID,
+		EventType:  "bet_settled",
+		Payload:    json.RawMessage(`{"bet_id":1}`),
+		EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+		Now:        env.clock.Now(),
+	})
+	if err != nil {
+		t.Fatalf("insert webhook: %v", err)
+	}
+
+	newTestWebhookWorker(env).processPending(context.Background())
+
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+		t.Fatalf("status = %q, want completed", got.Status)
+	}
+	if string(body) != `{"bet_id":1}` {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestWebhookWorkerFailsDisabledEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	ep := newTestEndpoint(t, env, op.ID, "http://a.example", true, "bet_settled")
+
+	e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+		OperatorID: op.ID,
+		EventType:  "bet_settled",
+		Payload:    json.RawMessage(`{}`),
+		EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+		Now:        env.clock.Now(),
+	})
+	if err != nil {
+		t.Fatalf("insert webhook: %v", err)
+	}
+
+	_, err = env.store.UpdateWebhookEndpoint(env.ctx, sqlc.UpdateWebhookEndpointParams{
+		ID:         ep.ID,
+		OperatorID: op.ID,
+		Url:        ep.Url,
+		EventTypes: ep.EventTypes,
+		Enabled:    false,
+	})
+	if err != nil {
+		t.Fatalf("disable endpoint: %v", err)
+	}
+
+	newTestWebhookWorker(env).processPending(context.Background())
+
+	got := getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Status != "failed" || got.ErrorMessage.String != "webhook endpoint disabled" {
+		t.Fatalf("status %q error %q", got.Status, got.ErrorMessage.String)
+	}
+}
+
+func TestWebhookEndpointServiceValidatesAndScopes(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	env.clock.Advance(time.Second)
+	other := env.operator(t, "")
+	svc := NewWebhookEndpointService(env.store, memstore.Tx[WebhookEndpointRepo](env.store), env.clock, NewComplianceService(env.store))
+
+	_, err := svc.Create(env.ctx, op.ID, WebhookEndpointParams{URL: "ftp://x", EventTypes: []string{"*"}})
+	if !errors.Is(err, ErrInvalidEndpoint) {
+		t.Fatalf("bad url: err = %v", err)
+	}
+	_, err = svc.Create(env.ctx, op.ID, WebhookEndpointParams{URL: "https://x.example"})
+	if !errors.Is(err, ErrInvalidEndpoint) {
+		t.Fatalf("no event types: err = %v", err)
+	}
+
+	ep, err := svc.Create(env.ctx, op.ID, WebhookEndpointParams{
+		URL:        "https://x.example",
+		EventTypes: []string{"session.*"},
+		Enabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if ep.Secret == "" {
+		t.Fatal("endpoint has no secret")
+	}
+
+	if _, err := svc.Get(env.ctx, other.ID, ep.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("get from another operator: err = %v", err)
+	}
+	if err := svc.Delete(env.ctx, other.ID, ep.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("delete from another operator: err = %v", err)
+	}
+	if err := svc.Delete(env.ctx, op.ID, ep.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestDeleteEndpointKeepsDeliveryHistory(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	ep := newTestEndpoint(t, env, op.ID, receiver.URL, true, "bet_settled")
+	svc := NewWebhookEndpointService(env.store, memstore.Tx[WebhookEndpointRepo](env.store), env.clock, NewComplianceService(env.store))
+
+	insert := func() sqlc.WebhookEvent {
+		e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+			OperatorID: op.ID,
+			EventType:  "bet_settled",
+			Payload:    json.RawMessage(`{}`),
+			EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+			Now:        env.clock.Now(),
+		})
+		if err != nil {
+			t.Fatalf("insert webhook: %v", err)
+		}
+		return e
+	}
+
+	delivered := insert()
+	newTestWebhookWorker(env).processPending(context.Background())
+	queued := insert()
+
+	if err := svc.Delete(env.ctx, op.ID, ep.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	got := getWebhook(t, env, op.ID, delivered.ID)
+	if got.Status != "completed" || got.EndpointID.Valid {
+		t.Fatalf("delivered: status %q endpoint %v", got.Status, got.EndpointID)
+	}
+	attempts, err := env.store.ListWebhookDeliveries(env.ctx, sqlc.ListWebhookDeliveriesParams{
+		WebhookEventID: delivered.ID,
+		OperatorID:     op.ID,
+	})
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].EndpointID.Valid {
+		t.Fatalf("attempts = %+v, want one with no endpoint", attempts)
+	}
+
+	got = getWebhook(t, env, op.ID, queued.ID)
+	if got.Status != "failed" || got.ErrorMessage.String != "webhook endpoint deleted" {
+		t.Fatalf("queued: status %q error %q", got.Status, got.ErrorMessage.String)
+	}
+}
+
+func TestRotatedSecretSignsUntilOverlapEnds(t *testing.T) {
+	var signature string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-RGS-Signature")
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	svc := NewWebhookEndpointService(env.store, memstore.Tx[WebhookEndpointRepo](env.store), env.clock, NewComplianceService(env.store))
+
+	ep, err := svc.Create(env.ctx, op.ID, WebhookEndpointParams{URL: receiver.URL, EventTypes: []string{"*"}, Enabled: true})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	old := ep.Secret
+
+	if _, err := svc.RotateSecret(env.ctx, op.ID, ep.ID, MaxSecretOverlap+time.Second); !errors.Is(err, ErrInvalidEndpoint) {
+		t.Fatalf("overlap past the max: err = %v", err)
+	}
+
+	rotated, err := svc.RotateSecret(env.ctx, op.ID, ep.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if rotated.Secret == old {
+		t.Fatal("secret did not change")
+	}
+
+	deliver := func() []string {
+		t.Helper()
+		e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+			OperatorID: op.ID,
+			EventType:  "bet_settled",
+			Payload:    json.RawMessage(`{}`),
+			EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+			Now:        env.clock.Now(),
+		})
+		if err != nil {
+			t.Fatalf("insert webhook: %v", err)
+		}
+		newTestWebhookWorker(env).processPending(context.Background())
+		if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+			t.Fatalf("status = %q", got.Status)
+		}
+		return strings.Split(signature, ",")
+	}
+
+	timestamp := strconv.FormatInt(env.clock.Now().Unix(), 10)
+	sigs := deliver()
+	if len(sigs) != 2 || sigs[0] != "v1="+sign(rotated.Secret, timestamp, "{}") || sigs[1] != "v1="+sign(old, timestamp, "{}") {
+		t.Fatalf("during overlap X-RGS-Signature = %q, want new then old", signature)
+	}
+
+	env.clock.Advance(time.Hour)
+	timestamp = strconv.FormatInt(env.clock.Now().Unix(), 10)
+	if sigs := deliver(); len(sigs) != 1 || sigs[0] != "v1="+sign(rotated.Secret, timestamp, "{}") {
+		t.Fatalf("after overlap X-RGS-Signature = %q, want the new secret only", signature)
+	}
+
+	logs, err := env.store.ListAuditLogsByOperator(env.ctx, sqlc.ListAuditLogsByOperatorParams{OperatorID: op.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("audit logs: %v", err)
+	}
+	var rotations int
+	for _, l := range logs {
+		if l.Action == "webhook_endpoint.secret_rotated" {
+			rotations++
+			if strings.Contains(string(l.Details), rotated.Secret) || strings.Contains(string(l.Details), old) {
+				t.Fatalf("audit log leaks a secret: %s", l.Details)
+			}
+		}
+	}
+	if rotations != 1 {
+		t.Fatalf("got %d rotation audit entries, want 1", rotations)
+	}
+}
+
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"rgs/sqlc"
+	"rgs/webhooksdk"
+)
+
+// WebhookEnvelope is the body of every webhook.
+type WebhookEnvelope = webhooksdk.Envelope
+
+func newWebhookEnvelope(e sqlc.Event, webhookType string) WebhookEnvelope {
+	return WebhookEnvelope{
+		ID:         e.EventID.String(),
+		Type:       webhookType,
+		Version:    e.Version,
+		CreatedAt:  e.CreatedAt.UTC(),
+		OperatorID: e.OperatorID,
+		Data:       e.Payload,
+	}
+}
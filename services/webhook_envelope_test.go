@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"rgs/webhooksdk"
+	"testing"
+)
+
+func TestWebhookEnvelopeIsSharedAcrossEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	newTestEndpoint(t, env, op.ID, "http://a.example", true, "bet_settled")
+	newTestEndpoint(t, env, op.ID, "http://b.example", true, "*")
+
+	err := env.events.Record(env.ctx, env.store, op.ID, "settlement.lost", BetSettled{
+		BetID:    7,
+		RoundID:  3,
+		PlayerID: 2,
+		Status:   "lost",
+	})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	env.events.dispatchPending()
+
+	event := env.store.Events()[0]
+	webhooks, err := env.store.ListWebhooksByOperator(env.ctx, op.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+	if len(webhooks) != 2 {
+		t.Fatalf("got %d webhooks, want 2", len(webhooks))
+	}
+	if !bytes.Equal(webhooks[0].Payload, webhooks[1].Payload) {
+		t.Fatalf("endpoints got different bodies:\n%s\n%s", webhooks[0].Payload, webhooks[1].Payload)
+	}
+
+	var envelope struct {
+		WebhookEnvelope
+		Data BetSettled `json:"data"`
+	}
+	if err := json.Unmarshal(webhooks[0].Payload, &envelope); err != nil {
+		t.Fatalf("decode %s: %v", webhooks[0].Payload, err)
+	}
+
+	if envelope.ID != event.EventID.String() {
+		t.Fatalf("id = %q, want event id %s", envelope.ID, event.EventID)
+	}
+	if envelope.Type != "bet_settled" || envelope.Version != 1 || envelope.OperatorID != op.ID {
+		t.Fatalf("envelope = %+v", envelope.WebhookEnvelope)
+	}
+	if !envelope.CreatedAt.Equal(env.clock.Now()) {
+		t.Fatalf("created_at = %v, want %v", envelope.CreatedAt, env.clock.Now())
+	}
+	if envelope.Data.BetID != 7 || envelope.Data.RoundID != 3 || envelope.Data.Status != "lost" {
+		t.Fatalf("data = %+v", envelope.Data)
+	}
+}
+
+// Every webhook the dispatcher can produce must decode with the SDK
+// receivers use.
+func TestWebhooksDecodeWithSDK(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	newTestEndpoint(t, env, op.ID, "http://a.example", true, "*")
+
+	events := map[string]EventData{
+		"round.finished":     RoundFinished{RoundID: 1},
+		"wallet.debit":       WalletDebited{PlayerID: 1},
+		"settlement.won":     BetSettled{BetID: 1},
+		"settlement.success": SettlementSucceeded{BetID: 1},
+		"settlement.failed":  SettlementFailed{BetID: 1},
+		"settlement.dead":    SettlementDead{BetID: 1},
+		"session.launched":   SessionLaunched{PlayerID: 1},
+		"session.verified":   SessionVerified{PlayerID: 1},
+		"session.revoked":    SessionRevoked{},
+	}
+	for eventType, data := range events {
+		if err := env.events.Record(env.ctx, env.store, op.ID, eventType, data); err != nil {
+			t.Fatalf("record %s: %v", eventType, err)
+		}
+	}
+	env.events.dispatchPending()
+
+	webhooks, err := env.store.ListWebhooksByOperator(env.ctx, op.ID)
+	if err != nil {
+		t.Fatalf("list webhooks: %v", err)
+	}
+	if len(webhooks) != len(events) {
+		t.Fatalf("got %d webhooks, want %d", len(webhooks), len(events))
+	}
+
+	for _, w := range webhooks {
+		var envelope webhooksdk.Envelope
+		if err := json.Unmarshal(w.Payload, &envelope); err != nil {
+			t.Fatalf("unmarshal %s: %v", w.Payload, err)
+		}
+		if _, err := envelope.Decode(); err != nil {
+			t.Errorf("%s: %v", envelope.Type, err)
+		}
+	}
+}
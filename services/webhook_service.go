@@ -2,24 +2,79 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"rgs/clock"
 	"rgs/sqlc"
+	"rgs/webhooksdk"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRetryPolicy = errors.New("invalid webhook retry policy")
+	ErrInvalidReplay      = errors.New("invalid webhook replay")
+	ErrWebhookInFlight    = errors.New("webhook is pending or being delivered")
 )
 
+// maxWebhookAge bounds how long an operator may keep webhooks queued.
+const maxWebhookAge = 30 * 24 * time.Hour
+
+// maxReplayWindow bounds the created_at range of one replay.
+const maxReplayWindow = 7 * 24 * time.Hour
+
+// replayableStatuses are the statuses a replay may reset; pending and
+// processing events are already on their way.
+var replayableStatuses = []string{"failed", "dead", "completed"}
+
 type WebhookService struct {
-	queries *sqlc.Queries
+	repo       WebhookRepo
+	clock      clock.Clock
+	compliance *ComplianceService
+	client     *WebhookClient
+	defaults   RetryPolicy
 }
 
-func NewWebhookService(q *sqlc.Queries) *WebhookService {
-	return &WebhookService{queries: q}
+func NewWebhookService(repo WebhookRepo, clk clock.Clock, comp *ComplianceService, defaults RetryPolicy) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		clock:      clk,
+		compliance: comp,
+		client:     NewWebhookClient(clk, http.DefaultMaxIdleConnsPerHost),
+		defaults:   defaults,
+	}
 }
 
-func (s *WebhookService) RetryWebhook(ctx context.Context, id int32) error {
-	_, err := s.queries.GetWebhookEventByID(ctx, id)
+// RetryWebhook requeues one of the operator's failed, dead or completed
+// webhooks; a pending or processing one is ErrWebhookInFlight. Another
+// operator's id is sql.ErrNoRows, as if it did not exist.
+func (s *WebhookService) RetryWebhook(ctx context.Context, operatorID, id int32) error {
+	n, err := s.repo.ResetWebhookForRetry(ctx, sqlc.ResetWebhookForRetryParams{
+		ID:         id,
+		OperatorID: operatorID,
+		Now:        s.clock.Now(),
+	})
 	if err != nil {
 		return err
 	}
+	if n > 0 {
+		return nil
+	}
 
-	return s.queries.ResetWebhookForRetry(ctx, id)
+	_, err = s.repo.GetWebhookEventByID(ctx, sqlc.GetWebhookEventByIDParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+	if err != nil {
+		return err
+	}
+	return ErrWebhookInFlight
 }
 
 func (s *WebhookService) ListWebhooks(
@@ -28,11 +83,199 @@ func (s *WebhookService) ListWebhooks(
 	status *string,
 ) ([]sqlc.WebhookEvent, error) {
 	if status == nil {
-		return s.queries.ListWebhooksByOperator(ctx, operatorID)
+		return s.repo.ListWebhooksByOperator(ctx, operatorID)
 	}
 
-	return s.queries.ListWebhooksByOperatorStatus(ctx, sqlc.ListWebhooksByOperatorStatusParams{
+	return s.repo.ListWebhooksByOperatorStatus(ctx, sqlc.ListWebhooksByOperatorStatusParams{
 		OperatorID: operatorID,
 		Status:     *status,
 	})
 }
+
+// Attempts returns the delivery log of one of the operator's webhooks,
+// oldest attempt first.
+func (s *WebhookService) Attempts(ctx context.Context, operatorID, id int32) ([]DeliveryAttempt, error) {
+	_, err := s.repo.GetWebhookEventByID(ctx, sqlc.GetWebhookEventByIDParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		WebhookEventID: id,
+		OperatorID:     operatorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]DeliveryAttempt, 0, len(rows))
+	for _, d := range rows {
+		out = append(out, newDeliveryAttempt(d))
+	}
+	return out, nil
+}
+
+// TestEndpoint sends a signed ping to one of the operator's endpoints,
+// enabled or not, and returns what the receiver answered. Nothing is
+// queued, logged as a delivery or retried.
+func (s *WebhookService) TestEndpoint(ctx context.Context, operatorID, endpointID int32) (DeliveryAttempt, error) {
+	ep, err := s.repo.GetWebhookEndpoint(ctx, sqlc.GetWebhookEndpointParams{
+		ID:         endpointID,
+		OperatorID: operatorID,
+	})
+	if err != nil {
+		return DeliveryAttempt{}, err
+	}
+
+	data, err := json.Marshal(Ping{EndpointID: ep.ID})
+	if err != nil {
+		return DeliveryAttempt{}, err
+	}
+
+	now := s.clock.Now()
+	envelope := WebhookEnvelope{
+		ID:         uuid.NewString(),
+		Type:       webhooksdk.TypePing,
+		Version:    Ping{}.Version(),
+		CreatedAt:  now.UTC(),
+		OperatorID: operatorID,
+		Data:       data,
+	}
+
+	sent, sendErr := s.client.Send(ctx, ep.Url, SigningSecrets(ep, now), envelope)
+	attempt := newSentAttempt(1, sql.NullInt32{Int32: ep.ID, Valid: true}, ep.Url, now, sent, sendErr)
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook.test", map[string]any{
+		"endpoint_id":     ep.ID,
+		"url":             ep.Url,
+		"response_status": attempt.ResponseStatus,
+		"error":           attempt.Error,
+	})
+
+	return attempt, nil
+}
+
+// WebhookReplayFilter selects the webhooks Replay re-queues: those created
+// in [From, To), with Status (failed and dead when empty) and, if set,
+// EventType.
+type WebhookReplayFilter struct {
+	Status    string
+	EventType string
+	From      time.Time
+	To        time.Time
+}
+
+// Replay re-queues every matching webhook for immediate delivery with its
+// attempts reset, like RetryWebhook does for one, and returns their ids.
+func (s *WebhookService) Replay(ctx context.Context, operatorID int32, f WebhookReplayFilter) ([]int32, error) {
+	statuses := []string{"failed", "dead"}
+	if f.Status != "" {
+		if !slices.Contains(replayableStatuses, f.Status) {
+			return nil, fmt.Errorf("%w: status must be one of %s", ErrInvalidReplay, strings.Join(replayableStatuses, ", "))
+		}
+		statuses = []string{f.Status}
+	}
+	switch {
+	case f.From.IsZero() || f.To.IsZero():
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidReplay)
+	case !f.To.After(f.From):
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidReplay)
+	case f.To.Sub(f.From) > maxReplayWindow:
+		return nil, fmt.Errorf("%w: from and to must be at most %s apart", ErrInvalidReplay, maxReplayWindow)
+	}
+
+	ids, err := s.repo.ReplayWebhookEvents(ctx, sqlc.ReplayWebhookEventsParams{
+		Now:         s.clock.Now(),
+		OperatorID:  operatorID,
+		Statuses:    statuses,
+		EventType:   sql.NullString{String: f.EventType, Valid: f.EventType != ""},
+		CreatedFrom: f.From,
+		CreatedTo:   f.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.compliance.Log(ctx, operatorID, nil, "webhook.replay", map[string]any{
+		"statuses":   statuses,
+		"event_type": f.EventType,
+		"from":       f.From,
+		"to":         f.To,
+		"replayed":   len(ids),
+	})
+
+	return ids, nil
+}
+
+// RetryPolicy returns the operator's policy, or the defaults when it has
+// not set one.
+func (s *WebhookService) RetryPolicy(ctx context.Context, operatorID int32) (RetryPolicy, error) {
+	return webhookRetryPolicy(ctx, s.repo, operatorID, s.defaults)
+}
+
+func (s *WebhookService) SetRetryPolicy(ctx context.Context, operatorID int32, p RetryPolicy) (RetryPolicy, error) {
+	if err := validateWebhookRetryPolicy(p); err != nil {
+		return RetryPolicy{}, err
+	}
+
+	row, err := s.repo.UpsertWebhookRetryPolicy(ctx, sqlc.UpsertWebhookRetryPolicyParams{
+		OperatorID:       operatorID,
+		MaxAttempts:      p.MaxAttempts,
+		MaxAgeSeconds:    int32(p.MaxAge / time.Second),
+		BaseDelaySeconds: int32(p.BaseDelay / time.Second),
+		MaxDelaySeconds:  int32(p.MaxDelay / time.Second),
+		Jitter:           p.Jitter,
+	})
+	if err != nil {
+		return RetryPolicy{}, err
+	}
+	return retryPolicyFromRow(row), nil
+}
+
+// ResetRetryPolicy puts the operator back on the defaults.
+func (s *WebhookService) ResetRetryPolicy(ctx context.Context, operatorID int32) (RetryPolicy, error) {
+	if err := s.repo.DeleteWebhookRetryPolicy(ctx, operatorID); err != nil {
+		return RetryPolicy{}, err
+	}
+	return s.defaults, nil
+}
+
+func validateWebhookRetryPolicy(p RetryPolicy) error {
+	switch {
+	case p.MaxAttempts < 1:
+		return fmt.Errorf("%w: max_attempts must be at least 1", ErrInvalidRetryPolicy)
+	case p.MaxAge < time.Minute || p.MaxAge > maxWebhookAge:
+		return fmt.Errorf("%w: max_age must be between 1m and %s", ErrInvalidRetryPolicy, maxWebhookAge)
+	case p.BaseDelay < time.Second:
+		return fmt.Errorf("%w: base_delay must be at least 1s", ErrInvalidRetryPolicy)
+	case p.MaxDelay < p.BaseDelay || p.MaxDelay > p.MaxAge:
+		return fmt.Errorf("%w: max_delay must be between base_delay and max_age", ErrInvalidRetryPolicy)
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("%w: jitter must be between 0 and 1", ErrInvalidRetryPolicy)
+	}
+	return nil
+}
+
+func webhookRetryPolicy(ctx context.Context, repo WebhookRepo, operatorID int32, defaults RetryPolicy) (RetryPolicy, error) {
+	row, err := repo.GetWebhookRetryPolicy(ctx, operatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaults, nil
+	}
+	if err != nil {
+		return RetryPolicy{}, err
+	}
+	return retryPolicyFromRow(row), nil
+}
+
+func retryPolicyFromRow(row sqlc.WebhookRetryPolicy) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: row.MaxAttempts,
+		MaxAge:      time.Duration(row.MaxAgeSeconds) * time.Second,
+		BaseDelay:   time.Duration(row.BaseDelaySeconds) * time.Second,
+		MaxDelay:    time.Duration(row.MaxDelaySeconds) * time.Second,
+		Jitter:      row.Jitter,
+	}
+}
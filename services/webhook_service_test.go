@@ -0,0 +1,237 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rgs/sqlc"
+	"rgs/webhooksdk"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWebhookServiceRetryPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+
+	got, err := svc.RetryPolicy(env.ctx, op.ID)
+	if err != nil || got != DefaultWebhookRetryPolicy {
+		t.Fatalf("unset policy = %+v, %v, want defaults", got, err)
+	}
+
+	custom := RetryPolicy{
+		MaxAttempts: 50,
+		MaxAge:      72 * time.Hour,
+		BaseDelay:   10 * time.Second,
+		MaxDelay:    2 * time.Hour,
+		Jitter:      0.5,
+	}
+	if _, err := svc.SetRetryPolicy(env.ctx, op.ID, custom); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got, _ := svc.RetryPolicy(env.ctx, op.ID); got != custom {
+		t.Fatalf("policy = %+v, want %+v", got, custom)
+	}
+
+	invalid := custom
+	invalid.Jitter = 1.5
+	if _, err := svc.SetRetryPolicy(env.ctx, op.ID, invalid); !errors.Is(err, ErrInvalidRetryPolicy) {
+		t.Fatalf("jitter 1.5: err = %v", err)
+	}
+	invalid = custom
+	invalid.MaxAge = 60 * 24 * time.Hour
+	if _, err := svc.SetRetryPolicy(env.ctx, op.ID, invalid); !errors.Is(err, ErrInvalidRetryPolicy) {
+		t.Fatalf("max age 60d: err = %v", err)
+	}
+
+	if _, err := svc.ResetRetryPolicy(env.ctx, op.ID); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if got, _ := svc.RetryPolicy(env.ctx, op.ID); got != DefaultWebhookRetryPolicy {
+		t.Fatalf("after reset = %+v, want defaults", got)
+	}
+}
+
+func TestWebhookServiceTestEndpointSendsSignedPing(t *testing.T) {
+	var envelope *webhooksdk.Envelope
+	var verifyErr error
+	env := newTestEnv(t)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := webhooksdk.Verifier{Secrets: []string{"epsec"}, Now: env.clock.Now}
+		envelope, verifyErr = v.Verify(r)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer receiver.Close()
+
+	op := env.operator(t, "")
+	ep := newTestEndpoint(t, env, op.ID, receiver.URL, false, "bet_settled")
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+
+	attempt, err := svc.TestEndpoint(env.ctx, op.ID, ep.ID)
+	if err != nil {
+		t.Fatalf("test endpoint: %v", err)
+	}
+	if verifyErr != nil {
+		t.Fatalf("receiver could not verify the ping: %v", verifyErr)
+	}
+	if attempt.ResponseStatus == nil || *attempt.ResponseStatus != http.StatusAccepted || attempt.ResponseBody != "pong" {
+		t.Fatalf("attempt = %+v", attempt)
+	}
+
+	p, err := envelope.Decode()
+	if ping, ok := p.(*webhooksdk.Ping); err != nil || !ok || ping.EndpointID != ep.ID {
+		t.Fatalf("payload = %#v, %v", p, err)
+	}
+
+	if webhooks, _ := env.store.ListWebhooksByOperator(env.ctx, op.ID); len(webhooks) != 0 {
+		t.Fatalf("ping was queued: %+v", webhooks)
+	}
+	if n := countAudit(t, env, op.ID, "webhook.test"); n != 1 {
+		t.Fatalf("got %d webhook.test audit entries, want 1", n)
+	}
+
+	env.clock.Advance(time.Second)
+	other := env.operator(t, "")
+	if _, err := svc.TestEndpoint(env.ctx, other.ID, ep.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("another operator's endpoint: err = %v", err)
+	}
+}
+
+func TestWebhookServiceReplay(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+	from := env.clock.Now()
+
+	dead := enqueueWebhook(t, env, op.ID)
+	claimWebhook(t, env, dead)
+	_, _ = env.store.MarkWebhookDead(env.ctx, sqlc.MarkWebhookDeadParams{ID: dead.ID, Retries: 25, WorkerID: "claimer"})
+	failed := enqueueWebhook(t, env, op.ID)
+	claimWebhook(t, env, failed)
+	_, _ = env.store.MarkWebhookFailed(env.ctx, sqlc.MarkWebhookFailedParams{ID: failed.ID, WorkerID: "claimer"})
+	completed := enqueueWebhook(t, env, op.ID)
+	claimWebhook(t, env, completed)
+	_, _ = env.store.MarkWebhookCompleted(env.ctx, sqlc.MarkWebhookCompletedParams{ID: completed.ID, WorkerID: "claimer"})
+	// Waiting on a retry, so it is not due when the later ones are claimed.
+	pending := enqueueWebhook(t, env, op.ID)
+	claimWebhook(t, env, pending)
+	_, _ = env.store.UpdateWebhookRetry(env.ctx, sqlc.UpdateWebhookRetryParams{
+		ID: pending.ID, NextRetryAt: from.Add(2 * time.Hour), WorkerID: "claimer",
+	})
+
+	env.clock.Advance(time.Hour)
+	later := enqueueWebhook(t, env, op.ID)
+	claimWebhook(t, env, later)
+	_, _ = env.store.MarkWebhookDead(env.ctx, sqlc.MarkWebhookDeadParams{ID: later.ID, WorkerID: "claimer"})
+
+	env.clock.Advance(time.Second)
+	other := env.operator(t, "")
+	foreign := enqueueWebhook(t, env, other.ID)
+	claimWebhook(t, env, foreign)
+	_, _ = env.store.MarkWebhookDead(env.ctx, sqlc.MarkWebhookDeadParams{ID: foreign.ID, WorkerID: "claimer"})
+
+	_, err := svc.Replay(env.ctx, op.ID, WebhookReplayFilter{Status: "pending", From: from, To: from.Add(time.Hour)})
+	if !errors.Is(err, ErrInvalidReplay) {
+		t.Fatalf("pending status: err = %v", err)
+	}
+	_, err = svc.Replay(env.ctx, op.ID, WebhookReplayFilter{From: from, To: from.Add(maxReplayWindow + time.Second)})
+	if !errors.Is(err, ErrInvalidReplay) {
+		t.Fatalf("window too wide: err = %v", err)
+	}
+
+	ids, err := svc.Replay(env.ctx, op.ID, WebhookReplayFilter{EventType: "bet_settled", From: from, To: from.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !slices.Equal(ids, []int32{dead.ID, failed.ID}) {
+		t.Fatalf("replayed %v, want dead %d and failed %d", ids, dead.ID, failed.ID)
+	}
+	for _, id := range ids {
+		got := getWebhook(t, env, op.ID, id)
+		if got.Status != "pending" || got.Retries != 0 || !got.RequeuedAt.Time.Equal(env.clock.Now()) {
+			t.Fatalf("replayed webhook = %+v", got)
+		}
+	}
+	untouched := []struct {
+		e    sqlc.WebhookEvent
+		want string
+	}{{completed, "completed"}, {pending, "pending"}, {later, "dead"}, {foreign, "dead"}}
+	for _, u := range untouched {
+		if got := getWebhook(t, env, u.e.OperatorID, u.e.ID); got.Status != u.want || got.RequeuedAt.Valid {
+			t.Fatalf("webhook %d is %q requeued %v, want untouched %q", u.e.ID, got.Status, got.RequeuedAt, u.want)
+		}
+	}
+	if n := countAudit(t, env, op.ID, "webhook.replay"); n != 1 {
+		t.Fatalf("got %d webhook.replay audit entries, want 1", n)
+	}
+}
+
+func TestReplayedWebhookGetsAFreshMaxAge(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+	e := enqueueWebhook(t, env, op.ID)
+
+	env.clock.Advance(DefaultWebhookRetryPolicy.MaxAge + time.Hour)
+	newTestWebhookWorker(env).processPending(env.ctx)
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "dead" {
+		t.Fatalf("status = %q, want dead past max age", got.Status)
+	}
+
+	ids, err := svc.Replay(env.ctx, op.ID, WebhookReplayFilter{Status: "dead", From: e.CreatedAt, To: e.CreatedAt.Add(time.Second)})
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("replay: %v, %v", ids, err)
+	}
+	newTestWebhookWorker(env).processPending(env.ctx)
+
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+		t.Fatalf("replayed webhook is %q, want completed", got.Status)
+	}
+}
+
+func TestRetryRejectsAnInFlightWebhook(t *testing.T) {
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+
+	e := enqueueWebhook(t, env, op.ID)
+	if err := svc.RetryWebhook(env.ctx, op.ID, e.ID); !errors.Is(err, ErrWebhookInFlight) {
+		t.Fatalf("retry pending: err = %v, want ErrWebhookInFlight", err)
+	}
+
+	claimWebhook(t, env, e)
+	if err := svc.RetryWebhook(env.ctx, op.ID, e.ID); !errors.Is(err, ErrWebhookInFlight) {
+		t.Fatalf("retry processing: err = %v, want ErrWebhookInFlight", err)
+	}
+	got := getWebhook(t, env, op.ID, e.ID)
+	if got.Status != "processing" || got.LockedBy.String != "claimer" {
+		t.Fatalf("status %q locked by %q after retry, want processing by claimer", got.Status, got.LockedBy.String)
+	}
+
+	if err := svc.RetryWebhook(env.ctx, op.ID, e.ID+1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("retry missing: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func countAudit(t *testing.T, env *testEnv, operatorID int32, action string) int {
+	t.Helper()
+
+	logs, err := env.store.ListAuditLogsByOperator(env.ctx, sqlc.ListAuditLogsByOperatorParams{OperatorID: operatorID, Limit: 100})
+	if err != nil {
+		t.Fatalf("audit logs: %v", err)
+	}
+	var n int
+	for _, l := range logs {
+		if l.Action == action {
+			n++
+		}
+	}
+	return n
+}
@@ -3,8 +3,14 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"rgs/clock"
 	"rgs/observability"
 	"rgs/sqlc"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,126 +18,403 @@ import (
 )
 
 type WebhookWorker struct {
-	queries *sqlc.Queries
-	bus     *EventBus
+	repo     WebhookRepo
+	clock    clock.Clock
+	bus      *EventBus
+	id       string
+	policy   RetryPolicy
+	limits   WebhookConcurrency
+	interval time.Duration
+	hb       *Heartbeat
+	rand     func() float64
+	client   *WebhookClient
+
+	mu         sync.Mutex
+	inFlight   int
+	byOperator map[int32]int
+	slots      map[int32]chan struct{}
+	deliveries sync.WaitGroup
+}
+
+// WebhookConcurrency bounds the deliveries a worker has in flight: Total
+// across operators and PerOperator for each one, so a slow receiver only
+// holds up its own operator's deliveries.
+type WebhookConcurrency struct {
+	Total       int
+	PerOperator int
 }
 
-func NewWebhookWorker(q *sqlc.Queries, bus *EventBus) *WebhookWorker {
+var DefaultWebhookConcurrency = WebhookConcurrency{Total: 16, PerOperator: 4}
+
+// webhookLease bounds how long a claimed event stays in processing before
+// another replica may pick it up again.
+const webhookLease = 5 * time.Minute
+
+func NewWebhookWorker(
+	repo WebhookRepo,
+	clk clock.Clock,
+	bus *EventBus,
+	workerID string,
+	policy RetryPolicy,
+	limits WebhookConcurrency,
+	interval time.Duration,
+	hb *Heartbeat,
+) *WebhookWorker {
 	return &WebhookWorker{
-		queries: q,
-		bus:     bus,
+		repo:       repo,
+		clock:      clk,
+		bus:        bus,
+		id:         workerID,
+		policy:     policy,
+		limits:     limits,
+		interval:   interval,
+		hb:         hb,
+		rand:       rand.Float64,
+		client:     NewWebhookClient(clk, limits.PerOperator),
+		byOperator: map[int32]int{},
+		slots:      map[int32]chan struct{}{},
 	}
 }
 
-func (w *WebhookWorker) Start() {
-	go func() {
-		for {
-			w.processPending()
-			time.Sleep(3 * time.Second)
+// Run claims due events every interval, as many as there are free slots,
+// and delivers them in the background. Deliveries in flight are finished
+// before Run returns; claimed ones not yet started are left to their
+// lease.
+func (w *WebhookWorker) Run(ctx context.Context) {
+	defer w.deliveries.Wait()
+
+	for {
+		w.dispatch(ctx)
+		w.recordQueueDepth(ctx)
+		w.hb.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
 		}
-	}()
+	}
 }
 
-func (w *WebhookWorker) processPending() {
-	ctx := context.Background()
+func (w *WebhookWorker) dispatch(runCtx context.Context) {
+	ctx := context.WithoutCancel(runCtx)
+
+	w.mu.Lock()
+	free := w.limits.Total - w.inFlight
+	var operators, inFlight []int32
+	for operatorID, n := range w.byOperator {
+		operators = append(operators, operatorID)
+		inFlight = append(inFlight, int32(n))
+	}
+	w.mu.Unlock()
 
-	events, err := w.queries.GetPendingWebhookEvents(ctx)
+	if free <= 0 {
+		return
+	}
+
+	now := w.clock.Now()
+	events, err := w.repo.ClaimPendingWebhookEvents(ctx, sqlc.ClaimPendingWebhookEventsParams{
+		WorkerID:          w.id,
+		LockedUntil:       now.Add(webhookLease),
+		Now:               now,
+		InFlightOperators: operators,
+		InFlightCounts:    inFlight,
+		PerOperator:       int32(w.limits.PerOperator),
+		BatchSize:         int32(free),
+	})
 	if err != nil {
-		observability.Logger.Error("failed to fetch pending webhook events:", zap.Error(err))
+		observability.Logger.Error("failed to claim pending webhook events:", zap.Error(err))
 		return
 	}
 
-	for _, ev := range events {
-		event, err := w.queries.MarkWebhookProcessing(ctx, ev.ID)
-		if err != nil {
-			observability.Logger.Error("failed to lock webhook event:", zap.Error(err))
-			continue
-		}
+	policies := map[int32]RetryPolicy{}
 
-		operator, err := w.queries.GetOperatorByID(ctx, event.OperatorID)
-		if err != nil || operator.WebhookUrl == "" {
-			_ = w.queries.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{
-				ID: event.ID,
-				ErrorMessage: sql.NullString{
-					String: "operator not found or no webhook_url",
-					Valid:  true,
-				},
-			})
-			continue
+	for _, event := range events {
+		policy, ok := policies[event.OperatorID]
+		if !ok {
+			policy, err = webhookRetryPolicy(ctx, w.repo, event.OperatorID, w.policy)
+			if err != nil {
+				observability.Logger.Error("failed to load webhook retry policy, using defaults",
+					zap.Int32("operator_id", event.OperatorID),
+					zap.Error(err),
+				)
+				policy = w.policy
+			}
+			policies[event.OperatorID] = policy
 		}
 
-		if time.Since(event.CreatedAt) > time.Minute {
-			_ = w.queries.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{
-				ID: event.ID,
-				ErrorMessage: sql.NullString{
-					String: "retry window exceeded",
-					Valid:  true,
-				},
-			})
-			continue
-		}
+		slot := w.acquire(event.OperatorID)
+		w.deliveries.Add(1)
+		go func() {
+			defer w.deliveries.Done()
+			defer w.release(event.OperatorID)
 
-		if w.bus != nil {
-			w.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: event.OperatorID,
-				EventType:  "webhook.retry",
-				Data: map[string]any{
-					"event_id":   event.ID,
-					"event_type": event.EventType,
-					"retries":    event.Retries,
-				},
-				CreatedAt: time.Now(),
-			})
-		}
+			select {
+			case slot <- struct{}{}:
+			case <-runCtx.Done():
+				return
+			}
+			defer func() { <-slot }()
 
-		client := NewWebhookClient(operator.WebhookSecret)
-		err = client.Send(ctx, operator.WebhookUrl, event.Payload)
-		if err == nil {
-			_ = w.queries.MarkWebhookCompleted(ctx, event.ID)
-			continue
-		}
+			w.deliver(ctx, event, policy)
+		}()
+	}
+}
+
+// acquire counts a claimed event against its operator and returns the
+// operator's slots, which bound how many of its deliveries run at once.
+func (w *WebhookWorker) acquire(operatorID int32) chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.inFlight++
+	w.byOperator[operatorID]++
+	observability.WebhooksInFlight.WithLabelValues(operatorLabel(operatorID)).Inc()
+
+	slot, ok := w.slots[operatorID]
+	if !ok {
+		slot = make(chan struct{}, w.limits.PerOperator)
+		w.slots[operatorID] = slot
+	}
+	return slot
+}
+
+func (w *WebhookWorker) release(operatorID int32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.inFlight--
+	w.byOperator[operatorID]--
+	observability.WebhooksInFlight.WithLabelValues(operatorLabel(operatorID)).Dec()
+
+	if w.byOperator[operatorID] == 0 {
+		delete(w.byOperator, operatorID)
+		delete(w.slots, operatorID)
+	}
+}
 
-		delay := nextRetryDelay(event.Retries)
-		next := time.Now().Add(delay)
+func operatorLabel(operatorID int32) string {
+	return strconv.Itoa(int(operatorID))
+}
+
+func (w *WebhookWorker) recordQueueDepth(ctx context.Context) {
+	rows, err := w.repo.CountQueuedWebhooksByOperator(ctx)
+	if err != nil {
+		observability.Logger.Error("failed to count queued webhooks", zap.Error(err))
+		return
+	}
 
-		_, _ = w.queries.UpdateWebhookRetry(ctx, sqlc.UpdateWebhookRetryParams{
-			ID:          event.ID,
-			NextRetryAt: next,
+	observability.WebhookQueueDepth.Reset()
+	for _, row := range rows {
+		observability.WebhookQueueDepth.WithLabelValues(operatorLabel(row.OperatorID)).Set(float64(row.Queued))
+	}
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, event sqlc.WebhookEvent, policy RetryPolicy) {
+	url, secrets, err := w.target(ctx, event)
+	if err != nil {
+		n, err := w.repo.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{
+			ID: event.ID,
 			ErrorMessage: sql.NullString{
 				String: err.Error(),
 				Valid:  true,
 			},
+			WorkerID: w.id,
 		})
+		w.finished(event, n, err)
+		return
+	}
 
-		if w.bus != nil {
-			w.bus.Publish(SSEEvent{
-				ID:         uuid.NewString(),
-				OperatorID: event.OperatorID,
-				EventType:  "webhook.failed",
-				Data: map[string]any{
-					"event_id":   event.ID,
-					"event_type": event.EventType,
-					"error":      err.Error(),
-					"retry_in":   delay.Seconds(),
-				},
-				CreatedAt: time.Now(),
-			})
-		}
+	if policy.Expired(queuedAt(event), w.clock.Now()) {
+		w.markDead(ctx, event, event.Retries, "max age exceeded")
+		return
+	}
+
+	if w.bus != nil {
+		w.bus.Publish(SSEEvent{
+			ID:         uuid.NewString(),
+			OperatorID: event.OperatorID,
+			EventType:  "webhook.retry",
+			Data: map[string]any{
+				"event_id":   event.ID,
+				"event_type": event.EventType,
+				"retries":    event.Retries,
+			},
+			CreatedAt: w.clock.Now(),
+		})
+	}
+
+	attempts := event.Retries + 1
+
+	attemptedAt := w.clock.Now()
+	sent, err := w.client.Send(ctx, url, secrets, event.Payload)
+	attempt := w.recordAttempt(ctx, event, attempts, url, attemptedAt, sent, err)
+
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	observability.WebhookDeliveryDuration.WithLabelValues(operatorLabel(event.OperatorID), outcome).Observe(sent.Latency.Seconds())
+
+	if err == nil {
+		n, err := w.repo.MarkWebhookCompleted(ctx, sqlc.MarkWebhookCompletedParams{
+			ID:       event.ID,
+			WorkerID: w.id,
+		})
+		w.finished(event, n, err)
+		return
+	}
+
+	delay := policy.Jittered(policy.Backoff(attempts), w.rand())
+	next := w.clock.Now().Add(delay)
+
+	if policy.Exhausted(attempts) || policy.Expired(queuedAt(event), next) {
+		w.markDead(ctx, event, attempts, err.Error())
+		return
+	}
+
+	n, errUpdate := w.repo.UpdateWebhookRetry(ctx, sqlc.UpdateWebhookRetryParams{
+		ID:          event.ID,
+		NextRetryAt: next,
+		ErrorMessage: sql.NullString{
+			String: err.Error(),
+			Valid:  true,
+		},
+		WorkerID: w.id,
+	})
+	if !w.finished(event, n, errUpdate) {
+		return
+	}
+
+	if w.bus != nil {
+		w.bus.Publish(SSEEvent{
+			ID:         uuid.NewString(),
+			OperatorID: event.OperatorID,
+			EventType:  "webhook.failed",
+			Data: map[string]any{
+				"event_id":   event.ID,
+				"event_type": event.EventType,
+				"error":      err.Error(),
+				"retry_in":   delay.Seconds(),
+				"attempt":    attempt,
+			},
+			CreatedAt: w.clock.Now(),
+		})
+	}
+}
+
+// finished reports whether a lease-checked update applied, logging why
+// not: it failed, or the lease expired and another worker owns the event.
+func (w *WebhookWorker) finished(event sqlc.WebhookEvent, n int64, err error) bool {
+	err = leaseHeld(n, err)
+	switch {
+	case errors.Is(err, errLeaseLost):
+		observability.Logger.Warn("webhook lease lost", zap.Int32("webhook_id", event.ID))
+	case err != nil:
+		observability.Logger.Error("failed to update webhook", zap.Int32("webhook_id", event.ID), zap.Error(err))
+	}
+	return err == nil
+}
+
+// recordAttempt writes the attempt to the delivery log. A failure to log
+// does not fail the delivery.
+func (w *WebhookWorker) recordAttempt(
+	ctx context.Context,
+	event sqlc.WebhookEvent,
+	n int32,
+	url string,
+	attemptedAt time.Time,
+	sent WebhookAttempt,
+	sendErr error,
+) DeliveryAttempt {
+	a := newSentAttempt(n, event.EndpointID, url, attemptedAt, sent, sendErr)
+
+	headers, _ := json.Marshal(a.RequestHeaders)
+	_, err := w.repo.InsertWebhookDelivery(ctx, sqlc.InsertWebhookDeliveryParams{
+		WebhookEventID: event.ID,
+		OperatorID:     event.OperatorID,
+		EndpointID:     event.EndpointID,
+		Attempt:        a.Attempt,
+		Url:            a.URL,
+		RequestHeaders: headers,
+		ResponseStatus: sql.NullInt32{Int32: int32(sent.StatusCode), Valid: a.ResponseStatus != nil},
+		ResponseBody:   sql.NullString{String: a.ResponseBody, Valid: a.ResponseStatus != nil},
+		LatencyMs:      a.LatencyMs,
+		Error:          sql.NullString{String: a.Error, Valid: a.Error != ""},
+		AttemptedAt:    a.AttemptedAt,
+	})
+	if err != nil {
+		observability.Logger.Error("failed to record webhook delivery", zap.Int32("webhook_id", event.ID), zap.Error(err))
+	}
+
+	return a
+}
+
+// markDead moves an event that will not be retried to the dead state; it
+// stays there until redelivered through POST /webhooks/retry/{id}.
+func (w *WebhookWorker) markDead(ctx context.Context, event sqlc.WebhookEvent, retries int32, reason string) {
+	n, err := w.repo.MarkWebhookDead(ctx, sqlc.MarkWebhookDeadParams{
+		ID:      event.ID,
+		Retries: retries,
+		ErrorMessage: sql.NullString{
+			String: reason,
+			Valid:  true,
+		},
+		WorkerID: w.id,
+	})
+	if !w.finished(event, n, err) {
+		return
+	}
+
+	observability.Logger.Warn("webhook moved to dead-letter",
+		zap.Int32("webhook_id", event.ID),
+		zap.Int32("operator_id", event.OperatorID),
+		zap.Int32("retries", retries),
+		zap.String("error", reason),
+	)
+
+	if w.bus != nil {
+		w.bus.Publish(SSEEvent{
+			ID:         uuid.NewString(),
+			OperatorID: event.OperatorID,
+			EventType:  "webhook.dead",
+			Data: map[string]any{
+				"event_id":    event.ID,
+				"event_type":  event.EventType,
+				"endpoint_id": event.EndpointID.Int32,
+				"retries":     retries,
+				"error":       reason,
+			},
+			CreatedAt: w.clock.Now(),
+		})
+	}
+}
+
+// target returns where a delivery goes and the secrets to sign it with,
+// both from its endpoint.
+func (w *WebhookWorker) target(ctx context.Context, event sqlc.WebhookEvent) (string, []string, error) {
+	if !event.EndpointID.Valid {
+		return "", nil, errors.New("webhook has no endpoint")
+	}
+
+	ep, err := w.repo.GetWebhookEndpoint(ctx, sqlc.GetWebhookEndpointParams{
+		ID:         event.EndpointID.Int32,
+		OperatorID: event.OperatorID,
+	})
+	if err != nil {
+		return "", nil, errors.New("webhook endpoint not found")
+	}
+	if !ep.Enabled {
+		return "", nil, errors.New("webhook endpoint disabled")
 	}
+	return ep.Url, SigningSecrets(ep, w.clock.Now()), nil
 }
 
-func nextRetryDelay(retries int32) time.Duration {
-	switch retries {
-	case 0:
-		return 0
-	case 1:
-		return 5 * time.Second
-	case 2:
-		return 10 * time.Second
-	case 3:
-		return 20 * time.Second
-	default:
-		return 25 * time.Second
+// queuedAt is when the event's max age starts: when it was enqueued, or
+// when an operator last re-queued it.
+func queuedAt(event sqlc.WebhookEvent) time.Time {
+	if event.RequeuedAt.Valid {
+		return event.RequeuedAt.Time
 	}
+	return event.CreatedAt
 }
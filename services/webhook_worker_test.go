@@ -0,0 +1,540 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"rgs/memstore"
+	"rgs/sqlc"
+	"rgs/webhooksdk"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestWebhookWorker uses the default policy without jitter, so retry
+// times are exact.
+func newTestWebhookWorker(env *testEnv) *WebhookWorker {
+	w := NewWebhookWorker(env.store, env.clock, nil, "test", DefaultWebhookRetryPolicy, DefaultWebhookConcurrency, time.Second, nil)
+	w.rand = func() float64 { return 0 }
+	return w
+}
+
+// processPending claims one batch and waits for it to be delivered.
+func (w *WebhookWorker) processPending(ctx context.Context) {
+	w.dispatch(ctx)
+	w.deliveries.Wait()
+}
+
+// enqueueWebhook queues a bet_settled delivery to the operator's first
+// endpoint, creating one from its webhook_url and secret the way
+// OperatorService.Create does.
+func enqueueWebhook(t *testing.T, env *testEnv, operatorID int32) sqlc.WebhookEvent {
+	t.Helper()
+
+	endpoints, _ := env.store.ListWebhookEndpoints(env.ctx, operatorID)
+	if len(endpoints) == 0 {
+		op, err := env.store.GetOperatorByID(env.ctx, operatorID)
+		if err != nil {
+			t.Fatalf("get operator: %v", err)
+		}
+		ep, err := env.store.CreateWebhookEndpoint(env.ctx, sqlc.CreateWebhookEndpointParams{
+			OperatorID: operatorID,
+			Url:        op.WebhookUrl,
+			Secret:     op.WebhookSecret,
+			EventTypes: DefaultEndpointEventTypes,
+			Enabled:    true,
+		})
+		if err != nil {
+			t.Fatalf("create endpoint: %v", err)
+		}
+		endpoints = append(endpoints, ep)
+	}
+
+	e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+		OperatorID: operatorID,
+		EventType:  "bet_settled",
+		Payload:    json.RawMessage(`{"bet_id":1}`),
+		EndpointID: sql.NullInt32{Int32: endpoints[0].ID, Valid: true},
+		Now:        env.clock.Now(),
+	})
+	if err != nil {
+		t.Fatalf("insert webhook: %v", err)
+	}
+	return e
+}
+
+// claimWebhook claims e for the "claimer" worker so a test can finish it
+// the way a worker would. e must be the oldest webhook due.
+func claimWebhook(t *testing.T, env *testEnv, e sqlc.WebhookEvent) {
+	t.Helper()
+
+	now := env.clock.Now()
+	claimed, err := env.store.ClaimPendingWebhookEvents(env.ctx, sqlc.ClaimPendingWebhookEventsParams{
+		WorkerID:    "claimer",
+		LockedUntil: now.Add(webhookLease),
+		Now:         now,
+		PerOperator: 1,
+		BatchSize:   1,
+	})
+	if err != nil || len(claimed) != 1 || claimed[0].ID != e.ID {
+		t.Fatalf("claim webhook %d: claimed %v, err %v", e.ID, claimed, err)
+	}
+}
+
+func getWebhook(t *testing.T, env *testEnv, operatorID, id int32) sqlc.WebhookEvent {
+	t.Helper()
+
+	e, err := env.store.GetWebhookEventByID(env.ctx, sqlc.GetWebhookEventByIDParams{
+		ID:         id,
+		OperatorID: operatorID,
+	})
+	if err != nil {
+		t.Fatalf("get webhook: %v", err)
+	}
+	return e
+}
+
+func TestWebhookWorkerDeliversSignedPayload(t *testing.T) {
+	var body []byte
+	var signature, timestamp string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-RGS-Signature")
+		timestamp = r.Header.Get("X-RGS-Timestamp")
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+
+	newTestWebhookWorker(env).processPending(context.Background())
+
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+		t.Fatalf("status = %q, want completed", got.Status)
+	}
+	if string(body) != `{"bet_id":1}` {
+		t.Fatalf("body = %s", body)
+	}
+	if want := strconv.FormatInt(env.clock.Now().Unix(), 10); timestamp != want {
+		t.Fatalf("timestamp = %s, want %s", timestamp, want)
+	}
+	v := webhooksdk.Verifier{Secrets: []string{op.WebhookSecret}, Now: env.clock.Now}
+	if err := v.VerifyPayload(timestamp, signature, body); err != nil {
+		t.Fatalf("signature %q: %v", signature, err)
+	}
+}
+
+func TestWebhookWorkerSchedulesRetryOnFailure(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+	w := newTestWebhookWorker(env)
+
+	w.processPending(context.Background())
+
+	got := getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Status != "pending" || got.Retries != 1 || !got.ErrorMessage.Valid {
+		t.Fatalf("after failure: status %q retries %d error %+v", got.Status, got.Retries, got.ErrorMessage)
+	}
+
+	env.clock.Advance(DefaultWebhookRetryPolicy.BaseDelay)
+	w.processPending(context.Background())
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+		t.Fatalf("after retry: status %q, want completed", got.Status)
+	}
+}
+
+func TestWebhookWorkerWaitsForRetryDelay(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+	w := newTestWebhookWorker(env)
+
+	policy := DefaultWebhookRetryPolicy
+
+	w.processPending(context.Background())
+
+	got := getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Retries != 1 || !got.NextRetryAt.Equal(env.clock.Now().Add(policy.Backoff(1))) {
+		t.Fatalf("retries %d next_retry_at %v", got.Retries, got.NextRetryAt)
+	}
+
+	env.clock.Advance(policy.Backoff(1) - time.Second)
+	w.processPending(context.Background())
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Retries != 1 {
+		t.Fatalf("retried before the delay elapsed: retries %d", got.Retries)
+	}
+
+	env.clock.Advance(time.Second)
+	w.processPending(context.Background())
+	got = getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Retries != 2 || !got.NextRetryAt.Equal(env.clock.Now().Add(policy.Backoff(2))) {
+		t.Fatalf("second failure: retries %d next_retry_at %v", got.Retries, got.NextRetryAt)
+	}
+}
+
+func TestWebhookWorkerUsesOperatorPolicy(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+
+	_, err := env.store.UpsertWebhookRetryPolicy(env.ctx, sqlc.UpsertWebhookRetryPolicyParams{
+		OperatorID:       op.ID,
+		MaxAttempts:      2,
+		MaxAgeSeconds:    3600,
+		BaseDelaySeconds: 30,
+		MaxDelaySeconds:  60,
+	})
+	if err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+
+	bus := NewEventBus(10)
+	w := newTestWebhookWorker(env)
+	w.bus = bus
+
+	w.processPending(context.Background())
+	if got := getWebhook(t, env, e.OperatorID, e.ID); !got.NextRetryAt.Equal(env.clock.Now().Add(30 * time.Second)) {
+		t.Fatalf("next_retry_at %v, want the operator's 30s base delay", got.NextRetryAt)
+	}
+
+	env.clock.Advance(30 * time.Second)
+	w.processPending(context.Background())
+
+	got := getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Status != "dead" || got.Retries != 2 {
+		t.Fatalf("status %q retries %d, want dead after 2 attempts", got.Status, got.Retries)
+	}
+
+	var dead []SSEEvent
+	for _, evt := range bus.GetBufferedEvents(op.ID, "") {
+		if evt.EventType == "webhook.dead" {
+			dead = append(dead, evt)
+		}
+	}
+	if len(dead) != 1 {
+		t.Fatalf("got %d webhook.dead events, want 1", len(dead))
+	}
+
+	env.clock.Advance(time.Hour)
+	w.processPending(context.Background())
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Retries != 2 {
+		t.Fatal("dead webhook was retried")
+	}
+}
+
+func TestWebhookWorkerKeepsRetryingWhileReceiverIsDown(t *testing.T) {
+	var up atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+	w := newTestWebhookWorker(env)
+
+	// Two hours of outage, polling every minute.
+	for i := 0; i < 120; i++ {
+		w.processPending(context.Background())
+		env.clock.Advance(time.Minute)
+	}
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "pending" {
+		t.Fatalf("status %q after a two hour outage, want pending", got.Status)
+	}
+
+	up.Store(true)
+	env.clock.Advance(DefaultWebhookRetryPolicy.MaxDelay)
+	w.processPending(context.Background())
+	if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+		t.Fatalf("status %q once the receiver is back, want completed", got.Status)
+	}
+}
+
+func TestWebhookWorkerDeadAfterMaxAge(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("event past its max age was delivered")
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+
+	env.clock.Advance(DefaultWebhookRetryPolicy.MaxAge + time.Minute)
+	newTestWebhookWorker(env).processPending(context.Background())
+
+	got := getWebhook(t, env, e.OperatorID, e.ID)
+	if got.Status != "dead" || got.ErrorMessage.String != "max age exceeded" {
+		t.Fatalf("status %q error %q", got.Status, got.ErrorMessage.String)
+	}
+}
+
+func TestWebhookWorkerLogsEveryAttempt(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(strings.Repeat("x", 2*maxResponseBody)))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, receiver.URL)
+	e := enqueueWebhook(t, env, op.ID)
+
+	bus := NewEventBus(10)
+	w := newTestWebhookWorker(env)
+	w.bus = bus
+
+	w.processPending(context.Background())
+	env.clock.Advance(DefaultWebhookRetryPolicy.BaseDelay)
+	w.processPending(context.Background())
+
+	svc := NewWebhookService(env.store, env.clock, NewComplianceService(env.store), DefaultWebhookRetryPolicy)
+	attempts, err := svc.Attempts(env.ctx, op.ID, e.ID)
+	if err != nil {
+		t.Fatalf("attempts: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(attempts))
+	}
+
+	failed, ok := attempts[0], attempts[1]
+	if failed.Attempt != 1 || failed.ResponseStatus == nil || *failed.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("first attempt = %+v", failed)
+	}
+	if len(failed.ResponseBody) != maxResponseBody || failed.Error == "" {
+		t.Fatalf("first attempt body %d bytes, error %q", len(failed.ResponseBody), failed.Error)
+	}
+	if failed.RequestHeaders["X-Rgs-Signature"] == "" || failed.URL != receiver.URL {
+		t.Fatalf("first attempt request = %s %v", failed.URL, failed.RequestHeaders)
+	}
+	if ok.Attempt != 2 || *ok.ResponseStatus != http.StatusOK || ok.ResponseBody != "ok" || ok.Error != "" {
+		t.Fatalf("second attempt = %+v", ok)
+	}
+	if !ok.AttemptedAt.Equal(env.clock.Now()) {
+		t.Fatalf("attempted_at = %v, want %v", ok.AttemptedAt, env.clock.Now())
+	}
+
+	var notice SSEEvent
+	for _, evt := range bus.GetBufferedEvents(op.ID, "") {
+		if evt.EventType == "webhook.failed" {
+			notice = evt
+		}
+	}
+	data, _ := notice.Data.(map[string]any)
+	if a, _ := data["attempt"].(DeliveryAttempt); a.Attempt != 1 {
+		t.Fatalf("webhook.failed data = %+v, want the attempt", notice.Data)
+	}
+
+	env.clock.Advance(time.Second)
+	other := env.operator(t, "")
+	if _, err := svc.Attempts(env.ctx, other.ID, e.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("attempts from another operator: err = %v", err)
+	}
+}
+
+func TestWebhookWorkerIsolatesSlowOperator(t *testing.T) {
+	unblock := make(chan struct{})
+	var active, maxActive atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-unblock
+	}))
+	defer slow.Close()
+
+	fastDone := make(chan struct{}, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fastDone <- struct{}{}
+	}))
+	defer fast.Close()
+
+	env := newTestEnv(t)
+	slowOp := env.operator(t, slow.URL)
+	env.clock.Advance(time.Second)
+	fastOp := env.operator(t, fast.URL)
+
+	var slowEvents []sqlc.WebhookEvent
+	for range 3 {
+		slowEvents = append(slowEvents, enqueueWebhook(t, env, slowOp.ID))
+	}
+	fastEvent := enqueueWebhook(t, env, fastOp.ID)
+
+	w := newTestWebhookWorker(env)
+	w.limits = WebhookConcurrency{Total: 4, PerOperator: 2}
+	w.dispatch(context.Background())
+
+	select {
+	case <-fastDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast operator was held up by the slow one")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for active.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(unblock)
+	w.deliveries.Wait()
+
+	if got := maxActive.Load(); got != 2 {
+		t.Fatalf("slow operator had %d deliveries in flight, want 2", got)
+	}
+	if got := getWebhook(t, env, fastEvent.OperatorID, fastEvent.ID); got.Status != "completed" {
+		t.Fatalf("fast operator's webhook is %q", got.Status)
+	}
+	if got := getWebhook(t, env, slowEvents[2].OperatorID, slowEvents[2].ID); got.Status != "pending" {
+		t.Fatalf("third slow webhook is %q, want it left for the next batch", got.Status)
+	}
+
+	w.processPending(context.Background())
+
+	if got := getWebhook(t, env, slowEvents[2].OperatorID, slowEvents[2].ID); got.Status != "completed" {
+		t.Fatalf("third slow webhook is %q after the next batch", got.Status)
+	}
+}
+
+func TestWebhookWorkerDeliversOrderedEndpointInOrder(t *testing.T) {
+	var received []string
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, "")
+	svc := NewWebhookEndpointService(env.store, memstore.Tx[WebhookEndpointRepo](env.store), env.clock, NewComplianceService(env.store))
+	ep, err := svc.Create(env.ctx, op.ID, WebhookEndpointParams{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+		Enabled:    true,
+		Ordered:    true,
+	})
+	if err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+
+	var events []sqlc.WebhookEvent
+	for _, body := range []string{`{"n":1}`, `{"n":2}`} {
+		e, err := env.store.InsertWebhookEvent(env.ctx, sqlc.InsertWebhookEventParams{
+			OperatorID: op.ID,
+			EventType:  "bet_settled",
+			Payload:    json.RawMessage(body),
+			EndpointID: sql.NullInt32{Int32: ep.ID, Valid: true},
+			Now:        env.clock.Now(),
+		})
+		if err != nil {
+			t.Fatalf("insert webhook: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	w := newTestWebhookWorker(env)
+	w.processPending(context.Background())
+
+	if got := getWebhook(t, env, events[1].OperatorID, events[1].ID); got.Status != "pending" || got.Retries != 0 {
+		t.Fatalf("second webhook was attempted while the first failed: %+v", got)
+	}
+
+	env.clock.Advance(DefaultWebhookRetryPolicy.BaseDelay)
+	w.processPending(context.Background())
+	w.processPending(context.Background())
+
+	want := []string{`{"n":1}`, `{"n":1}`, `{"n":2}`}
+	if !slices.Equal(received, want) {
+		t.Fatalf("received %v, want %v", received, want)
+	}
+	for _, e := range events {
+		if got := getWebhook(t, env, e.OperatorID, e.ID); got.Status != "completed" {
+			t.Fatalf("webhook %d is %q", e.ID, got.Status)
+		}
+	}
+}
+
+func TestWebhookWorkerCountsInFlightAgainstOperatorLimit(t *testing.T) {
+	unblock := make(chan struct{})
+	var active atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		active.Add(1)
+		<-unblock
+	}))
+	defer slow.Close()
+
+	env := newTestEnv(t)
+	op := env.operator(t, slow.URL)
+	first := enqueueWebhook(t, env, op.ID)
+
+	w := newTestWebhookWorker(env)
+	w.limits = WebhookConcurrency{Total: 4, PerOperator: 2}
+	w.dispatch(context.Background())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for active.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	for range 3 {
+		enqueueWebhook(t, env, op.ID)
+	}
+	w.dispatch(context.Background())
+
+	events, _ := env.store.ListWebhooksByOperator(env.ctx, op.ID)
+	var processing int
+	for _, e := range events {
+		if e.ID != first.ID && e.Status == "processing" {
+			processing++
+		}
+	}
+	close(unblock)
+	w.deliveries.Wait()
+
+	if processing != 1 {
+		t.Fatalf("claimed %d more with one in flight, want 1 to reach PerOperator", processing)
+	}
+}
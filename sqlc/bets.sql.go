@@ -89,11 +89,17 @@ func (q *Queries) GetBetByIdempotency(ctx context.Context, arg GetBetByIdempoten
 const getBetsByRound = `-- name: GetBetsByRound :many
 SELECT id, operator_id, player_id, round_id, amount, outcome, win_amount, status, idempotency_key, created_at FROM bets
 WHERE round_id = $1
+  AND operator_id = $2
 ORDER BY id
 `
 
-func (q *Queries) GetBetsByRound(ctx context.Context, roundID int32) ([]Bet, error) {
-	rows, err := q.db.QueryContext(ctx, getBetsByRound, roundID)
+type GetBetsByRoundParams struct {
+	RoundID    int32 `json:"round_id"`
+	OperatorID int32 `json:"operator_id"`
+}
+
+func (q *Queries) GetBetsByRound(ctx context.Context, arg GetBetsByRoundParams) ([]Bet, error) {
+	rows, err := q.db.QueryContext(ctx, getBetsByRound, arg.RoundID, arg.OperatorID)
 	if err != nil {
 		return nil, err
 	}
@@ -126,16 +132,29 @@ func (q *Queries) GetBetsByRound(ctx context.Context, roundID int32) ([]Bet, err
 	return items, nil
 }
 
-const markBetAsWon = `-- name: MarkBetAsWon :exec
+const markBetAsWon = `-- name: MarkBetAsWon :one
 UPDATE bets
 SET status = 'won'
 WHERE id = $1
     RETURNING id, operator_id, player_id, round_id, amount, outcome, win_amount, status, idempotency_key, created_at
 `
 
-func (q *Queries) MarkBetAsWon(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, markBetAsWon, id)
-	return err
+func (q *Queries) MarkBetAsWon(ctx context.Context, id int32) (Bet, error) {
+	row := q.db.QueryRowContext(ctx, markBetAsWon, id)
+	var i Bet
+	err := row.Scan(
+		&i.ID,
+		&i.OperatorID,
+		&i.PlayerID,
+		&i.RoundID,
+		&i.Amount,
+		&i.Outcome,
+		&i.WinAmount,
+		&i.Status,
+		&i.IdempotencyKey,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
 const updateBetStatus = `-- name: UpdateBetStatus :one
@@ -6,12 +6,58 @@ package sqlc
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusRetrying  OutboxStatus = "retrying"
+	OutboxStatusSucceeded OutboxStatus = "succeeded"
+	OutboxStatusDead      OutboxStatus = "dead"
+)
+
+func (e *OutboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OutboxStatus(s)
+	case string:
+		*e = OutboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OutboxStatus: %T", src)
+	}
+	return nil
+}
+
+type NullOutboxStatus struct {
+	OutboxStatus OutboxStatus `json:"outbox_status"`
+	Valid        bool         `json:"valid"` // Valid is true if OutboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.OutboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OutboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOutboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OutboxStatus), nil
+}
+
 type AuditLog struct {
 	ID         int32           `json:"id"`
 	OperatorID int32           `json:"operator_id"`
@@ -34,6 +80,33 @@ type Bet struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+type Event struct {
+	ID           int32           `json:"id"`
+	EventID      uuid.UUID       `json:"event_id"`
+	OperatorID   int32           `json:"operator_id"`
+	EventType    string          `json:"event_type"`
+	Payload      json.RawMessage `json:"payload"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DispatchedAt sql.NullTime    `json:"dispatched_at"`
+	LockedBy     sql.NullString  `json:"locked_by"`
+	LockedUntil  sql.NullTime    `json:"locked_until"`
+	Version      int32           `json:"version"`
+}
+
+type LedgerEntry struct {
+	ID            int32         `json:"id"`
+	TransactionID uuid.UUID     `json:"transaction_id"`
+	OperatorID    int32         `json:"operator_id"`
+	PlayerID      sql.NullInt32 `json:"player_id"`
+	BetID         sql.NullInt32 `json:"bet_id"`
+	OutboxID      sql.NullInt32 `json:"outbox_id"`
+	EntryType     string        `json:"entry_type"`
+	Account       string        `json:"account"`
+	Direction     string        `json:"direction"`
+	Amount        float64       `json:"amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
 type Operator struct {
 	ID            int32     `json:"id"`
 	Name          string    `json:"name"`
@@ -52,13 +125,20 @@ type OperatorLimit struct {
 }
 
 type Outbox struct {
-	ID         int32     `json:"id"`
-	BetID      int32     `json:"bet_id"`
-	OperatorID int32     `json:"operator_id"`
-	PlayerID   int32     `json:"player_id"`
-	Amount     float64   `json:"amount"`
-	CreatedAt  time.Time `json:"created_at"`
-	Processed  bool      `json:"processed"`
+	ID            int32          `json:"id"`
+	BetID         int32          `json:"bet_id"`
+	OperatorID    int32          `json:"operator_id"`
+	PlayerID      int32          `json:"player_id"`
+	Amount        float64        `json:"amount"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Status        OutboxStatus   `json:"status"`
+	Attempts      int32          `json:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     sql.NullString `json:"last_error"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	LockedBy      sql.NullString `json:"locked_by"`
+	LockedUntil   sql.NullTime   `json:"locked_until"`
+	CreditTxID    string         `json:"credit_tx_id"`
 }
 
 type Player struct {
@@ -67,6 +147,34 @@ type Player struct {
 	ExternalPlayerID string    `json:"external_player_id"`
 	Jurisdiction     string    `json:"jurisdiction"`
 	CreatedAt        time.Time `json:"created_at"`
+	Blocked          bool      `json:"blocked"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID             int32         `json:"id"`
+	RunID          int32         `json:"run_id"`
+	OperatorID     sql.NullInt32 `json:"operator_id"`
+	BetID          sql.NullInt32 `json:"bet_id"`
+	PlayerID       int32         `json:"player_id"`
+	RequestID      string        `json:"request_id"`
+	Kind           string        `json:"kind"`
+	Direction      string        `json:"direction"`
+	ExpectedAmount float64       `json:"expected_amount"`
+	ActualAmount   float64       `json:"actual_amount"`
+	Details        string        `json:"details"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID            int32          `json:"id"`
+	WindowStart   time.Time      `json:"window_start"`
+	WindowEnd     time.Time      `json:"window_end"`
+	Status        string         `json:"status"`
+	Checked       int32          `json:"checked"`
+	Discrepancies int32          `json:"discrepancies"`
+	ErrorMessage  sql.NullString `json:"error_message"`
+	StartedAt     time.Time      `json:"started_at"`
+	FinishedAt    sql.NullTime   `json:"finished_at"`
 }
 
 type Round struct {
@@ -89,6 +197,45 @@ type Session struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+type WebhookDelivery struct {
+	ID             int32           `json:"id"`
+	WebhookEventID int32           `json:"webhook_event_id"`
+	OperatorID     int32           `json:"operator_id"`
+	EndpointID     sql.NullInt32   `json:"endpoint_id"`
+	Attempt        int32           `json:"attempt"`
+	Url            string          `json:"url"`
+	RequestHeaders json.RawMessage `json:"request_headers"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	ResponseBody   sql.NullString  `json:"response_body"`
+	LatencyMs      int32           `json:"latency_ms"`
+	Error          sql.NullString  `json:"error"`
+	AttemptedAt    time.Time       `json:"attempted_at"`
+}
+
+type WebhookEndpoint struct {
+	ID                      int32          `json:"id"`
+	OperatorID              int32          `json:"operator_id"`
+	Url                     string         `json:"url"`
+	Secret                  string         `json:"secret"`
+	EventTypes              []string       `json:"event_types"`
+	Enabled                 bool           `json:"enabled"`
+	CreatedAt               time.Time      `json:"created_at"`
+	UpdatedAt               time.Time      `json:"updated_at"`
+	PreviousSecret          sql.NullString `json:"previous_secret"`
+	PreviousSecretExpiresAt sql.NullTime   `json:"previous_secret_expires_at"`
+	Ordered                 bool           `json:"ordered"`
+}
+
+type WebhookRetryPolicy struct {
+	OperatorID       int32     `json:"operator_id"`
+	MaxAttempts      int32     `json:"max_attempts"`
+	MaxAgeSeconds    int32     `json:"max_age_seconds"`
+	BaseDelaySeconds int32     `json:"base_delay_seconds"`
+	MaxDelaySeconds  int32     `json:"max_delay_seconds"`
+	Jitter           float64   `json:"jitter"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
 type WebhookEvent struct {
 	ID           int32           `json:"id"`
 	OperatorID   int32           `json:"operator_id"`
@@ -100,4 +247,8 @@ type WebhookEvent struct {
 	ErrorMessage sql.NullString  `json:"error_message"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	LockedBy     sql.NullString  `json:"locked_by"`
+	LockedUntil  sql.NullTime    `json:"locked_until"`
+	EndpointID   sql.NullInt32   `json:"endpoint_id"`
+	RequeuedAt   sql.NullTime    `json:"requeued_at"`
 }
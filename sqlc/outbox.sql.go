@@ -7,18 +7,35 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
-const getPendingOutbox = `-- name: GetPendingOutbox :many
-SELECT id, bet_id, operator_id, player_id, amount, created_at, processed
-FROM outbox
-WHERE processed = FALSE
-ORDER BY id
-    LIMIT 10
+const claimPendingOutbox = `-- name: ClaimPendingOutbox :many
+UPDATE outbox
+SET locked_by = $1::text,
+    locked_until = $2::timestamptz
+WHERE id IN (
+    SELECT id
+    FROM outbox
+    WHERE status IN ('pending', 'retrying')
+      AND next_attempt_at <= $3::timestamptz
+      AND (locked_until IS NULL OR locked_until < $3::timestamptz)
+    ORDER BY next_attempt_at, id
+        LIMIT 10
+    FOR UPDATE SKIP LOCKED
+)
+    RETURNING id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
 `
 
-func (q *Queries) GetPendingOutbox(ctx context.Context) ([]Outbox, error) {
-	rows, err := q.db.QueryContext(ctx, getPendingOutbox)
+type ClaimPendingOutboxParams struct {
+	WorkerID    string    `json:"worker_id"`
+	LockedUntil time.Time `json:"locked_until"`
+	Now         time.Time `json:"now"`
+}
+
+func (q *Queries) ClaimPendingOutbox(ctx context.Context, arg ClaimPendingOutboxParams) ([]Outbox, error) {
+	rows, err := q.db.QueryContext(ctx, claimPendingOutbox, arg.WorkerID, arg.LockedUntil, arg.Now)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +50,14 @@ func (q *Queries) GetPendingOutbox(ctx context.Context) ([]Outbox, error) {
 			&i.PlayerID,
 			&i.Amount,
 			&i.CreatedAt,
-			&i.Processed,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.CreditTxID,
 		); err != nil {
 			return nil, err
 		}
@@ -48,10 +72,45 @@ func (q *Queries) GetPendingOutbox(ctx context.Context) ([]Outbox, error) {
 	return items, nil
 }
 
+const getOutboxByOperator = `-- name: GetOutboxByOperator :one
+SELECT id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
+FROM outbox
+WHERE id = $1
+  AND operator_id = $2
+    LIMIT 1
+`
+
+type GetOutboxByOperatorParams struct {
+	ID         int32 `json:"id"`
+	OperatorID int32 `json:"operator_id"`
+}
+
+func (q *Queries) GetOutboxByOperator(ctx context.Context, arg GetOutboxByOperatorParams) (Outbox, error) {
+	row := q.db.QueryRowContext(ctx, getOutboxByOperator, arg.ID, arg.OperatorID)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.BetID,
+		&i.OperatorID,
+		&i.PlayerID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.UpdatedAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.CreditTxID,
+	)
+	return i, err
+}
+
 const insertOutbox = `-- name: InsertOutbox :one
-INSERT INTO outbox (bet_id, operator_id, player_id, amount)
-VALUES ($1, $2, $3, $4)
-    RETURNING id, bet_id, operator_id, player_id, amount, created_at, processed
+INSERT INTO outbox (bet_id, operator_id, player_id, amount, credit_tx_id)
+VALUES ($1, $2, $3, $4, $5)
+    RETURNING id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
 `
 
 type InsertOutboxParams struct {
@@ -59,6 +118,7 @@ type InsertOutboxParams struct {
 	OperatorID int32   `json:"operator_id"`
 	PlayerID   int32   `json:"player_id"`
 	Amount     float64 `json:"amount"`
+	CreditTxID string  `json:"credit_tx_id"`
 }
 
 func (q *Queries) InsertOutbox(ctx context.Context, arg InsertOutboxParams) (Outbox, error) {
@@ -67,6 +127,7 @@ func (q *Queries) InsertOutbox(ctx context.Context, arg InsertOutboxParams) (Out
 		arg.OperatorID,
 		arg.PlayerID,
 		arg.Amount,
+		arg.CreditTxID,
 	)
 	var i Outbox
 	err := row.Scan(
@@ -76,13 +137,20 @@ func (q *Queries) InsertOutbox(ctx context.Context, arg InsertOutboxParams) (Out
 		&i.PlayerID,
 		&i.Amount,
 		&i.CreatedAt,
-		&i.Processed,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.UpdatedAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.CreditTxID,
 	)
 	return i, err
 }
 
 const listOutboxByOperator = `-- name: ListOutboxByOperator :many
-SELECT id, bet_id, operator_id, player_id, amount, created_at, processed
+SELECT id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
 FROM outbox
 WHERE operator_id = $1
 ORDER BY id DESC
@@ -105,7 +173,14 @@ func (q *Queries) ListOutboxByOperator(ctx context.Context, operatorID int32) ([
 			&i.PlayerID,
 			&i.Amount,
 			&i.CreatedAt,
-			&i.Processed,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.CreditTxID,
 		); err != nil {
 			return nil, err
 		}
@@ -121,21 +196,161 @@ func (q *Queries) ListOutboxByOperator(ctx context.Context, operatorID int32) ([
 }
 
 const listOutboxByOperatorStatus = `-- name: ListOutboxByOperatorStatus :many
-SELECT id, bet_id, operator_id, player_id, amount, created_at, processed
+SELECT id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
 FROM outbox
 WHERE operator_id = $1
-  AND processed = $2
+  AND status = $2
 ORDER BY id DESC
     LIMIT 200
 `
 
 type ListOutboxByOperatorStatusParams struct {
-	OperatorID int32 `json:"operator_id"`
-	Processed  bool  `json:"processed"`
+	OperatorID int32        `json:"operator_id"`
+	Status     OutboxStatus `json:"status"`
 }
 
 func (q *Queries) ListOutboxByOperatorStatus(ctx context.Context, arg ListOutboxByOperatorStatusParams) ([]Outbox, error) {
-	rows, err := q.db.QueryContext(ctx, listOutboxByOperatorStatus, arg.OperatorID, arg.Processed)
+	rows, err := q.db.QueryContext(ctx, listOutboxByOperatorStatus, arg.OperatorID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.BetID,
+			&i.OperatorID,
+			&i.PlayerID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.CreditTxID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxDead = `-- name: MarkOutboxDead :execrows
+UPDATE outbox
+SET status = 'dead',
+    attempts = attempts + 1,
+    last_error = $2,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = $3
+  AND status IN ('pending', 'retrying')
+`
+
+type MarkOutboxDeadParams struct {
+	ID        int32          `json:"id"`
+	LastError sql.NullString `json:"last_error"`
+	WorkerID  string         `json:"worker_id"`
+}
+
+func (q *Queries) MarkOutboxDead(ctx context.Context, arg MarkOutboxDeadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOutboxDead, arg.ID, arg.LastError, arg.WorkerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markOutboxRetry = `-- name: MarkOutboxRetry :execrows
+UPDATE outbox
+SET status = 'retrying',
+    attempts = attempts + 1,
+    next_attempt_at = $2,
+    last_error = $3,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = $4
+  AND status IN ('pending', 'retrying')
+`
+
+type MarkOutboxRetryParams struct {
+	ID            int32          `json:"id"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     sql.NullString `json:"last_error"`
+	WorkerID      string         `json:"worker_id"`
+}
+
+func (q *Queries) MarkOutboxRetry(ctx context.Context, arg MarkOutboxRetryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOutboxRetry,
+		arg.ID,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.WorkerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markOutboxSucceeded = `-- name: MarkOutboxSucceeded :execrows
+UPDATE outbox
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = $2
+  AND status IN ('pending', 'retrying')
+`
+
+type MarkOutboxSucceededParams struct {
+	ID       int32  `json:"id"`
+	WorkerID string `json:"worker_id"`
+}
+
+func (q *Queries) MarkOutboxSucceeded(ctx context.Context, arg MarkOutboxSucceededParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOutboxSucceeded, arg.ID, arg.WorkerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const redriveDeadOutboxByOperator = `-- name: RedriveDeadOutboxByOperator :many
+UPDATE outbox
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = $2,
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE operator_id = $1
+  AND status = 'dead'
+    RETURNING id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
+`
+
+type RedriveDeadOutboxByOperatorParams struct {
+	OperatorID int32     `json:"operator_id"`
+	Now        time.Time `json:"now"`
+}
+
+func (q *Queries) RedriveDeadOutboxByOperator(ctx context.Context, arg RedriveDeadOutboxByOperatorParams) ([]Outbox, error) {
+	rows, err := q.db.QueryContext(ctx, redriveDeadOutboxByOperator, arg.OperatorID, arg.Now)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +365,14 @@ func (q *Queries) ListOutboxByOperatorStatus(ctx context.Context, arg ListOutbox
 			&i.PlayerID,
 			&i.Amount,
 			&i.CreatedAt,
-			&i.Processed,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.CreditTxID,
 		); err != nil {
 			return nil, err
 		}
@@ -165,15 +387,28 @@ func (q *Queries) ListOutboxByOperatorStatus(ctx context.Context, arg ListOutbox
 	return items, nil
 }
 
-const markOutboxProcessed = `-- name: MarkOutboxProcessed :one
+const redriveOutbox = `-- name: RedriveOutbox :one
 UPDATE outbox
-SET processed = TRUE
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = $3,
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
 WHERE id = $1
-    RETURNING id, bet_id, operator_id, player_id, amount, created_at, processed
+  AND operator_id = $2
+  AND status = 'dead'
+    RETURNING id, bet_id, operator_id, player_id, amount, created_at, status, attempts, next_attempt_at, last_error, updated_at, locked_by, locked_until, credit_tx_id
 `
 
-func (q *Queries) MarkOutboxProcessed(ctx context.Context, id int32) (Outbox, error) {
-	row := q.db.QueryRowContext(ctx, markOutboxProcessed, id)
+type RedriveOutboxParams struct {
+	ID         int32     `json:"id"`
+	OperatorID int32     `json:"operator_id"`
+	Now        time.Time `json:"now"`
+}
+
+func (q *Queries) RedriveOutbox(ctx context.Context, arg RedriveOutboxParams) (Outbox, error) {
+	row := q.db.QueryRowContext(ctx, redriveOutbox, arg.ID, arg.OperatorID, arg.Now)
 	var i Outbox
 	err := row.Scan(
 		&i.ID,
@@ -182,7 +417,14 @@ func (q *Queries) MarkOutboxProcessed(ctx context.Context, id int32) (Outbox, er
 		&i.PlayerID,
 		&i.Amount,
 		&i.CreatedAt,
-		&i.Processed,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.UpdatedAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.CreditTxID,
 	)
 	return i, err
 }
@@ -15,9 +15,10 @@ WHERE operator_id = $1 AND idempotency_key = $2
 -- name: GetBetsByRound :many
 SELECT * FROM bets
 WHERE round_id = $1
+  AND operator_id = $2
 ORDER BY id;
 
--- name: MarkBetAsWon :exec
+-- name: MarkBetAsWon :one
 UPDATE bets
 SET status = 'won'
 WHERE id = $1
@@ -0,0 +1,27 @@
+-- name: InsertEvent :one
+INSERT INTO events (event_id, operator_id, event_type, payload, version)
+VALUES ($1, $2, $3, $4, $5)
+    RETURNING *;
+
+-- name: ClaimUndispatchedEvents :many
+UPDATE events
+SET locked_by = sqlc.arg(worker_id)::text,
+    locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE id IN (
+    SELECT id
+    FROM events
+    WHERE dispatched_at IS NULL
+      AND (locked_until IS NULL OR locked_until < sqlc.arg(now)::timestamptz)
+    ORDER BY id
+        LIMIT 100
+    FOR UPDATE SKIP LOCKED
+)
+    RETURNING *;
+
+-- name: MarkEventDispatched :execrows
+UPDATE events
+SET dispatched_at = NOW(),
+    locked_until = NULL
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND dispatched_at IS NULL;
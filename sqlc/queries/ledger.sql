@@ -0,0 +1,60 @@
+-- name: InsertLedgerEntry :one
+INSERT INTO ledger_entries (
+    transaction_id, operator_id, player_id,
+    bet_id, outbox_id, entry_type,
+    account, direction, amount
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    RETURNING *;
+
+-- name: ListLedgerEntriesByBet :many
+SELECT *
+FROM ledger_entries
+WHERE operator_id = $1
+  AND bet_id = $2
+ORDER BY id;
+
+-- name: GetPlayerLedgerBalance :one
+SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)::float8 AS balance
+FROM ledger_entries
+WHERE operator_id = $1
+  AND player_id = $2
+  AND account = 'player_wallet'
+  AND created_at < sqlc.arg(as_of);
+
+-- name: ListPlayerLedgerHistory :many
+SELECT
+    date_trunc('day', created_at)::timestamptz AS bucket,
+    COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END), 0)::float8 AS debits,
+    COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END), 0)::float8 AS credits
+FROM ledger_entries
+WHERE operator_id = $1
+  AND player_id = $2
+  AND account = 'player_wallet'
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: ListOperatorLedgerBalances :many
+SELECT
+    account,
+    COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)::float8 AS balance
+FROM ledger_entries
+WHERE operator_id = $1
+  AND created_at < sqlc.arg(as_of)
+GROUP BY account
+ORDER BY account;
+
+-- name: ListOperatorLedgerHistory :many
+SELECT
+    date_trunc('day', created_at)::timestamptz AS bucket,
+    account,
+    COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END), 0)::float8 AS debits,
+    COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END), 0)::float8 AS credits
+FROM ledger_entries
+WHERE operator_id = $1
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+GROUP BY bucket, account
+ORDER BY bucket, account;
@@ -0,0 +1,5 @@
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(sqlc.arg(key)::bigint) AS acquired;
+
+-- name: AdvisoryUnlock :one
+SELECT pg_advisory_unlock(sqlc.arg(key)::bigint) AS released;
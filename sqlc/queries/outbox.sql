@@ -1,19 +1,88 @@
 -- name: InsertOutbox :one
-INSERT INTO outbox (bet_id, operator_id, player_id, amount)
-VALUES ($1, $2, $3, $4)
+INSERT INTO outbox (bet_id, operator_id, player_id, amount, credit_tx_id)
+VALUES ($1, $2, $3, $4, $5)
     RETURNING *;
 
--- name: GetPendingOutbox :many
+-- name: ClaimPendingOutbox :many
+UPDATE outbox
+SET locked_by = sqlc.arg(worker_id)::text,
+    locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE id IN (
+    SELECT id
+    FROM outbox
+    WHERE status IN ('pending', 'retrying')
+      AND next_attempt_at <= sqlc.arg(now)::timestamptz
+      AND (locked_until IS NULL OR locked_until < sqlc.arg(now)::timestamptz)
+    ORDER BY next_attempt_at, id
+        LIMIT 10
+    FOR UPDATE SKIP LOCKED
+)
+    RETURNING *;
+
+-- name: MarkOutboxSucceeded :execrows
+UPDATE outbox
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status IN ('pending', 'retrying');
+
+-- name: MarkOutboxRetry :execrows
+UPDATE outbox
+SET status = 'retrying',
+    attempts = attempts + 1,
+    next_attempt_at = $2,
+    last_error = $3,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status IN ('pending', 'retrying');
+
+-- name: MarkOutboxDead :execrows
+UPDATE outbox
+SET status = 'dead',
+    attempts = attempts + 1,
+    last_error = $2,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status IN ('pending', 'retrying');
+
+-- name: GetOutboxByOperator :one
 SELECT *
 FROM outbox
-WHERE processed = FALSE
-ORDER BY id
-    LIMIT 10;
+WHERE id = $1
+  AND operator_id = $2
+    LIMIT 1;
 
--- name: MarkOutboxProcessed :one
+-- name: RedriveOutbox :one
 UPDATE outbox
-SET processed = TRUE
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = sqlc.arg(now),
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
 WHERE id = $1
+  AND operator_id = $2
+  AND status = 'dead'
+    RETURNING *;
+
+-- name: RedriveDeadOutboxByOperator :many
+UPDATE outbox
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = sqlc.arg(now),
+    last_error = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE operator_id = $1
+  AND status = 'dead'
     RETURNING *;
 
 -- name: ListOutboxByOperator :many
@@ -27,6 +96,6 @@ ORDER BY id DESC
 SELECT *
 FROM outbox
 WHERE operator_id = $1
-  AND processed = $2
+  AND status = $2
 ORDER BY id DESC
-    LIMIT 200;
\ No newline at end of file
+    LIMIT 200;
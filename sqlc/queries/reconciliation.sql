@@ -0,0 +1,68 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (window_start, window_end)
+VALUES ($1, $2)
+    RETURNING *;
+
+-- name: GetCompletedReconciliationRun :one
+SELECT *
+FROM reconciliation_runs
+WHERE window_start = $1
+  AND window_end = $2
+  AND status = 'completed'
+ORDER BY id DESC
+    LIMIT 1;
+
+-- name: CompleteReconciliationRun :one
+UPDATE reconciliation_runs
+SET status = 'completed',
+    checked = $2,
+    discrepancies = $3,
+    finished_at = NOW()
+WHERE id = $1
+    RETURNING *;
+
+-- name: FailReconciliationRun :exec
+UPDATE reconciliation_runs
+SET status = 'failed',
+    error_message = $2,
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: InsertReconciliationDiscrepancy :exec
+INSERT INTO reconciliation_discrepancies (
+    run_id, operator_id, bet_id,
+    player_id, request_id, kind,
+    direction, expected_amount, actual_amount,
+    details
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListDiscrepanciesByOperator :many
+SELECT *
+FROM reconciliation_discrepancies
+WHERE operator_id = $1
+ORDER BY id DESC
+    LIMIT $2 OFFSET $3;
+
+-- name: ListDiscrepanciesByOperatorRun :many
+SELECT *
+FROM reconciliation_discrepancies
+WHERE operator_id = $1
+  AND run_id = $2
+ORDER BY id DESC
+    LIMIT $3 OFFSET $4;
+
+-- name: ListBetsCreatedBetween :many
+SELECT *
+FROM bets
+WHERE created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY id;
+
+-- name: ListOutboxForBetsCreatedBetween :many
+SELECT o.*
+FROM outbox o
+JOIN bets b ON b.id = o.bet_id
+WHERE b.created_at >= sqlc.arg(from_time)
+  AND b.created_at < sqlc.arg(to_time)
+ORDER BY o.id;
@@ -5,4 +5,5 @@ VALUES ($1, $2, $3, $4, $5)
 
 -- name: GetRound :one
 SELECT * FROM rounds
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1
+  AND operator_id = $2;
\ No newline at end of file
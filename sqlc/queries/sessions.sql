@@ -1,6 +1,6 @@
 -- name: CreateOperator :one
-INSERT INTO operators (name, api_key)
-VALUES ($1, $2)
+INSERT INTO operators (name, api_key, webhook_url, webhook_secret)
+VALUES ($1, $2, $3, $4)
     RETURNING *;
 
 -- name: GetOperatorByApiKey :one
@@ -11,6 +11,12 @@ WHERE api_key = $1
 -- name: GetOperatorByID :one
 SELECT * FROM operators WHERE id = $1 LIMIT 1;
 
+-- name: RotateOperatorApiKey :one
+UPDATE operators
+SET api_key = $2
+WHERE id = $1
+    RETURNING *;
+
 -- name: CreatePlayer :one
 INSERT INTO players (operator_id, external_player_id, jurisdiction)
 VALUES ($1, $2, $3)
@@ -25,8 +31,23 @@ WHERE operator_id = $1 AND external_player_id = $2
 SELECT *
 FROM players
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1;
 
+-- name: GetPlayerOperatorID :one
+-- Reconciliation attributes wallet transactions across operators; no
+-- request path may use this.
+SELECT operator_id
+FROM players
+WHERE id = $1;
+
+-- name: SetPlayerBlocked :one
+UPDATE players
+SET blocked = $3
+WHERE id = $1
+  AND operator_id = $2
+    RETURNING *;
+
 -- name: CreateSession :one
 INSERT INTO sessions (id, operator_id, player_id, launch_token, expires_at)
 VALUES ($1, $2, $3, $4, $5)
@@ -35,6 +56,7 @@ VALUES ($1, $2, $3, $4, $5)
 -- name: GetSession :one
 SELECT * FROM sessions
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1;
 
 -- name: VerifySessionByToken :one
@@ -43,10 +65,12 @@ FROM sessions
 WHERE launch_token = $1
   AND operator_id = $2
   AND revoked = FALSE
-  AND expires_at > NOW()
+  AND expires_at > sqlc.arg(now)::timestamptz
     LIMIT 1;
 
--- name: RevokeSession :exec
+-- name: RevokeSession :one
 UPDATE sessions
 SET revoked = TRUE
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1
+  AND operator_id = $2
+    RETURNING *;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: InsertWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    webhook_event_id,
+    operator_id,
+    endpoint_id,
+    attempt,
+    url,
+    request_headers,
+    response_status,
+    response_body,
+    latency_ms,
+    error,
+    attempted_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_event_id = $1
+  AND operator_id = $2
+ORDER BY id;
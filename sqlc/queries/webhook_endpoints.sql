@@ -0,0 +1,62 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (operator_id, url, secret, event_types, enabled, ordered)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT *
+FROM webhook_endpoints
+WHERE id = $1
+  AND operator_id = $2
+    LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoints
+WHERE operator_id = $1
+ORDER BY id;
+
+-- name: ListEnabledWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoints
+WHERE operator_id = $1
+  AND enabled = TRUE
+ORDER BY id;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $3,
+    event_types = $4,
+    enabled = $5,
+    ordered = $6,
+    updated_at = NOW()
+WHERE id = $1
+  AND operator_id = $2
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1
+  AND operator_id = $2;
+
+-- name: RotateWebhookEndpointSecret :one
+UPDATE webhook_endpoints
+SET previous_secret = secret,
+    previous_secret_expires_at = $4,
+    secret = $3,
+    updated_at = NOW()
+WHERE id = $1
+  AND operator_id = $2
+RETURNING *;
+
+-- name: CancelPendingEndpointWebhooks :execrows
+-- Deliveries still waiting for an endpoint that is being deleted fail; a
+-- delivery already in flight finishes on its own.
+UPDATE webhook_events
+SET status = 'failed',
+    error_message = 'webhook endpoint deleted',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE endpoint_id = $1
+  AND operator_id = $2
+  AND status = 'pending';
@@ -0,0 +1,27 @@
+-- name: GetWebhookRetryPolicy :one
+SELECT *
+FROM webhook_retry_policies
+WHERE operator_id = $1;
+
+-- name: UpsertWebhookRetryPolicy :one
+INSERT INTO webhook_retry_policies (
+    operator_id,
+    max_attempts,
+    max_age_seconds,
+    base_delay_seconds,
+    max_delay_seconds,
+    jitter
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (operator_id) DO UPDATE
+SET max_attempts = EXCLUDED.max_attempts,
+    max_age_seconds = EXCLUDED.max_age_seconds,
+    base_delay_seconds = EXCLUDED.base_delay_seconds,
+    max_delay_seconds = EXCLUDED.max_delay_seconds,
+    jitter = EXCLUDED.jitter,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteWebhookRetryPolicy :exec
+DELETE FROM webhook_retry_policies
+WHERE operator_id = $1;
@@ -5,64 +5,137 @@ INSERT INTO webhook_events (
     payload,
     status,
     retries,
-    next_retry_at
+    next_retry_at,
+    endpoint_id
     )
-VALUES ($1, $2, $3, 'pending', 0, NOW())
+VALUES ($1, $2, $3, 'pending', 0, sqlc.arg(now), $4)
 RETURNING *;
 
--- name: GetPendingWebhookEvents :many
-SELECT *
-FROM webhook_events
-WHERE status = 'pending'
-AND next_retry_at <= NOW()
-ORDER BY created_at
-LIMIT 50; -- small batch
-
--- name: MarkWebhookProcessing :one
+-- name: ClaimPendingWebhookEvents :many
+-- Claims due events for each operator up to per_operator less what this
+-- worker already has in flight for it (in_flight_operators and
+-- in_flight_counts pair up), so one operator's backlog cannot fill the
+-- batch or exceed its share. An event for an ordered endpoint is skipped while an earlier one to the
+-- same endpoint is still pending or processing.
 UPDATE webhook_events
 SET status = 'processing',
+    locked_by = sqlc.arg(worker_id)::text,
+    locked_until = sqlc.arg(locked_until)::timestamptz,
     updated_at = NOW()
-WHERE id = $1
+WHERE id IN (
+    SELECT id
+    FROM webhook_events
+    WHERE id IN (
+        SELECT due.id
+        FROM (
+            SELECT e.id,
+                   ROW_NUMBER() OVER (PARTITION BY e.operator_id ORDER BY e.next_retry_at, e.id) AS n,
+                   COALESCE(busy.n, 0) AS in_flight
+            FROM webhook_events e
+            LEFT JOIN webhook_endpoints ep ON ep.id = e.endpoint_id
+            LEFT JOIN unnest(sqlc.arg(in_flight_operators)::int[], sqlc.arg(in_flight_counts)::int[])
+                AS busy(operator_id, n) ON busy.operator_id = e.operator_id
+            WHERE ((e.status = 'pending' AND e.next_retry_at <= sqlc.arg(now)::timestamptz)
+                OR (e.status = 'processing' AND (e.locked_until IS NULL OR e.locked_until < sqlc.arg(now)::timestamptz)))
+              AND NOT (COALESCE(ep.ordered, FALSE) AND EXISTS (
+                  SELECT 1
+                  FROM webhook_events prev
+                  WHERE prev.endpoint_id = e.endpoint_id
+                    AND prev.id < e.id
+                    AND prev.status IN ('pending', 'processing')
+              ))
+        ) due
+        WHERE due.n <= sqlc.arg(per_operator)::int - due.in_flight
+    )
+      AND ((status = 'pending' AND next_retry_at <= sqlc.arg(now)::timestamptz)
+        OR (status = 'processing' AND (locked_until IS NULL OR locked_until < sqlc.arg(now)::timestamptz)))
+    ORDER BY next_retry_at, id
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
 RETURNING *;
 
--- name: MarkWebhookCompleted :exec
+-- name: MarkWebhookCompleted :execrows
 UPDATE webhook_events
 SET status = 'completed',
+    locked_until = NULL,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status = 'processing';
 
--- name: MarkWebhookFailed :exec
+-- name: MarkWebhookFailed :execrows
 UPDATE webhook_events
 SET status = 'failed',
+    locked_until = NULL,
     updated_at = NOW(),
     error_message = $2
-WHERE id = $1;
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status = 'processing';
+
+-- name: MarkWebhookDead :execrows
+UPDATE webhook_events
+SET status = 'dead',
+    retries = $2,
+    locked_until = NULL,
+    updated_at = NOW(),
+    error_message = $3
+WHERE id = $1
+  AND locked_by = sqlc.arg(worker_id)
+  AND status = 'processing';
 
--- name: UpdateWebhookRetry :one
+-- name: UpdateWebhookRetry :execrows
 UPDATE webhook_events
 SET retries = retries + 1,
     next_retry_at = $2,
     status = 'pending',
+    locked_until = NULL,
     updated_at = NOW(),
     error_message = $3
 WHERE id = $1
-RETURNING *;
+  AND locked_by = sqlc.arg(worker_id)
+  AND status = 'processing';
 
 -- name: GetWebhookEventByID :one
 SELECT *
 FROM webhook_events
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1;
 
--- name: ResetWebhookForRetry :exec
+-- name: ResetWebhookForRetry :execrows
+-- Only settled events: resetting one a worker holds would let a second
+-- worker claim and deliver it again.
+UPDATE webhook_events
+SET
+    status = 'pending',
+    retries = 0,
+    next_retry_at = sqlc.arg(now),
+    error_message = NULL,
+    locked_until = NULL,
+    requeued_at = sqlc.arg(now),
+    updated_at = NOW()
+WHERE id = $1
+  AND operator_id = $2
+  AND status IN ('failed', 'dead', 'completed');
+
+-- name: ReplayWebhookEvents :many
 UPDATE webhook_events
 SET
     status = 'pending',
     retries = 0,
-    next_retry_at = NOW(),
+    next_retry_at = sqlc.arg(now),
     error_message = NULL,
+    locked_until = NULL,
+    requeued_at = sqlc.arg(now),
     updated_at = NOW()
-WHERE id = $1;
+WHERE operator_id = sqlc.arg(operator_id)
+  AND status = ANY(sqlc.arg(statuses)::text[])
+  AND (sqlc.narg(event_type)::text IS NULL OR event_type = sqlc.narg(event_type))
+  AND created_at >= sqlc.arg(created_from)::timestamptz
+  AND created_at < sqlc.arg(created_to)::timestamptz
+RETURNING id;
 
 -- name: ListWebhooksByOperator :many
 SELECT *
@@ -77,4 +150,11 @@ FROM webhook_events
 WHERE operator_id = $1
   AND status = $2
 ORDER BY id DESC
-    LIMIT 200;
\ No newline at end of file
+    LIMIT 200;
+
+-- name: CountQueuedWebhooksByOperator :many
+SELECT operator_id, COUNT(*)::int AS queued
+FROM webhook_events
+WHERE status IN ('pending', 'processing')
+GROUP BY operator_id
+ORDER BY operator_id;
@@ -47,10 +47,16 @@ func (q *Queries) CreateRound(ctx context.Context, arg CreateRoundParams) (Round
 const getRound = `-- name: GetRound :one
 SELECT id, operator_id, player_id, server_seed, client_seed, outcome, created_at FROM rounds
 WHERE id = $1
+  AND operator_id = $2
 `
 
-func (q *Queries) GetRound(ctx context.Context, id int32) (Round, error) {
-	row := q.db.QueryRowContext(ctx, getRound, id)
+type GetRoundParams struct {
+	ID         int32 `json:"id"`
+	OperatorID int32 `json:"operator_id"`
+}
+
+func (q *Queries) GetRound(ctx context.Context, arg GetRoundParams) (Round, error) {
+	row := q.db.QueryRowContext(ctx, getRound, arg.ID, arg.OperatorID)
 	var i Round
 	err := row.Scan(
 		&i.ID,
@@ -13,18 +13,25 @@ import (
 )
 
 const createOperator = `-- name: CreateOperator :one
-INSERT INTO operators (name, api_key)
-VALUES ($1, $2)
+INSERT INTO operators (name, api_key, webhook_url, webhook_secret)
+VALUES ($1, $2, $3, $4)
     RETURNING id, name, api_key, webhook_url, webhook_secret, created_at
 `
 
 type CreateOperatorParams struct {
-	Name   string `json:"name"`
-	ApiKey string `json:"api_key"`
+	Name          string `json:"name"`
+	ApiKey        string `json:"api_key"`
+	WebhookUrl    string `json:"webhook_url"`
+	WebhookSecret string `json:"webhook_secret"`
 }
 
 func (q *Queries) CreateOperator(ctx context.Context, arg CreateOperatorParams) (Operator, error) {
-	row := q.db.QueryRowContext(ctx, createOperator, arg.Name, arg.ApiKey)
+	row := q.db.QueryRowContext(ctx, createOperator,
+		arg.Name,
+		arg.ApiKey,
+		arg.WebhookUrl,
+		arg.WebhookSecret,
+	)
 	var i Operator
 	err := row.Scan(
 		&i.ID,
@@ -40,7 +47,7 @@ func (q *Queries) CreateOperator(ctx context.Context, arg CreateOperatorParams)
 const createPlayer = `-- name: CreatePlayer :one
 INSERT INTO players (operator_id, external_player_id, jurisdiction)
 VALUES ($1, $2, $3)
-    RETURNING id, operator_id, external_player_id, jurisdiction, created_at
+    RETURNING id, operator_id, external_player_id, jurisdiction, created_at, blocked
 `
 
 type CreatePlayerParams struct {
@@ -58,6 +65,7 @@ func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (Pla
 		&i.ExternalPlayerID,
 		&i.Jurisdiction,
 		&i.CreatedAt,
+		&i.Blocked,
 	)
 	return i, err
 }
@@ -136,7 +144,7 @@ func (q *Queries) GetOperatorByID(ctx context.Context, id int32) (Operator, erro
 }
 
 const getPlayer = `-- name: GetPlayer :one
-SELECT id, operator_id, external_player_id, jurisdiction, created_at FROM players
+SELECT id, operator_id, external_player_id, jurisdiction, created_at, blocked FROM players
 WHERE operator_id = $1 AND external_player_id = $2
     LIMIT 1
 `
@@ -155,19 +163,26 @@ func (q *Queries) GetPlayer(ctx context.Context, arg GetPlayerParams) (Player, e
 		&i.ExternalPlayerID,
 		&i.Jurisdiction,
 		&i.CreatedAt,
+		&i.Blocked,
 	)
 	return i, err
 }
 
 const getPlayerByID = `-- name: GetPlayerByID :one
-SELECT id, operator_id, external_player_id, jurisdiction, created_at
+SELECT id, operator_id, external_player_id, jurisdiction, created_at, blocked
 FROM players
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1
 `
 
-func (q *Queries) GetPlayerByID(ctx context.Context, id int32) (Player, error) {
-	row := q.db.QueryRowContext(ctx, getPlayerByID, id)
+type GetPlayerByIDParams struct {
+	ID         int32 `json:"id"`
+	OperatorID int32 `json:"operator_id"`
+}
+
+func (q *Queries) GetPlayerByID(ctx context.Context, arg GetPlayerByIDParams) (Player, error) {
+	row := q.db.QueryRowContext(ctx, getPlayerByID, arg.ID, arg.OperatorID)
 	var i Player
 	err := row.Scan(
 		&i.ID,
@@ -175,18 +190,38 @@ func (q *Queries) GetPlayerByID(ctx context.Context, id int32) (Player, error) {
 		&i.ExternalPlayerID,
 		&i.Jurisdiction,
 		&i.CreatedAt,
+		&i.Blocked,
 	)
 	return i, err
 }
 
+const getPlayerOperatorID = `-- name: GetPlayerOperatorID :one
+SELECT operator_id
+FROM players
+WHERE id = $1
+`
+
+func (q *Queries) GetPlayerOperatorID(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getPlayerOperatorID, id)
+	var operator_id int32
+	err := row.Scan(&operator_id)
+	return operator_id, err
+}
+
 const getSession = `-- name: GetSession :one
 SELECT id, operator_id, player_id, launch_token, expires_at, revoked, created_at FROM sessions
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1
 `
 
-func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
-	row := q.db.QueryRowContext(ctx, getSession, id)
+type GetSessionParams struct {
+	ID         uuid.UUID `json:"id"`
+	OperatorID int32     `json:"operator_id"`
+}
+
+func (q *Queries) GetSession(ctx context.Context, arg GetSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, arg.ID, arg.OperatorID)
 	var i Session
 	err := row.Scan(
 		&i.ID,
@@ -200,15 +235,86 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	return i, err
 }
 
-const revokeSession = `-- name: RevokeSession :exec
+const revokeSession = `-- name: RevokeSession :one
 UPDATE sessions
 SET revoked = TRUE
 WHERE id = $1
+  AND operator_id = $2
+    RETURNING id, operator_id, player_id, launch_token, expires_at, revoked, created_at
 `
 
-func (q *Queries) RevokeSession(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, revokeSession, id)
-	return err
+type RevokeSessionParams struct {
+	ID         uuid.UUID `json:"id"`
+	OperatorID int32     `json:"operator_id"`
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, revokeSession, arg.ID, arg.OperatorID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.OperatorID,
+		&i.PlayerID,
+		&i.LaunchToken,
+		&i.ExpiresAt,
+		&i.Revoked,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const rotateOperatorApiKey = `-- name: RotateOperatorApiKey :one
+UPDATE operators
+SET api_key = $2
+WHERE id = $1
+    RETURNING id, name, api_key, webhook_url, webhook_secret, created_at
+`
+
+type RotateOperatorApiKeyParams struct {
+	ID     int32  `json:"id"`
+	ApiKey string `json:"api_key"`
+}
+
+func (q *Queries) RotateOperatorApiKey(ctx context.Context, arg RotateOperatorApiKeyParams) (Operator, error) {
+	row := q.db.QueryRowContext(ctx, rotateOperatorApiKey, arg.ID, arg.ApiKey)
+	var i Operator
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ApiKey,
+		&i.WebhookUrl,
+		&i.WebhookSecret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setPlayerBlocked = `-- name: SetPlayerBlocked :one
+UPDATE players
+SET blocked = $3
+WHERE id = $1
+  AND operator_id = $2
+    RETURNING id, operator_id, external_player_id, jurisdiction, created_at, blocked
+`
+
+type SetPlayerBlockedParams struct {
+	ID         int32 `json:"id"`
+	OperatorID int32 `json:"operator_id"`
+	Blocked    bool  `json:"blocked"`
+}
+
+func (q *Queries) SetPlayerBlocked(ctx context.Context, arg SetPlayerBlockedParams) (Player, error) {
+	row := q.db.QueryRowContext(ctx, setPlayerBlocked, arg.ID, arg.OperatorID, arg.Blocked)
+	var i Player
+	err := row.Scan(
+		&i.ID,
+		&i.OperatorID,
+		&i.ExternalPlayerID,
+		&i.Jurisdiction,
+		&i.CreatedAt,
+		&i.Blocked,
+	)
+	return i, err
 }
 
 const verifySessionByToken = `-- name: VerifySessionByToken :one
@@ -217,17 +323,18 @@ FROM sessions
 WHERE launch_token = $1
   AND operator_id = $2
   AND revoked = FALSE
-  AND expires_at > NOW()
+  AND expires_at > $3::timestamptz
     LIMIT 1
 `
 
 type VerifySessionByTokenParams struct {
-	LaunchToken string `json:"launch_token"`
-	OperatorID  int32  `json:"operator_id"`
+	LaunchToken string    `json:"launch_token"`
+	OperatorID  int32     `json:"operator_id"`
+	Now         time.Time `json:"now"`
 }
 
 func (q *Queries) VerifySessionByToken(ctx context.Context, arg VerifySessionByTokenParams) (Session, error) {
-	row := q.db.QueryRowContext(ctx, verifySessionByToken, arg.LaunchToken, arg.OperatorID)
+	row := q.db.QueryRowContext(ctx, verifySessionByToken, arg.LaunchToken, arg.OperatorID, arg.Now)
 	var i Session
 	err := row.Scan(
 		&i.ID,
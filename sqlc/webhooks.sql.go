@@ -10,19 +10,70 @@ import (
 	"database/sql"
 	"encoding/json"
 	"time"
+
+	"github.com/lib/pq"
 )
 
-const getPendingWebhookEvents = `-- name: GetPendingWebhookEvents :many
-SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
-FROM webhook_events
-WHERE status = 'pending'
-AND next_retry_at <= NOW()
-ORDER BY created_at
-LIMIT 50
+const claimPendingWebhookEvents = `-- name: ClaimPendingWebhookEvents :many
+UPDATE webhook_events
+SET status = 'processing',
+    locked_by = $1::text,
+    locked_until = $2::timestamptz,
+    updated_at = NOW()
+WHERE id IN (
+    SELECT id
+    FROM webhook_events
+    WHERE id IN (
+        SELECT due.id
+        FROM (
+            SELECT e.id,
+                   ROW_NUMBER() OVER (PARTITION BY e.operator_id ORDER BY e.next_retry_at, e.id) AS n,
+                   COALESCE(busy.n, 0) AS in_flight
+            FROM webhook_events e
+            LEFT JOIN webhook_endpoints ep ON ep.id = e.endpoint_id
+            LEFT JOIN unnest($3::int[], $4::int[])
+                AS busy(operator_id, n) ON busy.operator_id = e.operator_id
+            WHERE ((e.status = 'pending' AND e.next_retry_at <= $5::timestamptz)
+                OR (e.status = 'processing' AND (e.locked_until IS NULL OR e.locked_until < $5::timestamptz)))
+              AND NOT (COALESCE(ep.ordered, FALSE) AND EXISTS (
+                  SELECT 1
+                  FROM webhook_events prev
+                  WHERE prev.endpoint_id = e.endpoint_id
+                    AND prev.id < e.id
+                    AND prev.status IN ('pending', 'processing')
+              ))
+        ) due
+        WHERE due.n <= $6::int - due.in_flight
+    )
+      AND ((status = 'pending' AND next_retry_at <= $5::timestamptz)
+        OR (status = 'processing' AND (locked_until IS NULL OR locked_until < $5::timestamptz)))
+    ORDER BY next_retry_at, id
+    LIMIT $7::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at, locked_by, locked_until, endpoint_id, requeued_at
 `
 
-func (q *Queries) GetPendingWebhookEvents(ctx context.Context) ([]WebhookEvent, error) {
-	rows, err := q.db.QueryContext(ctx, getPendingWebhookEvents)
+type ClaimPendingWebhookEventsParams struct {
+	WorkerID          string    `json:"worker_id"`
+	LockedUntil       time.Time `json:"locked_until"`
+	Now               time.Time `json:"now"`
+	InFlightOperators []int32   `json:"in_flight_operators"`
+	InFlightCounts    []int32   `json:"in_flight_counts"`
+	PerOperator       int32     `json:"per_operator"`
+	BatchSize         int32     `json:"batch_size"`
+}
+
+func (q *Queries) ClaimPendingWebhookEvents(ctx context.Context, arg ClaimPendingWebhookEventsParams) ([]WebhookEvent, error) {
+	rows, err := q.db.QueryContext(ctx, claimPendingWebhookEvents,
+		arg.WorkerID,
+		arg.LockedUntil,
+		arg.Now,
+		pq.Array(arg.InFlightOperators),
+		pq.Array(arg.InFlightCounts),
+		arg.PerOperator,
+		arg.BatchSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +92,10 @@ func (q *Queries) GetPendingWebhookEvents(ctx context.Context) ([]WebhookEvent,
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.EndpointID,
+			&i.RequeuedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -55,15 +110,57 @@ func (q *Queries) GetPendingWebhookEvents(ctx context.Context) ([]WebhookEvent,
 	return items, nil
 }
 
+const countQueuedWebhooksByOperator = `-- name: CountQueuedWebhooksByOperator :many
+SELECT operator_id, COUNT(*)::int AS queued
+FROM webhook_events
+WHERE status IN ('pending', 'processing')
+GROUP BY operator_id
+ORDER BY operator_id
+`
+
+type CountQueuedWebhooksByOperatorRow struct {
+	OperatorID int32 `json:"operator_id"`
+	Queued     int32 `json:"queued"`
+}
+
+func (q *Queries) CountQueuedWebhooksByOperator(ctx context.Context) ([]CountQueuedWebhooksByOperatorRow, error) {
+	rows, err := q.db.QueryContext(ctx, countQueuedWebhooksByOperator)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountQueuedWebhooksByOperatorRow
+	for rows.Next() {
+		var i CountQueuedWebhooksByOperatorRow
+		if err := rows.Scan(&i.OperatorID, &i.Queued); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWebhookEventByID = `-- name: GetWebhookEventByID :one
-SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at, locked_by, locked_until, endpoint_id, requeued_at
 FROM webhook_events
 WHERE id = $1
+  AND operator_id = $2
     LIMIT 1
 `
 
-func (q *Queries) GetWebhookEventByID(ctx context.Context, id int32) (WebhookEvent, error) {
-	row := q.db.QueryRowContext(ctx, getWebhookEventByID, id)
+type GetWebhookEventByIDParams struct {
+	ID         int32 `json:"id"`
+	OperatorID int32 `json:"operator_id"`
+}
+
+func (q *Queries) GetWebhookEventByID(ctx context.Context, arg GetWebhookEventByIDParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEventByID, arg.ID, arg.OperatorID)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -76,6 +173,10 @@ func (q *Queries) GetWebhookEventByID(ctx context.Context, id int32) (WebhookEve
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.EndpointID,
+		&i.RequeuedAt,
 	)
 	return i, err
 }
@@ -87,20 +188,29 @@ INSERT INTO webhook_events (
     payload,
     status,
     retries,
-    next_retry_at
+    next_retry_at,
+    endpoint_id
     )
-VALUES ($1, $2, $3, 'pending', 0, NOW())
-RETURNING id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+VALUES ($1, $2, $3, 'pending', 0, $5, $4)
+RETURNING id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at, locked_by, locked_until, endpoint_id, requeued_at
 `
 
 type InsertWebhookEventParams struct {
 	OperatorID int32           `json:"operator_id"`
 	EventType  string          `json:"event_type"`
 	Payload    json.RawMessage `json:"payload"`
+	EndpointID sql.NullInt32   `json:"endpoint_id"`
+	Now        time.Time       `json:"now"`
 }
 
 func (q *Queries) InsertWebhookEvent(ctx context.Context, arg InsertWebhookEventParams) (WebhookEvent, error) {
-	row := q.db.QueryRowContext(ctx, insertWebhookEvent, arg.OperatorID, arg.EventType, arg.Payload)
+	row := q.db.QueryRowContext(ctx, insertWebhookEvent,
+		arg.OperatorID,
+		arg.EventType,
+		arg.Payload,
+		arg.EndpointID,
+		arg.Now,
+	)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -113,12 +223,16 @@ func (q *Queries) InsertWebhookEvent(ctx context.Context, arg InsertWebhookEvent
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.EndpointID,
+		&i.RequeuedAt,
 	)
 	return i, err
 }
 
 const listWebhooksByOperator = `-- name: ListWebhooksByOperator :many
-SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at, locked_by, locked_until, endpoint_id, requeued_at
 FROM webhook_events
 WHERE operator_id = $1
 ORDER BY id DESC
@@ -145,6 +259,10 @@ func (q *Queries) ListWebhooksByOperator(ctx context.Context, operatorID int32)
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.EndpointID,
+			&i.RequeuedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -160,7 +278,7 @@ func (q *Queries) ListWebhooksByOperator(ctx context.Context, operatorID int32)
 }
 
 const listWebhooksByOperatorStatus = `-- name: ListWebhooksByOperatorStatus :many
-SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+SELECT id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at, locked_by, locked_until, endpoint_id, requeued_at
 FROM webhook_events
 WHERE operator_id = $1
   AND status = $2
@@ -193,6 +311,10 @@ func (q *Queries) ListWebhooksByOperatorStatus(ctx context.Context, arg ListWebh
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.EndpointID,
+			&i.RequeuedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -207,111 +329,201 @@ func (q *Queries) ListWebhooksByOperatorStatus(ctx context.Context, arg ListWebh
 	return items, nil
 }
 
-const markWebhookCompleted = `-- name: MarkWebhookCompleted :exec
+const markWebhookCompleted = `-- name: MarkWebhookCompleted :execrows
 UPDATE webhook_events
 SET status = 'completed',
+    locked_until = NULL,
     updated_at = NOW()
 WHERE id = $1
+  AND locked_by = $2
+  AND status = 'processing'
+`
+
+type MarkWebhookCompletedParams struct {
+	ID       int32  `json:"id"`
+	WorkerID string `json:"worker_id"`
+}
+
+func (q *Queries) MarkWebhookCompleted(ctx context.Context, arg MarkWebhookCompletedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markWebhookCompleted, arg.ID, arg.WorkerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markWebhookDead = `-- name: MarkWebhookDead :execrows
+UPDATE webhook_events
+SET status = 'dead',
+    retries = $2,
+    locked_until = NULL,
+    updated_at = NOW(),
+    error_message = $3
+WHERE id = $1
+  AND locked_by = $4
+  AND status = 'processing'
 `
 
-func (q *Queries) MarkWebhookCompleted(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, markWebhookCompleted, id)
-	return err
+type MarkWebhookDeadParams struct {
+	ID           int32          `json:"id"`
+	Retries      int32          `json:"retries"`
+	ErrorMessage sql.NullString `json:"error_message"`
+	WorkerID     string         `json:"worker_id"`
+}
+
+func (q *Queries) MarkWebhookDead(ctx context.Context, arg MarkWebhookDeadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markWebhookDead,
+		arg.ID,
+		arg.Retries,
+		arg.ErrorMessage,
+		arg.WorkerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const markWebhookFailed = `-- name: MarkWebhookFailed :exec
+const markWebhookFailed = `-- name: MarkWebhookFailed :execrows
 UPDATE webhook_events
 SET status = 'failed',
+    locked_until = NULL,
     updated_at = NOW(),
     error_message = $2
 WHERE id = $1
+  AND locked_by = $3
+  AND status = 'processing'
 `
 
 type MarkWebhookFailedParams struct {
 	ID           int32          `json:"id"`
 	ErrorMessage sql.NullString `json:"error_message"`
+	WorkerID     string         `json:"worker_id"`
 }
 
-func (q *Queries) MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) error {
-	_, err := q.db.ExecContext(ctx, markWebhookFailed, arg.ID, arg.ErrorMessage)
-	return err
+func (q *Queries) MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markWebhookFailed, arg.ID, arg.ErrorMessage, arg.WorkerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const markWebhookProcessing = `-- name: MarkWebhookProcessing :one
-
+const replayWebhookEvents = `-- name: ReplayWebhookEvents :many
 UPDATE webhook_events
-SET status = 'processing',
+SET
+    status = 'pending',
+    retries = 0,
+    next_retry_at = $1,
+    error_message = NULL,
+    locked_until = NULL,
+    requeued_at = $1,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+WHERE operator_id = $2
+  AND status = ANY($3::text[])
+  AND ($4::text IS NULL OR event_type = $4)
+  AND created_at >= $5::timestamptz
+  AND created_at < $6::timestamptz
+RETURNING id
 `
 
-// small batch
-func (q *Queries) MarkWebhookProcessing(ctx context.Context, id int32) (WebhookEvent, error) {
-	row := q.db.QueryRowContext(ctx, markWebhookProcessing, id)
-	var i WebhookEvent
-	err := row.Scan(
-		&i.ID,
-		&i.OperatorID,
-		&i.EventType,
-		&i.Payload,
-		&i.Status,
-		&i.Retries,
-		&i.NextRetryAt,
-		&i.ErrorMessage,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+type ReplayWebhookEventsParams struct {
+	Now         time.Time      `json:"now"`
+	OperatorID  int32          `json:"operator_id"`
+	Statuses    []string       `json:"statuses"`
+	EventType   sql.NullString `json:"event_type"`
+	CreatedFrom time.Time      `json:"created_from"`
+	CreatedTo   time.Time      `json:"created_to"`
+}
+
+func (q *Queries) ReplayWebhookEvents(ctx context.Context, arg ReplayWebhookEventsParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, replayWebhookEvents,
+		arg.Now,
+		arg.OperatorID,
+		pq.Array(arg.Statuses),
+		arg.EventType,
+		arg.CreatedFrom,
+		arg.CreatedTo,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const resetWebhookForRetry = `-- name: ResetWebhookForRetry :exec
+const resetWebhookForRetry = `-- name: ResetWebhookForRetry :execrows
 UPDATE webhook_events
 SET
     status = 'pending',
     retries = 0,
-    next_retry_at = NOW(),
+    next_retry_at = $3,
     error_message = NULL,
+    locked_until = NULL,
+    requeued_at = $3,
     updated_at = NOW()
 WHERE id = $1
+  AND operator_id = $2
+  AND status IN ('failed', 'dead', 'completed')
 `
 
-func (q *Queries) ResetWebhookForRetry(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, resetWebhookForRetry, id)
-	return err
+type ResetWebhookForRetryParams struct {
+	ID         int32     `json:"id"`
+	OperatorID int32     `json:"operator_id"`
+	Now        time.Time `json:"now"`
 }
 
-const updateWebhookRetry = `-- name: UpdateWebhookRetry :one
+func (q *Queries) ResetWebhookForRetry(ctx context.Context, arg ResetWebhookForRetryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetWebhookForRetry, arg.ID, arg.OperatorID, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateWebhookRetry = `-- name: UpdateWebhookRetry :execrows
 UPDATE webhook_events
 SET retries = retries + 1,
     next_retry_at = $2,
     status = 'pending',
+    locked_until = NULL,
     updated_at = NOW(),
     error_message = $3
 WHERE id = $1
-RETURNING id, operator_id, event_type, payload, status, retries, next_retry_at, error_message, created_at, updated_at
+  AND locked_by = $4
+  AND status = 'processing'
 `
 
 type UpdateWebhookRetryParams struct {
 	ID           int32          `json:"id"`
 	NextRetryAt  time.Time      `json:"next_retry_at"`
 	ErrorMessage sql.NullString `json:"error_message"`
+	WorkerID     string         `json:"worker_id"`
 }
 
-func (q *Queries) UpdateWebhookRetry(ctx context.Context, arg UpdateWebhookRetryParams) (WebhookEvent, error) {
-	row := q.db.QueryRowContext(ctx, updateWebhookRetry, arg.ID, arg.NextRetryAt, arg.ErrorMessage)
-	var i WebhookEvent
-	err := row.Scan(
-		&i.ID,
-		&i.OperatorID,
-		&i.EventType,
-		&i.Payload,
-		&i.Status,
-		&i.Retries,
-		&i.NextRetryAt,
-		&i.ErrorMessage,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+func (q *Queries) UpdateWebhookRetry(ctx context.Context, arg UpdateWebhookRetryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWebhookRetry,
+		arg.ID,
+		arg.NextRetryAt,
+		arg.ErrorMessage,
+		arg.WorkerID,
 	)
-	return i, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"rgs/sqlc"
+	"testing"
+	"time"
+
+	"walletmock"
+)
+
+func TestBetsSettleWinsAndLosses(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 1000)
+
+	won := h.betUntil(t, player, "won")
+	lost := h.betUntil(t, player, "lost")
+
+	if b := h.bet(t, won); b.WinAmount != 5 || b.Outcome != 6 {
+		t.Fatalf("won bet = %+v", b)
+	}
+	if b := h.bet(t, lost); b.WinAmount != 0 || b.Outcome == 6 {
+		t.Fatalf("lost bet = %+v", b)
+	}
+
+	// Every bet staked 1.00 and every win paid 5.00.
+	var staked, paid float64
+	for _, tx := range h.wallet.PlayerTransactions(player, 1000) {
+		switch tx.Type {
+		case "debit":
+			staked += tx.Amount
+		case "credit":
+			paid += tx.Amount
+		}
+	}
+	if got, want := h.balance(t, player), 1000-staked+paid; got != want {
+		t.Fatalf("balance = %.2f, want %.2f", got, want)
+	}
+}
+
+func TestBetReplayIsIdempotent(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 100)
+
+	first := h.placeBet(t, player, 10, "replay-1")
+	second := h.placeBet(t, player, 10, "replay-1")
+	if first != second {
+		t.Fatalf("replay returned %+v, first was %+v", second, first)
+	}
+
+	bet := h.bet(t, "replay-1")
+	if got, want := h.balance(t, player), 100-10+bet.WinAmount; got != want {
+		t.Fatalf("balance = %.2f, want %.2f (debited once)", got, want)
+	}
+}
+
+func TestInsufficientFundsRejectsBet(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 5)
+
+	status := h.do(t, http.MethodPost, "/bets", map[string]any{
+		"player_id":       player,
+		"amount":          10,
+		"idempotency_key": "broke-1",
+	}, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", status)
+	}
+	if got := h.balance(t, player); got != 5 {
+		t.Fatalf("balance = %.2f, want 5", got)
+	}
+}
+
+func TestOutboxSettlesWinAfterCreditFailure(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 1000)
+
+	// The inline credit of the first win fails; the outbox retry succeeds.
+	if _, err := h.faults.Add(walletmock.Fault{
+		Endpoint:  "credit",
+		Action:    walletmock.ActionStatus,
+		Status:    http.StatusServiceUnavailable,
+		Remaining: 1,
+	}); err != nil {
+		t.Fatalf("add fault: %v", err)
+	}
+
+	// The outbox worker may settle the win before the test looks, so find
+	// the first win by outcome rather than by pending status.
+	var bet sqlc.Bet
+	key := ""
+	for i := 0; i < 200 && key == ""; i++ {
+		k := fmt.Sprintf("outbox-%d", i)
+		h.placeBet(t, player, 1, k)
+		if b := h.bet(t, k); b.Outcome == 6 {
+			key, bet = k, b
+		}
+	}
+	if key == "" {
+		t.Fatal("no winning bet")
+	}
+
+	eventually(t, 20*time.Second, "outbox to settle the win", func() bool {
+		return h.bet(t, key).Status == "won"
+	})
+
+	var entries []sqlc.Outbox
+	if status := h.do(t, http.MethodGet, "/outbox?status=succeeded", nil, &entries); status != http.StatusOK {
+		t.Fatalf("list outbox: status %d", status)
+	}
+	if len(entries) != 1 || entries[0].BetID != bet.ID {
+		t.Fatalf("succeeded outbox = %+v", entries)
+	}
+
+	credits := 0
+	for _, tx := range h.wallet.PlayerTransactions(player, 1000) {
+		if tx.RequestID == fmt.Sprintf("bet-%d-win", bet.ID) {
+			credits++
+		}
+	}
+	if credits != 1 {
+		t.Fatalf("win credited %d times, want 1", credits)
+	}
+}
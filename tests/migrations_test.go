@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"rgs/migrations"
+	"testing"
+)
+
+// migrateScratch applies the migrations up to and including version to a
+// throwaway schema and returns a connection whose search_path is set to
+// it, so a test can seed rows the way an older release left them.
+func migrateScratch(t *testing.T, version int64) (context.Context, *sql.Conn) {
+	t.Helper()
+
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	ctx := context.Background()
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("conn: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = conn.ExecContext(ctx, "DROP SCHEMA IF EXISTS migration_scratch CASCADE")
+		_ = conn.Close()
+	})
+
+	_, err = conn.ExecContext(ctx, `
+		DROP SCHEMA IF EXISTS migration_scratch CASCADE;
+		CREATE SCHEMA migration_scratch;
+		SET search_path TO migration_scratch;`)
+	if err != nil {
+		t.Fatalf("scratch schema: %v", err)
+	}
+
+	applyMigrations(t, ctx, conn, 1, version)
+	return ctx, conn
+}
+
+// applyMigrations runs the up migrations from..to inclusive on conn.
+func applyMigrations(t *testing.T, ctx context.Context, conn *sql.Conn, from, to int64) {
+	t.Helper()
+
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	for _, m := range all {
+		if m.Version < from || m.Version > to {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, m.Up); err != nil {
+			t.Fatalf("migration %d_%s: %v", m.Version, m.Name, err)
+		}
+	}
+}
+
+func migration(t *testing.T, version int64) migrations.Migration {
+	t.Helper()
+
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	for _, m := range all {
+		if m.Version == version {
+			return m
+		}
+	}
+	t.Fatalf("no migration %d", version)
+	return migrations.Migration{}
+}
+
+func TestCreditTxIDBackfillAllowsSharedIdempotencyKeys(t *testing.T) {
+	ctx, conn := migrateScratch(t, 7)
+
+	// Two operators whose players each have an outbox entry for a bet
+	// placed with the same idempotency key.
+	_, err := conn.ExecContext(ctx, `
+		INSERT INTO operators (name, api_key, webhook_url, webhook_secret)
+		VALUES ('a', 'key-a', 'http://a', 's'), ('b', 'key-b', 'http://b', 's');
+		INSERT INTO players (operator_id, external_player_id, jurisdiction)
+		SELECT id, 'p1', 'MT' FROM operators WHERE api_key IN ('key-a', 'key-b');
+		INSERT INTO rounds (operator_id, player_id, server_seed, client_seed, outcome)
+		SELECT operator_id, id, 's', 'c', 1 FROM players WHERE external_player_id = 'p1';
+		INSERT INTO bets (operator_id, player_id, round_id, amount, outcome, status, idempotency_key)
+		SELECT operator_id, player_id, id, 10, 1, 'pending', 'shared' FROM rounds;
+		INSERT INTO outbox (bet_id, operator_id, player_id, amount)
+		SELECT id, operator_id, player_id, 20 FROM bets;`)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, migration(t, 8).Up); err != nil {
+		t.Fatalf("migration 8: %v", err)
+	}
+
+	var n int
+	err = conn.QueryRowContext(ctx, `SELECT count(*) FROM outbox WHERE credit_tx_id = 'shared-win'`).Scan(&n)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("backfilled %d entries with shared-win, want 2", n)
+	}
+
+	// Still unique within an operator.
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO outbox (bet_id, operator_id, player_id, amount, credit_tx_id)
+		SELECT bet_id, operator_id, player_id, amount, credit_tx_id FROM outbox LIMIT 1`)
+	if err == nil {
+		t.Fatal("duplicate credit_tx_id within one operator was accepted")
+	}
+}
+
+func TestLegacyWebhooksMoveToTheMigratedEndpoint(t *testing.T) {
+	ctx, conn := migrateScratch(t, 10)
+
+	_, err := conn.ExecContext(ctx, `
+		INSERT INTO operators (name, api_key, webhook_url, webhook_secret)
+		VALUES ('a', 'key-a', 'http://a', 's'), ('b', 'key-b', '', 's');
+		INSERT INTO webhook_events (operator_id, event_type, payload)
+		SELECT id, 'bet_settled', '{}' FROM operators WHERE api_key IN ('key-a', 'key-b');`)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	// 0018 sets up the cluster-wide rgs_api role, which 0019 does not
+	// depend on.
+	applyMigrations(t, ctx, conn, 11, 17)
+	if _, err := conn.ExecContext(ctx, migration(t, 19).Up); err != nil {
+		t.Fatalf("migration 19: %v", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT o.api_key, e.status, ep.url
+		FROM webhook_events e
+		JOIN operators o ON o.id = e.operator_id
+		LEFT JOIN webhook_endpoints ep ON ep.id = e.endpoint_id
+		ORDER BY o.api_key`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+
+	got := map[string]string{}
+	for rows.Next() {
+		var key, status string
+		var url sql.NullString
+		if err := rows.Scan(&key, &status, &url); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got[key] = status + " " + url.String
+	}
+	if got["key-a"] != "pending http://a" {
+		t.Errorf("operator with webhook_url: %q, want pending to its endpoint", got["key-a"])
+	}
+	if got["key-b"] != "dead " {
+		t.Errorf("operator without webhook_url: %q, want dead", got["key-b"])
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"rgs/services"
+	"rgs/sqlc"
+	"testing"
+	"time"
+
+	"walletmock"
+)
+
+// A win whose credit the outbox delivers after midnight is reconciled
+// with its bet's day, and the next day does not report the credit as
+// unexpected.
+func TestReconciliationMatchesCreditsAcrossMidnight(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 1000)
+
+	// The inline credit and the outbox's first attempt fail, so the win
+	// is credited a backoff later.
+	if _, err := h.faults.Add(walletmock.Fault{
+		Endpoint:  "credit",
+		Action:    walletmock.ActionStatus,
+		Status:    http.StatusServiceUnavailable,
+		Remaining: 2,
+	}); err != nil {
+		t.Fatalf("add fault: %v", err)
+	}
+
+	key := ""
+	for i := 0; i < 200 && key == ""; i++ {
+		k := fmt.Sprintf("midnight-%d", i)
+		h.placeBet(t, player, 1, k)
+		if h.bet(t, k).Outcome == 6 {
+			key = k
+		}
+	}
+	if key == "" {
+		t.Fatal("no winning bet")
+	}
+	midnight := time.Now()
+
+	eventually(t, 20*time.Second, "outbox to settle the win", func() bool {
+		return h.bet(t, key).Status == "won"
+	})
+	bet := h.bet(t, key)
+	for _, tx := range h.wallet.PlayerTransactions(player, 1000) {
+		if tx.RequestID == services.CreditTxID(bet.ID) && !tx.CreatedAt.After(midnight) {
+			t.Fatalf("win credited at %v, before the window ends at %v", tx.CreatedAt, midnight)
+		}
+	}
+
+	wallet := services.NewWalletClient(h.walletURL, walletmock.Secret, 5*time.Second)
+	svc := services.NewReconciliationService(h.queries, wallet, services.DefaultOutboxRetryPolicy)
+
+	day, err := svc.Reconcile(h.ctx, midnight.Add(-time.Hour), midnight)
+	if err != nil {
+		t.Fatalf("reconcile the bet's day: %v", err)
+	}
+	next, err := svc.Reconcile(h.ctx, midnight, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("reconcile the next day: %v", err)
+	}
+
+	if day.Discrepancies != 0 || next.Discrepancies != 0 {
+		found, _ := h.queries.ListDiscrepanciesByOperator(h.ctx, sqlc.ListDiscrepanciesByOperatorParams{
+			OperatorID: sql.NullInt32{Int32: h.operator.ID, Valid: true},
+			Limit:      100,
+		})
+		t.Fatalf("discrepancies: bet's day %d, next day %d: %+v", day.Discrepancies, next.Discrepancies, found)
+	}
+}
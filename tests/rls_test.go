@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"rgs/app"
+	"rgs/services"
+	"rgs/sqlc"
+	"testing"
+	"time"
+)
+
+// rlsURL connects as dsn's user but acts as rgs_api, which the policies
+// apply to. The user must be allowed to SET ROLE rgs_api, as the
+// docker compose superuser is.
+func rlsURL(t *testing.T, dsn string) string {
+	t.Helper()
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", dsnEnv, err)
+	}
+	q := u.Query()
+	q.Set("options", "-c role=rgs_api")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func withRowLevelSecurity(t *testing.T) func(*app.Config) {
+	return func(cfg *app.Config) {
+		cfg.Database.APIURL = rlsURL(t, cfg.Database.URL)
+	}
+}
+
+func TestRowLevelSecurityModeServesRequests(t *testing.T) {
+	h := newHarness(t, withRowLevelSecurity(t))
+	intruder := h.as(t, "intruder")
+
+	player := h.player(t, "player-1", 100)
+	placed := h.placeBet(t, player, 1, "rls-1")
+
+	// Workers run on the privileged pool and still deliver.
+	d := h.receiver.next(t, 10*time.Second)
+	if !verifySignature(h.operator.WebhookSecret, d.Header.Get("X-RGS-Timestamp"), d.Header.Get("X-RGS-Signature"), d.Body) {
+		t.Fatal("webhook not signed")
+	}
+
+	if status := h.do(t, http.MethodGet, fmt.Sprintf("/rounds/%d", placed.RoundID), nil, nil); status != http.StatusOK {
+		t.Fatalf("owner round: status %d", status)
+	}
+	var audit []sqlc.AuditLog
+	if status := h.do(t, http.MethodGet, "/audit", nil, &audit); status != http.StatusOK || len(audit) == 0 {
+		t.Fatalf("owner audit: status %d, %d entries", status, len(audit))
+	}
+
+	if status := intruder.do(t, http.MethodGet, fmt.Sprintf("/rounds/%d", placed.RoundID), nil, nil); status != http.StatusNotFound {
+		t.Fatalf("intruder round: status %d, want 404", status)
+	}
+	audit = nil
+	if status := intruder.do(t, http.MethodGet, "/audit", nil, &audit); status != http.StatusOK || len(audit) != 0 {
+		t.Fatalf("intruder audit: status %d, %d entries", status, len(audit))
+	}
+}
+
+// The policies hold even for a query that names another operator.
+func TestPoliciesHideOtherOperatorsRows(t *testing.T) {
+	h := newHarness(t)
+	intruder := h.as(t, "intruder")
+	player := h.launch(t, "player-1").Session.PlayerID
+
+	apiDB, err := sql.Open("pgx", rlsURL(t, os.Getenv(dsnEnv)))
+	if err != nil {
+		t.Fatalf("open api db: %v", err)
+	}
+	defer apiDB.Close()
+	// One connection, so the unpinned queries below reuse the pinned one.
+	apiDB.SetMaxOpenConns(1)
+
+	tenants := services.NewTenantDB(apiDB)
+	q := sqlc.New(tenants)
+	owned := sqlc.GetPlayerByIDParams{ID: player, OperatorID: h.operator.ID}
+
+	ctx, release := tenants.Pin(h.ctx, intruder.operator.ID)
+	if _, err := q.GetPlayerByID(ctx, owned); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("intruder read: err = %v, want sql.ErrNoRows", err)
+	}
+	_, err = q.CreatePlayer(ctx, sqlc.CreatePlayerParams{
+		OperatorID: h.operator.ID, ExternalPlayerID: "planted", Jurisdiction: "MT",
+	})
+	if err == nil {
+		t.Fatal("intruder inserted a player for another operator")
+	}
+	release()
+
+	ctx, release = tenants.Pin(h.ctx, h.operator.ID)
+	if _, err := q.GetPlayerByID(ctx, owned); err != nil {
+		t.Fatalf("owner read: %v", err)
+	}
+	release()
+
+	// Released connections go back to the pool without an operator.
+	if _, err := q.GetPlayerByID(h.ctx, owned); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unpinned read: err = %v, want sql.ErrNoRows", err)
+	}
+}
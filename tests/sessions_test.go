@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSessionLaunchVerifyRevoke(t *testing.T) {
+	h := newHarness(t)
+
+	s := h.launch(t, "player-1")
+	if s.Session.OperatorID != h.operator.ID || s.Session.Revoked {
+		t.Fatalf("session = %+v", s.Session)
+	}
+
+	// A second launch for the same external id reuses the player.
+	if again := h.launch(t, "player-1"); again.Session.PlayerID != s.Session.PlayerID {
+		t.Fatalf("player %d, want %d", again.Session.PlayerID, s.Session.PlayerID)
+	}
+
+	if status := h.do(t, http.MethodPost, "/sessions/revoke?id="+s.Session.ID.String(), nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke: status %d", status)
+	}
+	if status := h.do(t, http.MethodGet, "/sessions/verify?token="+s.Token, nil, nil); status != http.StatusUnauthorized {
+		t.Fatalf("verify revoked: status %d, want 401", status)
+	}
+}
+
+func TestUnknownOperatorKeyIsRejected(t *testing.T) {
+	h := newHarness(t)
+	h.operator.ApiKey = "not-a-key"
+
+	if status := h.do(t, http.MethodGet, "/sessions/verify?token=x", nil, nil); status != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", status)
+	}
+}
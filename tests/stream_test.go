@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// stream opens /stream resuming after lastID and returns its events.
+func (h *harness) stream(t *testing.T, lastID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.rgs.URL+"/stream", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("X-Operator-Key", h.operator.ApiKey)
+	req.Header.Set("Last-Event-ID", lastID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stream: status %d", resp.StatusCode)
+	}
+
+	events := make(chan sseEvent)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		var evt sseEvent
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if evt.ID != "" {
+					select {
+					case events <- evt:
+					case <-ctx.Done():
+						return
+					}
+				}
+				evt = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				evt.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				evt.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				evt.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case evt, ok := <-events:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return evt
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event on stream")
+		return sseEvent{}
+	}
+}
+
+func TestStreamResumesAfterLastEventID(t *testing.T) {
+	h := newHarness(t)
+
+	for _, p := range []string{"player-1", "player-2", "player-3"} {
+		h.launch(t, p)
+	}
+	// Launch and verify each record an event.
+	eventually(t, 5*time.Second, "events to reach the bus", func() bool {
+		return len(h.app.Bus.GetBufferedEvents(h.operator.ID, "")) == 6
+	})
+	buffered := h.app.Bus.GetBufferedEvents(h.operator.ID, "")
+
+	events := h.stream(t, buffered[1].ID)
+	for _, want := range buffered[2:] {
+		got := nextEvent(t, events)
+		if got.ID != want.ID || got.Type != want.EventType {
+			t.Fatalf("replayed %s %s, want %s %s", got.ID, got.Type, want.ID, want.EventType)
+		}
+	}
+
+	// After the replay the same connection carries live events.
+	h.launch(t, "player-4")
+	if got := nextEvent(t, events); got.Type != "session.launched" || !strings.Contains(got.Data, `"player_id"`) {
+		t.Fatalf("live event = %+v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"rgs/services"
+	"rgs/sqlc"
+	"testing"
+	"time"
+)
+
+// as returns a copy of h that authenticates as a second operator.
+func (h *harness) as(t *testing.T, name string) *harness {
+	t.Helper()
+
+	operators := services.NewOperatorService(h.queries, services.SQLTx[services.OperatorRepo](h.db, h.queries), services.NewComplianceService(h.queries))
+	op, err := operators.Create(h.ctx, name, h.receiver.URL)
+	if err != nil {
+		t.Fatalf("create operator %s: %v", name, err)
+	}
+
+	other := *h
+	other.operator = op
+	return &other
+}
+
+func TestOperatorsCannotReachEachOthersRows(t *testing.T) {
+	h := newHarness(t)
+	intruder := h.as(t, "intruder")
+
+	s := h.launch(t, "player-1")
+	player := h.player(t, "player-1", 100)
+	placed := h.placeBet(t, player, 1, "tenant-1")
+
+	var events []sqlc.WebhookEvent
+	eventually(t, 5*time.Second, "owner webhook to be queued", func() bool {
+		h.do(t, http.MethodGet, "/webhooks", nil, &events)
+		return len(events) > 0
+	})
+
+	var endpoint struct {
+		ID int32 `json:"id"`
+	}
+	status := h.do(t, http.MethodPost, "/webhooks/endpoints", map[string]any{
+		"url":         h.receiver.URL,
+		"event_types": []string{"bet_settled"},
+		"enabled":     false,
+	}, &endpoint)
+	if status != http.StatusCreated {
+		t.Fatalf("create endpoint: status %d", status)
+	}
+
+	cross := []struct {
+		name         string
+		method, path string
+		body         any
+		want         int
+	}{
+		{"round", http.MethodGet, fmt.Sprintf("/rounds/%d", placed.RoundID), nil, http.StatusNotFound},
+		{"bet on player", http.MethodPost, "/bets", map[string]any{
+			"player_id": player, "amount": 1, "idempotency_key": "intruder-1",
+		}, http.StatusBadRequest},
+		{"revoke session", http.MethodPost, "/sessions/revoke?id=" + s.Session.ID.String(), nil, http.StatusNotFound},
+		{"retry webhook", http.MethodPost, fmt.Sprintf("/webhooks/retry/%d", events[0].ID), nil, http.StatusNotFound},
+		{"webhook attempts", http.MethodGet, fmt.Sprintf("/webhooks/%d/attempts", events[0].ID), nil, http.StatusNotFound},
+		{"endpoint", http.MethodGet, fmt.Sprintf("/webhooks/endpoints/%d", endpoint.ID), nil, http.StatusNotFound},
+	}
+	for _, c := range cross {
+		if status := intruder.do(t, c.method, c.path, c.body, nil); status != c.want {
+			t.Errorf("%s: intruder got status %d, want %d", c.name, status, c.want)
+		}
+	}
+
+	// The owner still sees everything the intruder was refused.
+	if status := h.do(t, http.MethodGet, fmt.Sprintf("/rounds/%d", placed.RoundID), nil, nil); status != http.StatusOK {
+		t.Fatalf("owner round: status %d", status)
+	}
+	if status := h.do(t, http.MethodGet, "/sessions/verify?token="+s.Token, nil, nil); status != http.StatusOK {
+		t.Fatalf("owner session after intruder revoke: status %d", status)
+	}
+	var bets int
+	if err := h.db.QueryRowContext(h.ctx, `SELECT count(*) FROM bets WHERE player_id = $1`, player).Scan(&bets); err != nil {
+		t.Fatalf("count bets: %v", err)
+	}
+	if bets != 1 {
+		t.Fatalf("player has %d bets, want only the owner's", bets)
+	}
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rgs/services"
+	"rgs/sqlc"
+	"rgs/webhooksdk"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// verifySignature checks a delivery the way operators do, with
+// webhooksdk.
+func verifySignature(secret, timestamp, header string, body []byte) bool {
+	v := webhooksdk.Verifier{Secrets: []string{secret}}
+	return v.VerifyPayload(timestamp, header, body) == nil
+}
+
+func TestSettlementWebhookIsDeliveredSigned(t *testing.T) {
+	h := newHarness(t)
+	player := h.player(t, "player-1", 100)
+
+	placed := h.placeBet(t, player, 1, "hook-1")
+
+	d := h.receiver.next(t, 10*time.Second)
+
+	timestamp := d.Header.Get("X-RGS-Timestamp")
+	signature := d.Header.Get("X-RGS-Signature")
+	if !verifySignature(h.operator.WebhookSecret, timestamp, signature, d.Body) {
+		t.Fatalf("signature %q does not verify", signature)
+	}
+	if verifySignature("wrong-secret", timestamp, signature, d.Body) {
+		t.Fatal("signature verifies with the wrong secret")
+	}
+
+	var envelope struct {
+		ID         string              `json:"id"`
+		Type       string              `json:"type"`
+		Version    int32               `json:"version"`
+		OperatorID int32               `json:"operator_id"`
+		Data       services.BetSettled `json:"data"`
+	}
+	if err := json.Unmarshal(d.Body, &envelope); err != nil {
+		t.Fatalf("payload %s: %v", d.Body, err)
+	}
+	if envelope.ID == "" || envelope.Type != "bet_settled" || envelope.Version != 1 || envelope.OperatorID != h.operator.ID {
+		t.Fatalf("envelope = %+v", envelope)
+	}
+	if envelope.Data.BetID != placed.BetID || envelope.Data.RoundID != placed.RoundID || envelope.Data.PlayerID != player {
+		t.Fatalf("data = %+v, want bet %d round %d player %d", envelope.Data, placed.BetID, placed.RoundID, player)
+	}
+	if want := h.bet(t, "hook-1").Status; envelope.Data.Status != want {
+		t.Fatalf("data status %q, want %q", envelope.Data.Status, want)
+	}
+
+	var events []sqlc.WebhookEvent
+	eventually(t, 5*time.Second, "webhook to be marked completed", func() bool {
+		h.do(t, http.MethodGet, "/webhooks?status=completed", nil, &events)
+		return len(events) == 1 && events[0].EventType == "bet_settled"
+	})
+
+	var attempts []services.DeliveryAttempt
+	if status := h.do(t, http.MethodGet, fmt.Sprintf("/webhooks/%d/attempts", events[0].ID), nil, &attempts); status != http.StatusOK {
+		t.Fatalf("attempts: status %d", status)
+	}
+	if len(attempts) != 1 || attempts[0].ResponseStatus == nil || *attempts[0].ResponseStatus != http.StatusOK {
+		t.Fatalf("attempts = %+v, want one 200", attempts)
+	}
+	if attempts[0].RequestHeaders["X-Rgs-Signature"] != signature {
+		t.Fatalf("logged signature %q, want %q", attempts[0].RequestHeaders["X-Rgs-Signature"], signature)
+	}
+}
+
+func TestWebhookEndpointsFanOutBySubscription(t *testing.T) {
+	h := newHarness(t)
+
+	sessions := newReceiver()
+	defer sessions.Close()
+
+	var created struct {
+		ID     int32  `json:"id"`
+		Secret string `json:"secret"`
+	}
+	status := h.do(t, http.MethodPost, "/webhooks/endpoints", map[string]any{
+		"url":         sessions.URL,
+		"event_types": []string{"session.*"},
+	}, &created)
+	if status != http.StatusCreated || created.Secret == "" {
+		t.Fatalf("create endpoint: status %d secret %q", status, created.Secret)
+	}
+
+	h.launch(t, "player-1")
+
+	d := sessions.next(t, 10*time.Second)
+	if !verifySignature(created.Secret, d.Header.Get("X-RGS-Timestamp"), d.Header.Get("X-RGS-Signature"), d.Body) {
+		t.Fatal("session webhook not signed with the endpoint secret")
+	}
+
+	select {
+	case d := <-h.receiver.deliveries:
+		t.Fatalf("default endpoint got a session event: %s", d.Body)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if status := h.do(t, http.MethodDelete, "/webhooks/endpoints/"+strconv.Itoa(int(created.ID)), nil, nil); status != http.StatusNoContent {
+		t.Fatalf("delete endpoint: status %d", status)
+	}
+	if status := h.do(t, http.MethodGet, "/webhooks/endpoints/"+strconv.Itoa(int(created.ID)), nil, nil); status != http.StatusNotFound {
+		t.Fatalf("get deleted endpoint: status %d", status)
+	}
+}
+
+func TestRotatedSecretSignsDuringOverlap(t *testing.T) {
+	h := newHarness(t)
+
+	sessions := newReceiver()
+	defer sessions.Close()
+
+	var created struct {
+		ID     int32  `json:"id"`
+		Secret string `json:"secret"`
+	}
+	status := h.do(t, http.MethodPost, "/webhooks/endpoints", map[string]any{
+		"url":         sessions.URL,
+		"event_types": []string{"session.*"},
+	}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("create endpoint: status %d", status)
+	}
+
+	var rotated struct {
+		Secret                  string     `json:"secret"`
+		PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at"`
+	}
+	path := fmt.Sprintf("/webhooks/endpoints/%d/rotate-secret", created.ID)
+	if status := h.do(t, http.MethodPost, path, map[string]any{"overlap_seconds": 3600}, &rotated); status != http.StatusOK {
+		t.Fatalf("rotate: status %d", status)
+	}
+	if rotated.Secret == "" || rotated.Secret == created.Secret || rotated.PreviousSecretExpiresAt == nil {
+		t.Fatalf("rotate response = %+v", rotated)
+	}
+
+	h.launch(t, "player-1")
+
+	d := sessions.next(t, 10*time.Second)
+	timestamp := d.Header.Get("X-RGS-Timestamp")
+	signature := d.Header.Get("X-RGS-Signature")
+	if !verifySignature(rotated.Secret, timestamp, signature, d.Body) {
+		t.Fatalf("signature %q does not verify with the new secret", signature)
+	}
+	if !verifySignature(created.Secret, timestamp, signature, d.Body) {
+		t.Fatalf("signature %q does not verify with the old secret during the overlap", signature)
+	}
+}
+
+func TestWebhookTestSendsPing(t *testing.T) {
+	h := newHarness(t)
+
+	onboarding := newReceiver()
+	defer onboarding.Close()
+
+	var created struct {
+		ID     int32  `json:"id"`
+		Secret string `json:"secret"`
+	}
+	status := h.do(t, http.MethodPost, "/webhooks/endpoints", map[string]any{
+		"url":         onboarding.URL,
+		"event_types": []string{"bet_settled"},
+		"enabled":     false,
+	}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("create endpoint: status %d", status)
+	}
+
+	var attempt services.DeliveryAttempt
+	if status := h.do(t, http.MethodPost, "/webhooks/test", map[string]any{"endpoint_id": created.ID}, &attempt); status != http.StatusOK {
+		t.Fatalf("test: status %d", status)
+	}
+	if attempt.ResponseStatus == nil || *attempt.ResponseStatus != http.StatusOK || attempt.Error != "" {
+		t.Fatalf("attempt = %+v", attempt)
+	}
+
+	d := onboarding.next(t, time.Second)
+	if !verifySignature(created.Secret, d.Header.Get("X-RGS-Timestamp"), d.Header.Get("X-RGS-Signature"), d.Body) {
+		t.Fatal("ping not signed with the endpoint secret")
+	}
+	var envelope webhooksdk.Envelope
+	if err := json.Unmarshal(d.Body, &envelope); err != nil || envelope.Type != webhooksdk.TypePing {
+		t.Fatalf("ping body %s: %v", d.Body, err)
+	}
+
+	if status := h.do(t, http.MethodPost, "/webhooks/test", map[string]any{"endpoint_id": created.ID + 1000}, nil); status != http.StatusNotFound {
+		t.Fatalf("unknown endpoint: status %d", status)
+	}
+}
@@ -0,0 +1,180 @@
+package walletmock
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// AdminHandlers expose test-only controls. They are not authenticated; the
+// mock is never meant to be reachable outside a test environment.
+type AdminHandlers struct {
+	store  *Store
+	faults *FaultInjector
+}
+
+func NewAdminHandlers(store *Store, faults *FaultInjector) *AdminHandlers {
+	return &AdminHandlers{store: store, faults: faults}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("error encoding admin response", err)
+	}
+}
+
+func (h *AdminHandlers) ListFaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.faults.List())
+}
+
+func (h *AdminHandlers) AddFault(w http.ResponseWriter, r *http.Request) {
+	var f Fault
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.faults.Add(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *AdminHandlers) DeleteFault(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if !h.faults.Remove(id) {
+		http.Error(w, "fault not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandlers) ClearFaults(w http.ResponseWriter, r *http.Request) {
+	h.faults.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoadScenario appends the faults of a scenario; pass ?replace=true to
+// clear the current faults first.
+func (h *AdminHandlers) LoadScenario(w http.ResponseWriter, r *http.Request) {
+	var s Scenario
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("replace") == "true" {
+		h.faults.Clear()
+	}
+
+	if err := h.faults.LoadScenario(s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.faults.List())
+}
+
+func (h *AdminHandlers) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.store.Players())
+}
+
+func (h *AdminHandlers) CreatePlayer(w http.ResponseWriter, r *http.Request) {
+	var p Player
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil || p.PlayerID == 0 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	err := h.store.CreatePlayer(p.PlayerID, p.Balance)
+	if errors.Is(err, ErrPlayerExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (h *AdminHandlers) GetPlayer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	bal, ok := h.store.GetBalance(int32(id))
+	if !ok {
+		http.Error(w, ErrPlayerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Player{PlayerID: int32(id), Balance: bal})
+}
+
+func (h *AdminHandlers) SetBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Balance float64 `json:"balance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.SetBalance(int32(id), body.Balance)
+	if errors.Is(err, ErrPlayerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Player{PlayerID: int32(id), Balance: body.Balance})
+}
+
+// ListTransactions returns the newest entries first, optionally filtered by
+// ?player_id= and capped by ?limit= (default 100).
+func (h *AdminHandlers) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	var player int32
+	if v := r.URL.Query().Get("player_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			http.Error(w, "invalid player_id", http.StatusBadRequest)
+			return
+		}
+		player = int32(id)
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	writeJSON(w, http.StatusOK, h.store.PlayerTransactions(player, limit))
+}
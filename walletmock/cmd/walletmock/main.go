@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"walletmock"
+)
+
+func main() {
+	server, err := walletmock.NewServerFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Wallet mock running on :9000")
+	log.Fatal(http.ListenAndServe(":9000", server.Handler()))
+}
@@ -0,0 +1,309 @@
+package walletmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault actions. An empty action only injects latency.
+const (
+	ActionStatus       = "status"
+	ActionMalformed    = "malformed"
+	ActionTimeout      = "timeout"
+	ActionDropResponse = "drop_response"
+)
+
+const (
+	DistributionFixed   = "fixed"
+	DistributionUniform = "uniform"
+	DistributionNormal  = "normal"
+)
+
+type Latency struct {
+	Distribution string `json:"distribution"`
+	MinMs        int    `json:"min_ms,omitempty"`
+	MaxMs        int    `json:"max_ms,omitempty"`
+	MeanMs       int    `json:"mean_ms,omitempty"`
+	StddevMs     int    `json:"stddev_ms,omitempty"`
+}
+
+// Fault matches requests by endpoint ("debit", "credit", "transactions",
+// empty for all) and player (0 for all). Rate is the probability the action
+// fires on a match, treated as 1 when zero. Remaining limits how many times
+// the fault fires before it is removed; 0 means forever.
+type Fault struct {
+	ID        int      `json:"id"`
+	Endpoint  string   `json:"endpoint,omitempty"`
+	PlayerID  int32    `json:"player_id,omitempty"`
+	Latency   *Latency `json:"latency,omitempty"`
+	Action    string   `json:"action,omitempty"`
+	Status    int      `json:"status,omitempty"`
+	Rate      float64  `json:"rate,omitempty"`
+	Remaining int      `json:"remaining,omitempty"`
+}
+
+// Scenario is the JSON file format loaded at startup or via the admin API.
+type Scenario struct {
+	Name   string  `json:"name"`
+	Faults []Fault `json:"faults"`
+}
+
+type FaultDecision struct {
+	Delay  time.Duration
+	Action string
+	Status int
+}
+
+type FaultInjector struct {
+	mu     sync.Mutex
+	faults []*Fault
+	nextID int
+	rnd    *rand.Rand
+}
+
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func validateFault(f Fault) error {
+	switch f.Action {
+	case "", ActionMalformed, ActionTimeout, ActionDropResponse:
+	case ActionStatus:
+		if f.Status < 100 || f.Status > 599 {
+			return fmt.Errorf("fault action status needs a valid status, got %d", f.Status)
+		}
+	default:
+		return fmt.Errorf("unknown fault action %q", f.Action)
+	}
+
+	if f.Rate < 0 || f.Rate > 1 {
+		return fmt.Errorf("fault rate must be between 0 and 1, got %v", f.Rate)
+	}
+
+	if f.Latency != nil {
+		switch f.Latency.Distribution {
+		case "", DistributionFixed, DistributionUniform, DistributionNormal:
+		default:
+			return fmt.Errorf("unknown latency distribution %q", f.Latency.Distribution)
+		}
+	}
+
+	return nil
+}
+
+func (fi *FaultInjector) Add(f Fault) (Fault, error) {
+	if err := validateFault(f); err != nil {
+		return Fault{}, err
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	fi.nextID++
+	f.ID = fi.nextID
+	fi.faults = append(fi.faults, &f)
+
+	return f, nil
+}
+
+func (fi *FaultInjector) LoadScenario(s Scenario) error {
+	for _, f := range s.Faults {
+		if err := validateFault(f); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range s.Faults {
+		if _, err := fi.Add(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (fi *FaultInjector) List() []Fault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	out := make([]Fault, 0, len(fi.faults))
+	for _, f := range fi.faults {
+		out = append(out, *f)
+	}
+	return out
+}
+
+func (fi *FaultInjector) Remove(id int) bool {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	for i, f := range fi.faults {
+		if f.ID == id {
+			fi.faults = append(fi.faults[:i], fi.faults[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (fi *FaultInjector) Clear() {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	fi.faults = nil
+}
+
+// sampleLatency must be called with fi.mu held.
+func (fi *FaultInjector) sampleLatency(l *Latency) time.Duration {
+	var ms float64
+
+	switch l.Distribution {
+	case DistributionUniform:
+		ms = float64(l.MinMs)
+		if l.MaxMs > l.MinMs {
+			ms += fi.rnd.Float64() * float64(l.MaxMs-l.MinMs)
+		}
+	case DistributionNormal:
+		ms = fi.rnd.NormFloat64()*float64(l.StddevMs) + float64(l.MeanMs)
+	default:
+		ms = float64(l.MinMs)
+	}
+
+	if ms < 0 {
+		ms = 0
+	}
+
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Evaluate sums the latency of every matching fault and picks the action of
+// the first matching fault whose rate fires.
+func (fi *FaultInjector) Evaluate(endpoint string, playerID int32) FaultDecision {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	var d FaultDecision
+	kept := fi.faults[:0]
+
+	for _, f := range fi.faults {
+		matches := (f.Endpoint == "" || f.Endpoint == endpoint) &&
+			(f.PlayerID == 0 || f.PlayerID == playerID)
+
+		fired := false
+		if matches {
+			if f.Latency != nil {
+				d.Delay += fi.sampleLatency(f.Latency)
+				fired = f.Action == ""
+			}
+
+			if f.Action != "" && d.Action == "" {
+				rate := f.Rate
+				if rate == 0 {
+					rate = 1
+				}
+				if fi.rnd.Float64() < rate {
+					d.Action = f.Action
+					d.Status = f.Status
+					fired = true
+				}
+			}
+		}
+
+		if fired && f.Remaining > 0 {
+			f.Remaining--
+			if f.Remaining == 0 {
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	fi.faults = kept
+
+	return d
+}
+
+func LoadScenarioFile(path string) (Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var s Scenario
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return Scenario{}, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// parseLatencyEnv accepts "50" (fixed) or "20-200" (uniform).
+func parseLatencyEnv(v string) (*Latency, error) {
+	if lo, hi, ok := strings.Cut(v, "-"); ok {
+		minMs, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, err
+		}
+		maxMs, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, err
+		}
+		return &Latency{Distribution: DistributionUniform, MinMs: minMs, MaxMs: maxMs}, nil
+	}
+
+	ms, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Latency{Distribution: DistributionFixed, MinMs: ms}, nil
+}
+
+// FaultsFromEnv builds global faults from WALLET_LATENCY_MS,
+// WALLET_ERROR_RATE, WALLET_ERROR_STATUS and WALLET_SCENARIO_FILE.
+func FaultsFromEnv() (Scenario, error) {
+	s := Scenario{Name: "env"}
+
+	if v := os.Getenv("WALLET_LATENCY_MS"); v != "" {
+		l, err := parseLatencyEnv(v)
+		if err != nil {
+			return Scenario{}, fmt.Errorf("invalid WALLET_LATENCY_MS: %w", err)
+		}
+		s.Faults = append(s.Faults, Fault{Latency: l})
+	}
+
+	if v := os.Getenv("WALLET_ERROR_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Scenario{}, fmt.Errorf("invalid WALLET_ERROR_RATE: %w", err)
+		}
+
+		status := 500
+		if sv := os.Getenv("WALLET_ERROR_STATUS"); sv != "" {
+			status, err = strconv.Atoi(sv)
+			if err != nil {
+				return Scenario{}, fmt.Errorf("invalid WALLET_ERROR_STATUS: %w", err)
+			}
+		}
+
+		if rate > 0 {
+			s.Faults = append(s.Faults, Fault{Action: ActionStatus, Status: status, Rate: rate})
+		}
+	}
+
+	if path := os.Getenv("WALLET_SCENARIO_FILE"); path != "" {
+		file, err := LoadScenarioFile(path)
+		if err != nil {
+			return Scenario{}, err
+		}
+		s.Faults = append(s.Faults, file.Faults...)
+	}
+
+	return s, nil
+}
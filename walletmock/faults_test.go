@@ -0,0 +1,493 @@
+package walletmock
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(Secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newTestServer serves a fresh in-memory store (player 1 holds 100000)
+// with a seeded fault injector.
+func newTestServer(t *testing.T) (*httptest.Server, *Store, *FaultInjector) {
+	t.Helper()
+
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	faults := NewFaultInjector()
+	faults.rnd = rand.New(rand.NewSource(1))
+
+	srv := httptest.NewServer(NewServer(store, faults).Handler())
+	t.Cleanup(srv.Close)
+	return srv, store, faults
+}
+
+// move sends a signed debit or credit and returns the response status and
+// body, or the transport error when there is no response.
+func move(t *testing.T, client *http.Client, url, endpoint string, player int32, amount float64, requestID string) (int, []byte, error) {
+	t.Helper()
+
+	body, _ := json.Marshal(WalletRequest{
+		PlayerID:  player,
+		Amount:    amount,
+		RequestID: requestID,
+		Signature: sign(fmt.Sprintf("%d:%f:%s", player, amount, requestID)),
+	})
+	resp, err := client.Post(url+"/wallet/"+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, raw, err
+}
+
+func TestFaultLatencyDistributions(t *testing.T) {
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+		mean     time.Duration
+	}{
+		{
+			name:    "fixed",
+			latency: Latency{Distribution: DistributionFixed, MinMs: 40},
+			min:     40 * time.Millisecond, max: 40 * time.Millisecond, mean: 40 * time.Millisecond,
+		},
+		{
+			name:    "default is fixed",
+			latency: Latency{MinMs: 15},
+			min:     15 * time.Millisecond, max: 15 * time.Millisecond, mean: 15 * time.Millisecond,
+		},
+		{
+			name:    "uniform",
+			latency: Latency{Distribution: DistributionUniform, MinMs: 20, MaxMs: 200},
+			min:     20 * time.Millisecond, max: 200 * time.Millisecond, mean: 110 * time.Millisecond,
+		},
+		{
+			name:    "normal",
+			latency: Latency{Distribution: DistributionNormal, MeanMs: 80, StddevMs: 10},
+			min:     0, max: time.Second, mean: 80 * time.Millisecond,
+		},
+		{
+			name:    "normal never negative",
+			latency: Latency{Distribution: DistributionNormal, MeanMs: -50, StddevMs: 1},
+			min:     0, max: 0, mean: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := NewFaultInjector()
+			fi.rnd = rand.New(rand.NewSource(1))
+			l := tt.latency
+			if _, err := fi.Add(Fault{Latency: &l}); err != nil {
+				t.Fatalf("add: %v", err)
+			}
+
+			const samples = 2000
+			var total time.Duration
+			for range samples {
+				d := fi.Evaluate("debit", 1)
+				if d.Action != "" {
+					t.Fatalf("latency-only fault set action %q", d.Action)
+				}
+				if d.Delay < tt.min || d.Delay > tt.max {
+					t.Fatalf("delay %v outside [%v, %v]", d.Delay, tt.min, tt.max)
+				}
+				total += d.Delay
+			}
+
+			mean := total / samples
+			if diff := mean - tt.mean; diff < -5*time.Millisecond || diff > 5*time.Millisecond {
+				t.Fatalf("mean delay %v, want about %v", mean, tt.mean)
+			}
+		})
+	}
+}
+
+func TestFaultLatenciesAdd(t *testing.T) {
+	fi := NewFaultInjector()
+	for _, ms := range []int{10, 25} {
+		if _, err := fi.Add(Fault{Latency: &Latency{MinMs: ms}}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	if d := fi.Evaluate("credit", 1); d.Delay != 35*time.Millisecond {
+		t.Fatalf("delay %v, want both latencies summed", d.Delay)
+	}
+}
+
+func TestFaultActions(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      Fault
+		wantStatus int
+		wantErr    bool
+		// applied is whether the debit still moves money.
+		applied bool
+	}{
+		{
+			name:       "status",
+			fault:      Fault{Action: ActionStatus, Status: http.StatusServiceUnavailable},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "malformed",
+			fault:      Fault{Action: ActionMalformed},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "timeout",
+			fault:   Fault{Action: ActionTimeout},
+			wantErr: true,
+		},
+		{
+			name:    "drop response",
+			fault:   Fault{Action: ActionDropResponse},
+			wantErr: true,
+			applied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store, faults := newTestServer(t)
+			if _, err := faults.Add(tt.fault); err != nil {
+				t.Fatalf("add fault: %v", err)
+			}
+
+			client := &http.Client{Timeout: 200 * time.Millisecond}
+			status, body, err := move(t, client, srv.URL, "debit", 1, 10, "req-1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got status %d, want no response", status)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("debit: %v", err)
+				}
+				if status != tt.wantStatus {
+					t.Fatalf("status %d, want %d", status, tt.wantStatus)
+				}
+				var resp WalletResponse
+				if status == http.StatusOK && json.Unmarshal(body, &resp) == nil {
+					t.Fatalf("body %q decoded, want malformed JSON", body)
+				}
+			}
+
+			want := 100000.0
+			if tt.applied {
+				want -= 10
+			}
+			if bal, _ := store.GetBalance(1); bal != want {
+				t.Fatalf("balance %v, want %v", bal, want)
+			}
+
+			// With the fault cleared the same request id reports what the
+			// wallet actually did.
+			faults.Clear()
+			status, body, err = move(t, http.DefaultClient, srv.URL, "debit", 1, 10, "req-1")
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("retry: status %d err %v", status, err)
+			}
+			var resp WalletResponse
+			if err := json.Unmarshal(body, &resp); err != nil || !resp.Success || resp.Balance != 100000-10 {
+				t.Fatalf("retry response %s, want success at %v", body, 100000-10)
+			}
+		})
+	}
+}
+
+func TestFaultScoping(t *testing.T) {
+	tests := []struct {
+		name     string
+		fault    Fault
+		endpoint string
+		player   int32
+		fires    bool
+	}{
+		{"all endpoints and players", Fault{}, "debit", 1, true},
+		{"matching endpoint", Fault{Endpoint: "credit"}, "credit", 1, true},
+		{"other endpoint", Fault{Endpoint: "credit"}, "debit", 1, false},
+		{"matching player", Fault{PlayerID: 7}, "debit", 7, true},
+		{"other player", Fault{PlayerID: 7}, "debit", 1, false},
+		{"matching endpoint and player", Fault{Endpoint: "credit", PlayerID: 7}, "credit", 7, true},
+		{"matching endpoint, other player", Fault{Endpoint: "credit", PlayerID: 7}, "credit", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store, faults := newTestServer(t)
+			if err := store.CreatePlayer(7, 100); err != nil {
+				t.Fatalf("create player: %v", err)
+			}
+
+			f := tt.fault
+			f.Action, f.Status = ActionStatus, http.StatusTeapot
+			if _, err := faults.Add(f); err != nil {
+				t.Fatalf("add fault: %v", err)
+			}
+
+			status, _, err := move(t, http.DefaultClient, srv.URL, tt.endpoint, tt.player, 1, "req-1")
+			if err != nil {
+				t.Fatalf("%s: %v", tt.endpoint, err)
+			}
+			if fired := status == http.StatusTeapot; fired != tt.fires {
+				t.Fatalf("status %d, fault fired %v, want %v", status, fired, tt.fires)
+			}
+		})
+	}
+}
+
+func TestFaultRate(t *testing.T) {
+	fi := NewFaultInjector()
+	fi.rnd = rand.New(rand.NewSource(1))
+	if _, err := fi.Add(Fault{Action: ActionStatus, Status: 500, Rate: 0.25}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	fired := 0
+	for range 4000 {
+		if fi.Evaluate("debit", 1).Action == ActionStatus {
+			fired++
+		}
+	}
+	if fired < 900 || fired > 1100 {
+		t.Fatalf("fired %d of 4000, want about a quarter", fired)
+	}
+}
+
+func TestFaultRemainingCountsDown(t *testing.T) {
+	srv, _, faults := newTestServer(t)
+	f, err := faults.Add(Fault{Endpoint: "credit", Action: ActionStatus, Status: http.StatusServiceUnavailable, Remaining: 2})
+	if err != nil {
+		t.Fatalf("add fault: %v", err)
+	}
+
+	// A request the fault does not match leaves the count alone.
+	if status, _, _ := move(t, http.DefaultClient, srv.URL, "debit", 1, 1, "debit-1"); status != http.StatusOK {
+		t.Fatalf("unmatched debit: status %d", status)
+	}
+
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		if i == 1 {
+			if got := faults.List(); len(got) != 1 || got[0].ID != f.ID || got[0].Remaining != 1 {
+				t.Fatalf("after one firing: faults %+v, want remaining 1", got)
+			}
+		}
+		status, _, err := move(t, http.DefaultClient, srv.URL, "credit", 1, 1, fmt.Sprintf("credit-%d", i))
+		if err != nil || status != want {
+			t.Fatalf("credit %d: status %d err %v, want %d", i, status, err, want)
+		}
+	}
+
+	if got := faults.List(); len(got) != 0 {
+		t.Fatalf("faults %+v, want the spent fault removed", got)
+	}
+}
+
+func TestFaultsFromEnv(t *testing.T) {
+	scenario := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(scenario, []byte(`{"faults": [{"endpoint": "credit", "action": "drop_response", "remaining": 1}]}`), 0o644); err != nil {
+		t.Fatalf("write scenario: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []Fault
+		wantErr bool
+	}{
+		{name: "nothing set"},
+		{
+			name: "fixed latency",
+			env:  map[string]string{"WALLET_LATENCY_MS": "50"},
+			want: []Fault{{Latency: &Latency{Distribution: DistributionFixed, MinMs: 50}}},
+		},
+		{
+			name: "uniform latency",
+			env:  map[string]string{"WALLET_LATENCY_MS": "20-200"},
+			want: []Fault{{Latency: &Latency{Distribution: DistributionUniform, MinMs: 20, MaxMs: 200}}},
+		},
+		{
+			name: "error rate defaults to 500",
+			env:  map[string]string{"WALLET_ERROR_RATE": "0.1"},
+			want: []Fault{{Action: ActionStatus, Status: 500, Rate: 0.1}},
+		},
+		{
+			name: "error rate and status",
+			env:  map[string]string{"WALLET_ERROR_RATE": "0.5", "WALLET_ERROR_STATUS": "503"},
+			want: []Fault{{Action: ActionStatus, Status: 503, Rate: 0.5}},
+		},
+		{
+			name: "zero error rate",
+			env:  map[string]string{"WALLET_ERROR_RATE": "0"},
+		},
+		{
+			name: "scenario file after env faults",
+			env:  map[string]string{"WALLET_LATENCY_MS": "5", "WALLET_SCENARIO_FILE": scenario},
+			want: []Fault{
+				{Latency: &Latency{Distribution: DistributionFixed, MinMs: 5}},
+				{Endpoint: "credit", Action: ActionDropResponse, Remaining: 1},
+			},
+		},
+		{name: "bad latency", env: map[string]string{"WALLET_LATENCY_MS": "soon"}, wantErr: true},
+		{name: "bad rate", env: map[string]string{"WALLET_ERROR_RATE": "often"}, wantErr: true},
+		{name: "bad status", env: map[string]string{"WALLET_ERROR_RATE": "1", "WALLET_ERROR_STATUS": "x"}, wantErr: true},
+		{name: "missing scenario file", env: map[string]string{"WALLET_SCENARIO_FILE": scenario + ".missing"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"WALLET_LATENCY_MS", "WALLET_ERROR_RATE", "WALLET_ERROR_STATUS", "WALLET_SCENARIO_FILE"} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			s, err := FaultsFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FaultsFromEnv: %v", err)
+			}
+
+			got, _ := json.Marshal(s.Faults)
+			want, _ := json.Marshal(tt.want)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("faults %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadScenarioFile(t *testing.T) {
+	s, err := LoadScenarioFile(filepath.Join("scenarios", "credit_outage.json"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	fi := NewFaultInjector()
+	if err := fi.LoadScenario(s); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	if got := fi.List(); len(got) != 3 || got[0].PlayerID != 7 || got[0].Remaining != 3 {
+		t.Fatalf("faults %+v", got)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"faults": [`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadScenarioFile(bad); err == nil {
+		t.Fatal("malformed scenario file loaded")
+	}
+}
+
+func TestAdminScenarios(t *testing.T) {
+	srv, _, faults := newTestServer(t)
+	if _, err := faults.Add(Fault{Endpoint: "debit", Action: ActionTimeout}); err != nil {
+		t.Fatalf("add fault: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		wantStatus int
+		wantFaults int
+	}{
+		{"append", "", `{"faults": [{"endpoint": "credit", "action": "malformed"}]}`, http.StatusOK, 2},
+		{"replace", "?replace=true", `{"faults": [{"action": "status", "status": 502}]}`, http.StatusOK, 1},
+		{"invalid fault rejects the whole scenario", "", `{"faults": [{"action": "status", "status": 502}, {"action": "explode"}]}`, http.StatusBadRequest, 1},
+		{"bad rate", "", `{"faults": [{"action": "status", "status": 500, "rate": 2}]}`, http.StatusBadRequest, 1},
+		{"malformed body", "", `{"faults": `, http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/admin/scenarios"+tt.query, "application/json", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("post: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := faults.List(); len(got) != tt.wantFaults {
+				t.Fatalf("faults %+v, want %d", got, tt.wantFaults)
+			}
+		})
+	}
+}
+
+func TestAdminFaults(t *testing.T) {
+	srv, _, faults := newTestServer(t)
+
+	resp, err := http.Post(srv.URL+"/admin/faults", "application/json",
+		bytes.NewBufferString(`{"endpoint": "credit", "action": "status", "status": 503, "remaining": 1}`))
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var created Fault
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.ID == 0 || created.Remaining != 1 {
+		t.Fatalf("add: status %d fault %+v", resp.StatusCode, created)
+	}
+
+	resp, err = http.Post(srv.URL+"/admin/faults", "application/json", bytes.NewBufferString(`{"action": "status"}`))
+	if err != nil {
+		t.Fatalf("add invalid: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status action without a status: got %d, want 400", resp.StatusCode)
+	}
+
+	del := func(path string) int {
+		req, _ := http.NewRequest(http.MethodDelete, srv.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("delete %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := del(fmt.Sprintf("/admin/faults/%d", created.ID)); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d", status)
+	}
+	if status := del(fmt.Sprintf("/admin/faults/%d", created.ID)); status != http.StatusNotFound {
+		t.Fatalf("delete again: status %d, want 404", status)
+	}
+
+	_, _ = faults.Add(Fault{Action: ActionTimeout})
+	if status := del("/admin/faults"); status != http.StatusNoContent || len(faults.List()) != 0 {
+		t.Fatalf("clear: status %d faults %+v", status, faults.List())
+	}
+}
@@ -1,20 +1,78 @@
-package main
+package walletmock
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 )
 
 type Handlers struct {
-	store *Store
+	store  *Store
+	faults *FaultInjector
 }
 
-func NewHandlers(store *Store) *Handlers {
-	return &Handlers{store: store}
+func NewHandlers(store *Store, faults *FaultInjector) *Handlers {
+	return &Handlers{store: store, faults: faults}
 }
 
 func (h *Handlers) Debit(w http.ResponseWriter, r *http.Request) {
+	h.movement(w, r, "debit")
+}
+
+func (h *Handlers) Credit(w http.ResponseWriter, r *http.Request) {
+	h.movement(w, r, "credit")
+}
+
+// applyFault runs the pre-processing part of a fault decision. It returns
+// false when the request has been fully handled by the fault.
+func applyFault(w http.ResponseWriter, r *http.Request, d FaultDecision) bool {
+	if d.Delay > 0 {
+		select {
+		case <-time.After(d.Delay):
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	switch d.Action {
+	case ActionTimeout:
+		// hold the request until the client gives up
+		<-r.Context().Done()
+		return false
+	case ActionStatus:
+		http.Error(w, "injected fault", d.Status)
+		return false
+	case ActionMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success": tru`))
+		return false
+	}
+
+	return true
+}
+
+// dropResponse closes the connection without writing a response.
+func dropResponse(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		log.Println("error hijacking connection", err)
+		return
+	}
+	_ = conn.Close()
+}
+
+func (h *Handlers) movement(
+	w http.ResponseWriter,
+	r *http.Request,
+	endpoint string,
+) {
 	var req WalletRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -27,61 +85,66 @@ func (h *Handlers) Debit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.store.CheckIdempotent(req.RequestID) {
-		err := json.NewEncoder(w).Encode(WalletResponse{
-			Success: true,
-			Balance: h.store.GetBalance(req.PlayerID),
-		})
-		if err != nil {
-			log.Println("error encoding response", err)
-		}
+	decision := h.faults.Evaluate(endpoint, req.PlayerID)
+	if !applyFault(w, r, decision) {
 		return
 	}
 
-	ok, newBalance := h.store.Debit(req.PlayerID, req.Amount)
-
-	err = json.NewEncoder(w).Encode(WalletResponse{
-		Success: ok,
-		Balance: newBalance,
-	})
+	resp, replayed, err := h.store.Execute(endpoint, req)
+	if errors.Is(err, ErrIdempotencyReused) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		log.Println("error encoding wallet response", err)
+		log.Println("error persisting wallet state", err)
+		http.Error(w, "wallet state not persisted", http.StatusInternalServerError)
 		return
 	}
-}
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
 
-func (h *Handlers) Credit(w http.ResponseWriter, r *http.Request) {
-	var req WalletRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
+	if decision.Action == ActionDropResponse {
+		dropResponse(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(resp)
 	if err != nil {
-		log.Println("error decoding request", err)
+		log.Println("error encoding wallet response", err)
 		return
 	}
+}
 
-	if !ValidSignature(req.PlayerID, req.Amount, req.RequestID, req.Signature) {
+func (h *Handlers) Transactions(w http.ResponseWriter, r *http.Request) {
+	fromParam := r.URL.Query().Get("from")
+	toParam := r.URL.Query().Get("to")
+
+	if !ValidPayloadSignature(fromParam+":"+toParam, r.URL.Query().Get("signature")) {
 		http.Error(w, "invalid signature", http.StatusUnauthorized)
 		return
 	}
 
-	if h.store.CheckIdempotent(req.RequestID) {
-		err := json.NewEncoder(w).Encode(WalletResponse{
-			Success: true,
-			Balance: h.store.GetBalance(req.PlayerID),
-		})
-		if err != nil {
-			log.Println("error encoding wallet response", err)
-		}
+	from, err := time.Parse(time.RFC3339, fromParam)
+	if err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, toParam)
+	if err != nil {
+		http.Error(w, "invalid to", http.StatusBadRequest)
 		return
 	}
 
-	ok, newBalance := h.store.Credit(req.PlayerID, req.Amount)
+	if !applyFault(w, r, h.faults.Evaluate("transactions", 0)) {
+		return
+	}
 
-	err = json.NewEncoder(w).Encode(WalletResponse{
-		Success: ok,
-		Balance: newBalance,
+	err = json.NewEncoder(w).Encode(TransactionsResponse{
+		Transactions: h.store.Transactions(from, to),
 	})
 	if err != nil {
-		log.Println("error encoding wallet response", err)
+		log.Println("error encoding transactions response", err)
 		return
 	}
 }
@@ -1,6 +0,0 @@
-package main
-
-func main() {
-	server := NewServer()
-	server.Run()
-}
@@ -1,4 +1,6 @@
-package main
+package walletmock
+
+import "time"
 
 type WalletRequest struct {
 	PlayerID  int32   `json:"player_id"`
@@ -11,3 +13,22 @@ type WalletResponse struct {
 	Success bool    `json:"success"`
 	Balance float64 `json:"balance"`
 }
+
+type Transaction struct {
+	ID           int64     `json:"id"`
+	RequestID    string    `json:"request_id"`
+	PlayerID     int32     `json:"player_id"`
+	Type         string    `json:"type"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type TransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+}
+
+type Player struct {
+	PlayerID int32   `json:"player_id"`
+	Balance  float64 `json:"balance"`
+}
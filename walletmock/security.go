@@ -1,4 +1,4 @@
-package main
+package walletmock
 
 import (
 	"crypto/hmac"
@@ -10,8 +10,10 @@ import (
 const Secret = "testsecret123"
 
 func ValidSignature(player int32, amount float64, reqID, sig string) bool {
-	payload := fmt.Sprintf("%d:%f:%s", player, amount, reqID)
+	return ValidPayloadSignature(fmt.Sprintf("%d:%f:%s", player, amount, reqID), sig)
+}
 
+func ValidPayloadSignature(payload, sig string) bool {
 	mac := hmac.New(sha256.New, []byte(Secret))
 	mac.Write([]byte(payload))
 	expected := hex.EncodeToString(mac.Sum(nil))
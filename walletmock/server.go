@@ -1,36 +1,73 @@
-package main
+package walletmock
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"os"
 )
 
 type Server struct {
 	store    *Store
+	faults   *FaultInjector
 	handlers *Handlers
+	admin    *AdminHandlers
 }
 
-func NewServer() *Server {
-	store := NewStore()
-	handlers := NewHandlers(store)
+// NewServerFromEnv builds the server the walletmock binary runs, loading
+// the snapshot from WALLET_SNAPSHOT_FILE and faults from the environment.
+func NewServerFromEnv() (*Server, error) {
+	store, err := NewStore(os.Getenv("WALLET_SNAPSHOT_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wallet snapshot: %w", err)
+	}
+
+	faults := NewFaultInjector()
+
+	scenario, err := FaultsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("invalid fault configuration: %w", err)
+	}
+	if err := faults.LoadScenario(scenario); err != nil {
+		return nil, fmt.Errorf("invalid fault scenario: %w", err)
+	}
 
+	return NewServer(store, faults), nil
+}
+
+// NewServer wires the handlers around store and faults, which tests can
+// keep hold of to seed balances and inject faults directly.
+func NewServer(store *Store, faults *FaultInjector) *Server {
 	return &Server{
 		store:    store,
-		handlers: handlers,
+		faults:   faults,
+		handlers: NewHandlers(store, faults),
+		admin:    NewAdminHandlers(store, faults),
 	}
 }
 
-func (s *Server) Run() {
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/wallet/debit", s.handlers.Debit)
 	mux.HandleFunc("/wallet/credit", s.handlers.Credit)
+	mux.HandleFunc("/wallet/transactions", s.handlers.Transactions)
+
+	mux.HandleFunc("GET /admin/faults", s.admin.ListFaults)
+	mux.HandleFunc("POST /admin/faults", s.admin.AddFault)
+	mux.HandleFunc("DELETE /admin/faults", s.admin.ClearFaults)
+	mux.HandleFunc("DELETE /admin/faults/{id}", s.admin.DeleteFault)
+	mux.HandleFunc("POST /admin/scenarios", s.admin.LoadScenario)
+
+	mux.HandleFunc("GET /admin/players", s.admin.ListPlayers)
+	mux.HandleFunc("POST /admin/players", s.admin.CreatePlayer)
+	mux.HandleFunc("GET /admin/players/{id}", s.admin.GetPlayer)
+	mux.HandleFunc("PUT /admin/players/{id}/balance", s.admin.SetBalance)
+	mux.HandleFunc("GET /admin/transactions", s.admin.ListTransactions)
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
 
-	log.Println("Wallet mock running on :9000")
-	log.Fatal(http.ListenAndServe(":9000", mux))
+	return mux
 }
@@ -1,61 +1,265 @@
-package main
+package walletmock
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPlayerExists      = errors.New("player already exists")
+	ErrPlayerNotFound    = errors.New("player not found")
+	ErrIdempotencyReused = errors.New("request_id reused with different parameters")
+)
+
+// IdempotencyRecord is the stored outcome of a request_id, replayed as-is
+// for repeated requests, including declined ones.
+type IdempotencyRecord struct {
+	Type     string         `json:"type"`
+	PlayerID int32          `json:"player_id"`
+	Amount   float64        `json:"amount"`
+	Response WalletResponse `json:"response"`
+}
+
+type snapshot struct {
+	Balances     map[int32]float64            `json:"balances"`
+	Idempotency  map[string]IdempotencyRecord `json:"idempotency"`
+	Transactions []Transaction                `json:"transactions"`
+}
 
 type Store struct {
-	balances map[int32]float64
-	idem     map[string]bool
-	mu       sync.Mutex
+	balances     map[int32]float64
+	idem         map[string]IdempotencyRecord
+	transactions []Transaction
+	snapshotPath string
+	mu           sync.Mutex
 }
 
-func NewStore() *Store {
+// NewStore loads snapshotPath when it exists; an empty path keeps state in
+// memory only.
+func NewStore(snapshotPath string) (*Store, error) {
 	s := &Store{
-		balances: make(map[int32]float64),
-		idem:     make(map[string]bool),
+		balances:     make(map[int32]float64),
+		idem:         make(map[string]IdempotencyRecord),
+		snapshotPath: snapshotPath,
+	}
+
+	if snapshotPath != "" {
+		raw, err := os.ReadFile(snapshotPath)
+		if err == nil {
+			var snap snapshot
+			if err := json.Unmarshal(raw, &snap); err != nil {
+				return nil, err
+			}
+			if snap.Balances != nil {
+				s.balances = snap.Balances
+			}
+			if snap.Idempotency != nil {
+				s.idem = snap.Idempotency
+			}
+			s.transactions = snap.Transactions
+			return s, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
 	}
 
 	// default balance for player with id 1
 	s.balances[1] = 100000
 
-	return s
+	return s, nil
+}
+
+// persist writes the snapshot atomically; callers must hold s.mu.
+func (s *Store) persist() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(snapshot{
+		Balances:     s.balances,
+		Idempotency:  s.idem,
+		Transactions: s.transactions,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshotPath), ".walletmock-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.snapshotPath)
 }
 
-func (s *Store) CheckIdempotent(key string) bool {
+func (s *Store) GetBalance(player int32) (float64, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.idem[key] {
-		return true
+	bal, ok := s.balances[player]
+	return bal, ok
+}
+
+func (s *Store) CreatePlayer(player int32, balance float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.balances[player]; ok {
+		return ErrPlayerExists
 	}
 
-	s.idem[key] = true
-	return false
+	s.balances[player] = balance
+	if err := s.persist(); err != nil {
+		delete(s.balances, player)
+		return err
+	}
+	return nil
 }
 
-func (s *Store) GetBalance(player int32) float64 {
+func (s *Store) SetBalance(player int32, balance float64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.balances[player]
+
+	prev, ok := s.balances[player]
+	if !ok {
+		return ErrPlayerNotFound
+	}
+
+	s.balances[player] = balance
+	if err := s.persist(); err != nil {
+		s.balances[player] = prev
+		return err
+	}
+	return nil
+}
+
+// record appends to the transaction log; callers must hold s.mu.
+func (s *Store) record(requestID string, player int32, txType string, amount, balance float64) {
+	s.transactions = append(s.transactions, Transaction{
+		ID:           int64(len(s.transactions) + 1),
+		RequestID:    requestID,
+		PlayerID:     player,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: balance,
+		CreatedAt:    time.Now().UTC(),
+	})
 }
 
-func (s *Store) Debit(player int32, amount float64) (ok bool, newBalance float64) {
+// Execute applies a debit or credit once per request_id. A repeated
+// request_id replays the stored response (replayed is true) without moving
+// money again; reusing it for a different movement is rejected.
+func (s *Store) Execute(txType string, req WalletRequest) (resp WalletResponse, replayed bool, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	bal := s.balances[player]
-	if bal < amount {
-		return false, bal
+	if rec, ok := s.idem[req.RequestID]; ok {
+		if rec.Type != txType || rec.PlayerID != req.PlayerID || rec.Amount != req.Amount {
+			return WalletResponse{}, false, ErrIdempotencyReused
+		}
+		return rec.Response, true, nil
 	}
 
-	newBal := bal - amount
-	s.balances[player] = newBal
-	return true, newBal
+	bal, existed := s.balances[req.PlayerID]
+	prev, logged := bal, len(s.transactions)
+
+	switch txType {
+	case "debit":
+		if bal < req.Amount {
+			resp = WalletResponse{Success: false, Balance: bal}
+			break
+		}
+		bal -= req.Amount
+		s.balances[req.PlayerID] = bal
+		s.record(req.RequestID, req.PlayerID, txType, req.Amount, bal)
+		resp = WalletResponse{Success: true, Balance: bal}
+	case "credit":
+		bal += req.Amount
+		s.balances[req.PlayerID] = bal
+		s.record(req.RequestID, req.PlayerID, txType, req.Amount, bal)
+		resp = WalletResponse{Success: true, Balance: bal}
+	}
+
+	s.idem[req.RequestID] = IdempotencyRecord{
+		Type:     txType,
+		PlayerID: req.PlayerID,
+		Amount:   req.Amount,
+		Response: resp,
+	}
+
+	// Undo a movement the snapshot does not hold: the caller gets an error
+	// and retries, and a restart must not forget money already reported
+	// as moved.
+	if err := s.persist(); err != nil {
+		if existed {
+			s.balances[req.PlayerID] = prev
+		} else {
+			delete(s.balances, req.PlayerID)
+		}
+		s.transactions = s.transactions[:logged]
+		delete(s.idem, req.RequestID)
+		return WalletResponse{}, false, err
+	}
+	return resp, false, nil
 }
 
-func (s *Store) Credit(player int32, amount float64) (bool, float64) {
+// Transactions returns the log entries created in [from, to).
+func (s *Store) Transactions(from, to time.Time) []Transaction {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.balances[player] += amount
-	return true, s.balances[player]
+	out := []Transaction{}
+	for _, t := range s.transactions {
+		if t.CreatedAt.Before(from) || !t.CreatedAt.Before(to) {
+			continue
+		}
+		out = append(out, t)
+	}
+
+	return out
+}
+
+// PlayerTransactions returns the newest entries first; player 0 means all.
+func (s *Store) PlayerTransactions(player int32, limit int) []Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []Transaction{}
+	for i := len(s.transactions) - 1; i >= 0 && len(out) < limit; i-- {
+		t := s.transactions[i]
+		if player != 0 && t.PlayerID != player {
+			continue
+		}
+		out = append(out, t)
+	}
+
+	return out
+}
+
+func (s *Store) Players() []Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Player, 0, len(s.balances))
+	for id, bal := range s.balances {
+		out = append(out, Player{PlayerID: id, Balance: bal})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PlayerID < out[j].PlayerID })
+
+	return out
 }
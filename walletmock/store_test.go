@@ -0,0 +1,232 @@
+package walletmock
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreReplaysTheOriginalResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		req  WalletRequest
+		// balanceBetween is set between the two calls, so a replay that
+		// re-ran the movement would answer differently.
+		balanceBetween float64
+		want           WalletResponse
+		wantBalance    float64
+	}{
+		{
+			name:           "debit",
+			kind:           "debit",
+			req:            WalletRequest{PlayerID: 2, Amount: 30, RequestID: "d1"},
+			balanceBetween: 500,
+			want:           WalletResponse{Success: true, Balance: 20},
+			wantBalance:    500,
+		},
+		{
+			name:           "declined debit",
+			kind:           "debit",
+			req:            WalletRequest{PlayerID: 2, Amount: 80, RequestID: "d2"},
+			balanceBetween: 500,
+			want:           WalletResponse{Success: false, Balance: 50},
+			wantBalance:    500,
+		},
+		{
+			name:           "credit",
+			kind:           "credit",
+			req:            WalletRequest{PlayerID: 2, Amount: 5, RequestID: "c1"},
+			balanceBetween: 0,
+			want:           WalletResponse{Success: true, Balance: 55},
+			wantBalance:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStore("")
+			if err != nil {
+				t.Fatalf("new store: %v", err)
+			}
+			if err := s.CreatePlayer(2, 50); err != nil {
+				t.Fatalf("create player: %v", err)
+			}
+
+			resp, replayed, err := s.Execute(tt.kind, tt.req)
+			if err != nil || replayed || resp != tt.want {
+				t.Fatalf("first: %+v replayed %v err %v, want %+v", resp, replayed, err, tt.want)
+			}
+			logged := len(s.PlayerTransactions(2, 100))
+
+			if err := s.SetBalance(2, tt.balanceBetween); err != nil {
+				t.Fatalf("set balance: %v", err)
+			}
+
+			resp, replayed, err = s.Execute(tt.kind, tt.req)
+			if err != nil || !replayed || resp != tt.want {
+				t.Fatalf("replay: %+v replayed %v err %v, want %+v", resp, replayed, err, tt.want)
+			}
+			if bal, _ := s.GetBalance(2); bal != tt.wantBalance {
+				t.Fatalf("balance %v after replay, want %v", bal, tt.wantBalance)
+			}
+			if got := len(s.PlayerTransactions(2, 100)); got != logged {
+				t.Fatalf("replay logged a transaction: %d, want %d", got, logged)
+			}
+
+			other := tt.req
+			other.Amount++
+			if _, _, err := s.Execute(tt.kind, other); !errors.Is(err, ErrIdempotencyReused) {
+				t.Fatalf("reused request id: err %v, want ErrIdempotencyReused", err)
+			}
+		})
+	}
+}
+
+func TestStoreRestartsFromSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	if err := s.CreatePlayer(2, 50); err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+	steps := []struct {
+		kind string
+		req  WalletRequest
+	}{
+		{"debit", WalletRequest{PlayerID: 2, Amount: 20, RequestID: "d1"}},
+		{"debit", WalletRequest{PlayerID: 2, Amount: 100, RequestID: "d2"}},
+		{"credit", WalletRequest{PlayerID: 2, Amount: 7.5, RequestID: "c1"}},
+	}
+	want := map[string]WalletResponse{}
+	for _, step := range steps {
+		resp, _, err := s.Execute(step.kind, step.req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", step.kind, step.req.RequestID, err)
+		}
+		want[step.req.RequestID] = resp
+	}
+
+	restarted, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+
+	if bal, ok := restarted.GetBalance(2); !ok || bal != 37.5 {
+		t.Fatalf("balance %v (%v) after restart, want 37.5", bal, ok)
+	}
+	if _, ok := restarted.GetBalance(1); !ok {
+		t.Fatal("default player lost on restart")
+	}
+	if got, want := restarted.PlayerTransactions(2, 100), s.PlayerTransactions(2, 100); len(got) != 2 || len(got) != len(want) || got[0].RequestID != want[0].RequestID {
+		t.Fatalf("transactions %+v after restart, want %+v", got, want)
+	}
+	for _, step := range steps {
+		resp, replayed, err := restarted.Execute(step.kind, step.req)
+		if err != nil || !replayed || resp != want[step.req.RequestID] {
+			t.Fatalf("replay %s after restart: %+v replayed %v err %v, want %+v",
+				step.req.RequestID, resp, replayed, err, want[step.req.RequestID])
+		}
+	}
+}
+
+func TestStoreRejectsCorruptSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	if err := os.WriteFile(path, []byte(`{"balances": `), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := NewStore(path); err == nil {
+		t.Fatal("loaded a corrupt snapshot")
+	}
+}
+
+// A write that does not reach the snapshot leaves the in-memory state as
+// it was, so a retry after the disk recovers applies the movement once.
+func TestStoreFailedWriteLeavesStateUnchanged(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	s, err := NewStore(filepath.Join(dir, "wallet.json"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	if err := s.CreatePlayer(2, 50); err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+
+	// Without its directory every snapshot write fails.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("remove snapshot dir: %v", err)
+	}
+
+	failures := []struct {
+		name string
+		do   func() error
+	}{
+		{"debit", func() error {
+			_, _, err := s.Execute("debit", WalletRequest{PlayerID: 2, Amount: 20, RequestID: "d1"})
+			return err
+		}},
+		{"credit to a new player", func() error {
+			_, _, err := s.Execute("credit", WalletRequest{PlayerID: 3, Amount: 5, RequestID: "c1"})
+			return err
+		}},
+		{"create player", func() error { return s.CreatePlayer(4, 10) }},
+		{"set balance", func() error { return s.SetBalance(2, 999) }},
+	}
+	for _, f := range failures {
+		if err := f.do(); err == nil {
+			t.Fatalf("%s: persisted without a snapshot directory", f.name)
+		}
+	}
+
+	if bal, _ := s.GetBalance(2); bal != 50 {
+		t.Fatalf("player 2 balance %v, want 50", bal)
+	}
+	for _, id := range []int32{3, 4} {
+		if _, ok := s.GetBalance(id); ok {
+			t.Fatalf("player %d exists after a failed write", id)
+		}
+	}
+	if got := s.PlayerTransactions(0, 100); len(got) != 0 {
+		t.Fatalf("transactions %+v after failed writes", got)
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	resp, replayed, err := s.Execute("debit", WalletRequest{PlayerID: 2, Amount: 20, RequestID: "d1"})
+	if err != nil || replayed || resp != (WalletResponse{Success: true, Balance: 30}) {
+		t.Fatalf("retry: %+v replayed %v err %v, want a fresh debit to 30", resp, replayed, err)
+	}
+}
+
+func TestFailedWriteIsAServerError(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(filepath.Join(dir, "wallet.json"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	srv := httptest.NewServer(NewServer(store, NewFaultInjector()).Handler())
+	defer srv.Close()
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("remove snapshot dir: %v", err)
+	}
+
+	status, _, err := move(t, http.DefaultClient, srv.URL, "debit", 1, 10, "d1")
+	if err != nil || status != http.StatusInternalServerError {
+		t.Fatalf("status %d err %v, want 500", status, err)
+	}
+	if bal, _ := store.GetBalance(1); bal != 100000 {
+		t.Fatalf("balance %v, want 100000", bal)
+	}
+}
@@ -0,0 +1,65 @@
+// Command example-receiver is a minimal operator-side webhook endpoint.
+// It verifies every delivery with webhooksdk and logs the decoded event.
+//
+//	WEBHOOK_SECRETS=new,old example-receiver -addr :8081
+//
+// List both secrets while a rotation overlaps, then drop the old one.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"rgs/webhooksdk"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "listen address")
+	flag.Parse()
+
+	var secrets []string
+	for _, s := range strings.Split(os.Getenv("WEBHOOK_SECRETS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) == 0 {
+		log.Fatal("WEBHOOK_SECRETS is not set")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhooks", webhooksdk.Middleware(secrets...)(http.HandlerFunc(handle)))
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	e, _ := webhooksdk.EnvelopeFromContext(r.Context())
+
+	// A real receiver would skip e.ID if it has seen it before: retries
+	// and redeliveries reuse it.
+	p, err := e.Decode()
+	if errors.Is(err, webhooksdk.ErrUnknownType) || errors.Is(err, webhooksdk.ErrUnsupportedVersion) {
+		// Acknowledge so RGS stops retrying; upgrade the SDK to handle it.
+		log.Printf("%s %s v%d: %v", e.ID, e.Type, e.Version, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	switch p := p.(type) {
+	case *webhooksdk.BetSettled:
+		log.Printf("%s bet %d %s, credit %s", e.ID, p.BetID, p.Status, p.CreditTxID)
+	case *webhooksdk.SettlementSucceeded:
+		log.Printf("%s bet %d credited %.2f", e.ID, p.BetID, p.Amount)
+	default:
+		log.Printf("%s %s %+v", e.ID, e.Type, p)
+	}
+}
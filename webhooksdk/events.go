@@ -0,0 +1,187 @@
+package webhooksdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope is the body of every webhook. ID is the domain event's id and
+// is the same on every retry and every endpoint, so receivers can dedupe
+// on it. Version is the schema version of Data for this Type.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int32           `json:"version"`
+	CreatedAt  time.Time       `json:"created_at"`
+	OperatorID int32           `json:"operator_id"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Webhook types. An endpoint subscribes to these, or to a prefix such as
+// "session.*".
+const (
+	TypeBetSettled        = "bet_settled"
+	TypeSettlementSuccess = "settlement_success"
+	TypeSettlementFailed  = "settlement.failed"
+	TypeSettlementDead    = "settlement.dead"
+	TypeRoundFinished     = "round.finished"
+	TypeWalletDebit       = "wallet.debit"
+	TypeSessionLaunched   = "session.launched"
+	TypeSessionVerified   = "session.verified"
+	TypeSessionRevoked    = "session.revoked"
+	TypePing              = "ping"
+)
+
+var (
+	ErrUnknownType        = errors.New("webhooksdk: unknown event type")
+	ErrUnsupportedVersion = errors.New("webhooksdk: unsupported event version")
+)
+
+// Payload is the Data of an envelope. Version is the newest schema
+// version of it this package understands.
+type Payload interface {
+	Version() int32
+}
+
+var payloads = map[string]func() Payload{
+	TypeBetSettled:        func() Payload { return new(BetSettled) },
+	TypeSettlementSuccess: func() Payload { return new(SettlementSucceeded) },
+	TypeSettlementFailed:  func() Payload { return new(SettlementFailed) },
+	TypeSettlementDead:    func() Payload { return new(SettlementDead) },
+	TypeRoundFinished:     func() Payload { return new(RoundFinished) },
+	TypeWalletDebit:       func() Payload { return new(WalletDebited) },
+	TypeSessionLaunched:   func() Payload { return new(SessionLaunched) },
+	TypeSessionVerified:   func() Payload { return new(SessionVerified) },
+	TypeSessionRevoked:    func() Payload { return new(SessionRevoked) },
+	TypePing:              func() Payload { return new(Ping) },
+}
+
+// Decode unmarshals Data into the struct for Type and returns a pointer
+// to it, e.g. *BetSettled for bet_settled. A Version newer than this
+// package knows fails with ErrUnsupportedVersion rather than decoding
+// fields that may have changed meaning.
+func (e *Envelope) Decode() (Payload, error) {
+	newPayload, ok := payloads[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, e.Type)
+	}
+
+	p := newPayload()
+	if e.Version > p.Version() {
+		return nil, fmt.Errorf("%w: %s version %d", ErrUnsupportedVersion, e.Type, e.Version)
+	}
+	if err := json.Unmarshal(e.Data, p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return p, nil
+}
+
+// RoundFinished is round.finished.
+type RoundFinished struct {
+	RoundID    int32  `json:"round_id"`
+	PlayerID   int32  `json:"player_id"`
+	ServerSeed string `json:"server_seed"`
+	ClientSeed string `json:"client_seed"`
+	Outcome    int32  `json:"outcome"`
+}
+
+func (RoundFinished) Version() int32 { return 1 }
+
+// WalletDebited is wallet.debit.
+type WalletDebited struct {
+	PlayerID int32   `json:"player_id"`
+	Amount   float64 `json:"amount"`
+}
+
+func (WalletDebited) Version() int32 { return 1 }
+
+// BetSettled is bet_settled, sent when a bet is won, lost or left pending
+// on the credit.
+type BetSettled struct {
+	BetID      int32   `json:"bet_id"`
+	RoundID    int32   `json:"round_id"`
+	PlayerID   int32   `json:"player_id"`
+	Amount     float64 `json:"amount"`
+	Status     string  `json:"status"`
+	CreditTxID string  `json:"credit_tx_id"`
+}
+
+func (BetSettled) Version() int32 { return 1 }
+
+// SettlementSucceeded is settlement_success, sent once a pending win is
+// credited.
+type SettlementSucceeded struct {
+	BetID      int32   `json:"bet_id"`
+	RoundID    int32   `json:"round_id"`
+	PlayerID   int32   `json:"player_id"`
+	Amount     float64 `json:"amount"`
+	Status     string  `json:"status"`
+	OutboxID   int32   `json:"outbox_id"`
+	CreditTxID string  `json:"credit_tx_id"`
+}
+
+func (SettlementSucceeded) Version() int32 { return 1 }
+
+// SettlementFailed is settlement.failed, a credit attempt that will be
+// retried.
+type SettlementFailed struct {
+	BetID    int32   `json:"bet_id"`
+	PlayerID int32   `json:"player_id"`
+	Amount   float64 `json:"amount"`
+	Error    string  `json:"error"`
+	OutboxID int32   `json:"outbox_id"`
+	Attempts int32   `json:"attempts"`
+	RetryIn  float64 `json:"retry_in"`
+}
+
+func (SettlementFailed) Version() int32 { return 1 }
+
+// SettlementDead is settlement.dead, a credit that exhausted its retries.
+type SettlementDead struct {
+	BetID      int32   `json:"bet_id"`
+	PlayerID   int32   `json:"player_id"`
+	Amount     float64 `json:"amount"`
+	Error      string  `json:"error"`
+	OutboxID   int32   `json:"outbox_id"`
+	Attempts   int32   `json:"attempts"`
+	CreditTxID string  `json:"credit_tx_id"`
+}
+
+func (SettlementDead) Version() int32 { return 1 }
+
+// SessionLaunched is session.launched.
+type SessionLaunched struct {
+	SessionID uuid.UUID `json:"session_id"`
+	PlayerID  int32     `json:"player_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (SessionLaunched) Version() int32 { return 1 }
+
+// SessionVerified is session.verified.
+type SessionVerified struct {
+	SessionID uuid.UUID `json:"session_id"`
+	PlayerID  int32     `json:"player_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (SessionVerified) Version() int32 { return 1 }
+
+// SessionRevoked is session.revoked.
+type SessionRevoked struct {
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+func (SessionRevoked) Version() int32 { return 1 }
+
+// Ping is ping, sent on demand by POST /webhooks/test whatever the
+// endpoint subscribes to. It is never queued or retried.
+type Ping struct {
+	EndpointID int32 `json:"endpoint_id"`
+}
+
+func (Ping) Version() int32 { return 1 }
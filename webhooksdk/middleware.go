@@ -0,0 +1,41 @@
+package webhooksdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// Middleware returns Verifier{Secrets: secrets}.Middleware.
+func Middleware(secrets ...string) func(http.Handler) http.Handler {
+	v := &Verifier{Secrets: secrets}
+	return v.Middleware
+}
+
+// Middleware verifies each request before next sees it. Bad signatures
+// and stale timestamps get 401, unreadable bodies 400. next finds the
+// envelope with EnvelopeFromContext.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e, err := v.Verify(r)
+		if errors.Is(err, ErrInvalidPayload) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, e)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// EnvelopeFromContext returns the envelope Middleware verified.
+func EnvelopeFromContext(ctx context.Context) (*Envelope, bool) {
+	e, ok := ctx.Value(contextKey{}).(*Envelope)
+	return e, ok
+}
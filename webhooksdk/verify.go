@@ -0,0 +1,154 @@
+// Package webhooksdk verifies and decodes the webhooks RGS sends to
+// operators.
+//
+// Every delivery is a JSON Envelope posted with two headers:
+//
+//	X-RGS-Timestamp: <unix seconds>
+//	X-RGS-Signature: v1=<hex>[,v1=<hex>]
+//
+// Each v1 entry is the hex HMAC-SHA256 of the timestamp followed by the
+// body, keyed with one of the endpoint's secrets. There are two entries
+// while a rotated secret is still in its overlap window.
+package webhooksdk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimestampHeader = "X-RGS-Timestamp"
+	SignatureHeader = "X-RGS-Signature"
+)
+
+// DefaultTolerance is how far a delivery's timestamp may be from the
+// receiver's clock. Older requests are treated as replays.
+const DefaultTolerance = 5 * time.Minute
+
+// MaxBodyBytes bounds how much of a request Verify reads.
+const MaxBodyBytes = 1 << 20
+
+var (
+	ErrNoSecrets          = errors.New("webhooksdk: no secrets configured")
+	ErrMissingHeaders     = errors.New("webhooksdk: missing timestamp or signature header")
+	ErrInvalidTimestamp   = errors.New("webhooksdk: invalid timestamp")
+	ErrTimestampTolerance = errors.New("webhooksdk: timestamp outside tolerance")
+	ErrInvalidSignature   = errors.New("webhooksdk: no signature matches")
+	ErrInvalidPayload     = errors.New("webhooksdk: invalid payload")
+)
+
+// Sign returns the X-RGS-Signature value for body: one v1 entry per
+// secret, in order. Receivers can use it to sign fixtures in tests.
+func Sign(timestamp string, body []byte, secrets ...string) string {
+	sigs := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		sigs = append(sigs, "v1="+hex.EncodeToString(mac(secret, timestamp, body)))
+	}
+	return strings.Join(sigs, ",")
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Verifier checks deliveries against a set of secrets. Configure every
+// secret that is live for the endpoint: during a rotation that is the new
+// one and, until the overlap ends, the old one.
+type Verifier struct {
+	Secrets []string
+	// Tolerance defaults to DefaultTolerance when zero.
+	Tolerance time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verify checks r with the given secrets and decodes its envelope. See
+// Verifier.Verify.
+func Verify(r *http.Request, secrets ...string) (*Envelope, error) {
+	v := Verifier{Secrets: secrets}
+	return v.Verify(r)
+}
+
+// Verify reads r's body, checks its timestamp and signature and decodes
+// the envelope. r.Body is replaced so handlers can read it again.
+func (v *Verifier) Verify(r *http.Request) (*Envelope, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = v.VerifyPayload(r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Envelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return &e, nil
+}
+
+// VerifyPayload checks a delivery's headers against its raw body. Use it
+// when the body has already been read.
+func (v *Verifier) VerifyPayload(timestamp, signature string, body []byte) error {
+	if len(v.Secrets) == 0 {
+		return ErrNoSecrets
+	}
+	if timestamp == "" || signature == "" {
+		return ErrMissingHeaders
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	if skew := now().Sub(time.Unix(unix, 0)).Abs(); skew > tolerance {
+		return ErrTimestampTolerance
+	}
+
+	// Entries with another scheme are skipped so receivers keep working
+	// when a new one is added next to v1.
+	var sigs [][]byte
+	for _, entry := range strings.Split(signature, ",") {
+		hexSig, ok := strings.CutPrefix(strings.TrimSpace(entry), "v1=")
+		if !ok {
+			continue
+		}
+		if sig, err := hex.DecodeString(hexSig); err == nil {
+			sigs = append(sigs, sig)
+		}
+	}
+
+	for _, secret := range v.Secrets {
+		expected := mac(secret, timestamp, body)
+		for _, sig := range sigs {
+			if hmac.Equal(expected, sig) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,126 @@
+package webhooksdk
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+const body = `{"id":"e1","type":"bet_settled","version":1,"operator_id":1,"data":{"bet_id":42,"status":"won"}}`
+
+func newRequest(timestamp time.Time, signature string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	r.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	r.Header.Set(SignatureHeader, signature)
+	return r
+}
+
+func signed(timestamp time.Time, secrets ...string) *http.Request {
+	return newRequest(timestamp, Sign(strconv.FormatInt(timestamp.Unix(), 10), []byte(body), secrets...))
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *http.Request
+		secrets []string
+		want    error
+	}{
+		{"valid", signed(now, "new"), []string{"new"}, nil},
+		{"any of several signatures", signed(now, "new", "old"), []string{"old"}, nil},
+		{"any of several secrets", signed(now, "old"), []string{"new", "old"}, nil},
+		{"unknown schemes skipped", newRequest(now, "v0=abc, "+Sign(strconv.FormatInt(now.Unix(), 10), []byte(body), "new")), []string{"new"}, nil},
+		{"wrong secret", signed(now, "other"), []string{"new"}, ErrInvalidSignature},
+		{"bare hex", newRequest(now, strings.TrimPrefix(Sign(strconv.FormatInt(now.Unix(), 10), []byte(body), "new"), "v1=")), []string{"new"}, ErrInvalidSignature},
+		{"stale", signed(now.Add(-DefaultTolerance-time.Second), "new"), []string{"new"}, ErrTimestampTolerance},
+		{"from the future", signed(now.Add(DefaultTolerance+time.Second), "new"), []string{"new"}, ErrTimestampTolerance},
+		{"missing signature", newRequest(now, ""), []string{"new"}, ErrMissingHeaders},
+		{"no secrets", signed(now, "new"), nil, ErrNoSecrets},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Verifier{Secrets: tt.secrets, Now: func() time.Time { return now }}
+			_, err := v.Verify(tt.req)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTamperedTimestamp(t *testing.T) {
+	r := signed(now, "new")
+	r.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix()+1, 10))
+
+	v := Verifier{Secrets: []string{"new"}, Now: func() time.Time { return now }}
+	if _, err := v.Verify(r); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestVerifyDecodesEnvelopeAndKeepsBody(t *testing.T) {
+	r := signed(now, "new")
+	v := Verifier{Secrets: []string{"new"}, Now: func() time.Time { return now }}
+
+	e, err := v.Verify(r)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if e.ID != "e1" || e.Type != TypeBetSettled || e.OperatorID != 1 {
+		t.Fatalf("envelope = %+v", e)
+	}
+
+	p, err := e.Decode()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	settled, ok := p.(*BetSettled)
+	if !ok || settled.BetID != 42 || settled.Status != "won" {
+		t.Fatalf("payload = %#v", p)
+	}
+
+	rest, _ := io.ReadAll(r.Body)
+	if string(rest) != body {
+		t.Fatalf("body after verify = %q", rest)
+	}
+}
+
+func TestDecodeRejectsUnknownTypeAndNewerVersion(t *testing.T) {
+	e := Envelope{Type: "bet.cancelled", Version: 1, Data: []byte(`{}`)}
+	if _, err := e.Decode(); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("unknown type: err = %v", err)
+	}
+
+	e = Envelope{Type: TypeBetSettled, Version: 2, Data: []byte(`{}`)}
+	if _, err := e.Decode(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("newer version: err = %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v := Verifier{Secrets: []string{"new"}, Now: func() time.Time { return now }}
+	var got *Envelope
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = EnvelopeFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signed(now, "other"))
+	if rec.Code != http.StatusUnauthorized || got != nil {
+		t.Fatalf("bad signature: status %d, handler ran = %v", rec.Code, got != nil)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signed(now, "new"))
+	if rec.Code != http.StatusOK || got == nil || got.ID != "e1" {
+		t.Fatalf("good signature: status %d, envelope %+v", rec.Code, got)
+	}
+}